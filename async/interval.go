@@ -9,6 +9,7 @@ import (
 
 /*
 NewInterval returns a new worker that runs an action on an interval.
+If the interval is not positive it defaults to `DefaultInterval`.
 
 Example:
 
@@ -19,11 +20,14 @@ Example:
 
 */
 func NewInterval(action ContextAction, interval time.Duration, options ...IntervalOption) *Interval {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
 	i := Interval{
 		Latch:    NewLatch(),
 		Action:   action,
 		Context:  context.Background(),
-		Interval: DefaultInterval,
+		Interval: interval,
 	}
 	for _, option := range options {
 		option(&i)
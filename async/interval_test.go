@@ -2,6 +2,7 @@ package async
 
 import (
 	"context"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -36,3 +37,21 @@ func TestIntervalWorker(t *testing.T) {
 	assert.True(w.IsStopped())
 	assert.True(didWork)
 }
+
+func TestNewIntervalInterval(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(time.Millisecond, NewInterval(nil, time.Millisecond).Interval)
+	assert.Equal(DefaultInterval, NewInterval(nil, 0).Interval)
+
+	var calls int32
+	w := NewInterval(func(_ context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}, 5*time.Millisecond)
+	go w.Start()
+	<-w.NotifyStarted()
+	time.Sleep(100 * time.Millisecond)
+	w.Stop()
+	assert.True(atomic.LoadInt32(&calls) > 2, "the action should run on the given interval")
+}
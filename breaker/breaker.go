@@ -0,0 +1,340 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/stats"
+)
+
+// New returns a new closed breaker.
+func New(options ...Option) *Breaker {
+	b := &Breaker{
+		Window:           DefaultWindow,
+		WindowBuckets:    DefaultWindowBuckets,
+		MinRequests:      DefaultMinRequests,
+		FailureRate:      DefaultFailureRate,
+		SlowRate:         DefaultSlowRate,
+		OpenTimeout:      DefaultOpenTimeout,
+		HalfOpenRequests: DefaultHalfOpenRequests,
+	}
+	for _, option := range options {
+		option(b)
+	}
+	return b
+}
+
+// Option is an option for breakers.
+type Option func(*Breaker)
+
+// OptConfig sets the breaker fields from a config, using defaults for unset fields.
+func OptConfig(cfg Config) Option {
+	return func(b *Breaker) {
+		b.Name = cfg.Name
+		b.Window = cfg.WindowOrDefault()
+		b.WindowBuckets = cfg.WindowBucketsOrDefault()
+		b.MinRequests = cfg.MinRequestsOrDefault()
+		b.FailureRate = cfg.FailureRateOrDefault()
+		b.SlowThreshold = cfg.SlowThreshold
+		b.SlowRate = cfg.SlowRateOrDefault()
+		b.OpenTimeout = cfg.OpenTimeoutOrDefault()
+		b.HalfOpenRequests = cfg.HalfOpenRequestsOrDefault()
+	}
+}
+
+// OptName sets the breaker name, which is included in errors, events and stats.
+func OptName(name string) Option {
+	return func(b *Breaker) { b.Name = name }
+}
+
+// OptWindow sets the duration of the rolling window and the number of buckets it is divided into.
+func OptWindow(window time.Duration, buckets int) Option {
+	return func(b *Breaker) {
+		b.Window = window
+		b.WindowBuckets = buckets
+	}
+}
+
+// OptMinRequests sets the minimum number of calls in the window before the breaker can open.
+func OptMinRequests(minRequests int64) Option {
+	return func(b *Breaker) { b.MinRequests = minRequests }
+}
+
+// OptFailureRate sets the rate of failed calls in the window, between 0 and 1, that opens the breaker.
+func OptFailureRate(failureRate float64) Option {
+	return func(b *Breaker) { b.FailureRate = failureRate }
+}
+
+// OptSlowCalls sets the latency above which calls are slow, and the rate of slow calls in the window that opens the breaker.
+func OptSlowCalls(threshold time.Duration, slowRate float64) Option {
+	return func(b *Breaker) {
+		b.SlowThreshold = threshold
+		b.SlowRate = slowRate
+	}
+}
+
+// OptOpenTimeout sets the duration the breaker stays open before allowing trial calls.
+func OptOpenTimeout(openTimeout time.Duration) Option {
+	return func(b *Breaker) { b.OpenTimeout = openTimeout }
+}
+
+// OptHalfOpenRequests sets the number of trial calls allowed, and required to succeed, while half-open.
+func OptHalfOpenRequests(halfOpenRequests int) Option {
+	return func(b *Breaker) { b.HalfOpenRequests = halfOpenRequests }
+}
+
+// OptLog sets the logger state change events are triggered on.
+func OptLog(log logger.Log) Option {
+	return func(b *Breaker) { b.Log = log }
+}
+
+// OptStats sets the stats collector state changes and rejected calls are reported to.
+func OptStats(collector stats.Collector) Option {
+	return func(b *Breaker) { b.Stats = collector }
+}
+
+// Breaker is a circuit breaker.
+/*
+A breaker starts closed, and tracks the outcomes of calls over a rolling window. Once the window has
+at least `MinRequests` calls, and the rate of failed calls reaches `FailureRate`, or the rate of calls slower than
+`SlowThreshold` reaches `SlowRate`, the breaker opens and calls fail fast with an `ErrOpen` error.
+
+After `OpenTimeout`, the breaker is half-open, and allows up to `HalfOpenRequests` trial calls at a time.
+If that many trial calls succeed the breaker closes, and if any of them fail, or are slow, it opens again.
+
+	b := breaker.New(breaker.OptName("payments"), breaker.OptLog(log))
+	err := b.Do(ctx, func(ctx context.Context) error {
+		return payments.Charge(ctx, charge)
+	})
+	if breaker.IsOpen(err) {
+		...
+	}
+
+Callers that need to inspect results to decide if a call failed can use `Allow` directly.
+*/
+type Breaker struct {
+	// Name is the breaker name, which is included in errors, events and stats.
+	Name string
+	// Window is the duration of the rolling window call outcomes are tracked over.
+	Window time.Duration
+	// WindowBuckets is the number of buckets the rolling window is divided into.
+	WindowBuckets int
+	// MinRequests is the minimum number of calls in the window before the breaker can open.
+	MinRequests int64
+	// FailureRate is the rate of failed calls in the window that opens the breaker.
+	FailureRate float64
+	// SlowThreshold is the latency above which calls are slow; slow calls are not tracked if it is unset.
+	SlowThreshold time.Duration
+	// SlowRate is the rate of slow calls in the window that opens the breaker.
+	SlowRate float64
+	// OpenTimeout is the duration the breaker stays open before allowing trial calls.
+	OpenTimeout time.Duration
+	// HalfOpenRequests is the number of trial calls allowed, and required to succeed, while half-open.
+	HalfOpenRequests int
+	// Log is an optional logger state change events are triggered on.
+	Log logger.Log
+	// Stats is an optional collector state changes and rejected calls are reported to.
+	Stats stats.Collector
+
+	mu                sync.Mutex
+	state             State
+	generation        uint64
+	window            *window
+	openedAt          time.Time
+	halfOpenInFlight  int
+	halfOpenSuccesses int
+}
+
+// State returns the current state.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.getState() == StateOpen && b.openTimeoutElapsed(time.Now()) {
+		return StateHalfOpen
+	}
+	return b.getState()
+}
+
+// Counts returns the call outcomes tracked over the rolling window.
+func (b *Breaker) Counts() Counts {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.getWindow().Counts(time.Now())
+}
+
+// Do calls an action if the breaker allows it, and records the outcome.
+// The action failed if it returns an error or panics.
+func (b *Breaker) Do(ctx context.Context, action func(context.Context) error) error {
+	done, err := b.Allow(ctx)
+	if err != nil {
+		return err
+	}
+	outcome := OutcomeFailure
+	defer func() { done(outcome) }()
+	if err = action(ctx); err == nil {
+		outcome = OutcomeSuccess
+	}
+	return err
+}
+
+// Allow returns if the breaker allows a call.
+// If it does, the returned function must be called with the outcome of the call once it finishes,
+// otherwise an `ErrOpen` error is returned.
+func (b *Breaker) Allow(ctx context.Context) (func(Outcome), error) {
+	b.mu.Lock()
+	now := time.Now()
+	var change *Event
+	if b.getState() == StateOpen && b.openTimeoutElapsed(now) {
+		change = b.setState(now, StateHalfOpen)
+	}
+
+	var err error
+	switch b.getState() {
+	case StateOpen:
+		err = ex.New(ErrOpen, ex.OptMessagef("breaker: %s", b.Name))
+	case StateHalfOpen:
+		if b.halfOpenInFlight >= b.halfOpenRequestsOrDefault() {
+			err = ex.New(ErrOpen, ex.OptMessagef("breaker: %s; half-open trial calls in flight", b.Name))
+		} else {
+			b.halfOpenInFlight++
+		}
+	}
+	generation := b.generation
+	b.mu.Unlock()
+
+	b.onStateChange(ctx, change)
+	if err != nil {
+		if b.Stats != nil {
+			logger.MaybeError(b.Log, b.Stats.Increment(MetricNameRejected, stats.Tag(TagBreaker, b.Name)))
+		}
+		return nil, err
+	}
+	return func(outcome Outcome) {
+		b.record(ctx, generation, outcome, time.Since(now))
+	}, nil
+}
+
+// record records the outcome of a call allowed during a given generation.
+// Outcomes of calls allowed before the last state change are ignored.
+func (b *Breaker) record(ctx context.Context, generation uint64, outcome Outcome, elapsed time.Duration) {
+	b.mu.Lock()
+	if generation != b.generation {
+		b.mu.Unlock()
+		return
+	}
+
+	now := time.Now()
+	failed := outcome == OutcomeFailure
+	slow := b.SlowThreshold > 0 && elapsed >= b.SlowThreshold
+	var change *Event
+	switch b.getState() {
+	case StateClosed:
+		if outcome == OutcomeIgnored {
+			break
+		}
+		b.getWindow().Add(now, failed, slow)
+		if b.shouldOpen(b.getWindow().Counts(now)) {
+			change = b.setState(now, StateOpen)
+		}
+	case StateHalfOpen:
+		b.halfOpenInFlight--
+		if outcome == OutcomeIgnored {
+			break
+		}
+		if failed || slow {
+			change = b.setState(now, StateOpen)
+			break
+		}
+		b.halfOpenSuccesses++
+		if b.halfOpenSuccesses >= b.halfOpenRequestsOrDefault() {
+			change = b.setState(now, StateClosed)
+		}
+	}
+	b.mu.Unlock()
+
+	b.onStateChange(ctx, change)
+}
+
+// shouldOpen returns if the counts reach the failure or slow rate.
+func (b *Breaker) shouldOpen(counts Counts) bool {
+	if counts.Requests == 0 || counts.Requests < b.MinRequests {
+		return false
+	}
+	if b.FailureRate > 0 && counts.FailureRate() >= b.FailureRate {
+		return true
+	}
+	if b.SlowThreshold > 0 && b.SlowRate > 0 && counts.SlowRate() >= b.SlowRate {
+		return true
+	}
+	return false
+}
+
+// setState changes the state and returns the state change event.
+// It must be called while holding the lock.
+func (b *Breaker) setState(now time.Time, state State) *Event {
+	change := NewEvent(b.Name, b.getState(), state, OptEventCounts(b.getWindow().Counts(now)))
+	b.state = state
+	b.generation++
+	b.halfOpenInFlight = 0
+	b.halfOpenSuccesses = 0
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.getWindow().Reset()
+	}
+	return change
+}
+
+// onStateChange triggers the state change event and reports it to stats.
+func (b *Breaker) onStateChange(ctx context.Context, change *Event) {
+	if change == nil {
+		return
+	}
+	logger.MaybeTrigger(ctx, b.Log, change)
+	if b.Stats != nil {
+		logger.MaybeError(b.Log, b.Stats.Increment(MetricNameStateChange,
+			stats.Tag(TagBreaker, b.Name),
+			stats.Tag(TagFrom, string(change.From)),
+			stats.Tag(TagTo, string(change.To)),
+		))
+	}
+}
+
+// getState returns the state, which is closed for the zero value.
+func (b *Breaker) getState() State {
+	if b.state == "" {
+		return StateClosed
+	}
+	return b.state
+}
+
+func (b *Breaker) openTimeoutElapsed(now time.Time) bool {
+	return now.Sub(b.openedAt) >= b.OpenTimeout
+}
+
+func (b *Breaker) halfOpenRequestsOrDefault() int {
+	if b.HalfOpenRequests > 0 {
+		return b.HalfOpenRequests
+	}
+	return DefaultHalfOpenRequests
+}
+
+// getWindow returns the rolling window, creating it if it hasn't been created yet.
+func (b *Breaker) getWindow() *window {
+	if b.window == nil {
+		buckets := b.WindowBuckets
+		if buckets <= 0 {
+			buckets = DefaultWindowBuckets
+		}
+		duration := b.Window
+		if duration <= 0 {
+			duration = DefaultWindow
+		}
+		b.window = newWindow(duration, buckets)
+	}
+	return b.window
+}
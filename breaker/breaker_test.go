@@ -0,0 +1,193 @@
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/stats"
+)
+
+func TestBreakerFailureRate(t *testing.T) {
+	assert := assert.New(t)
+
+	b := New(OptMinRequests(4), OptFailureRate(0.5), OptOpenTimeout(time.Hour))
+	for index := 0; index < 3; index++ {
+		assert.Nil(b.Do(context.Background(), func(_ context.Context) error { return nil }))
+	}
+	assert.Equal(StateClosed, b.State())
+
+	// 1 of 4 failed
+	assert.NotNil(b.Do(context.Background(), func(_ context.Context) error { return fmt.Errorf("failed") }))
+	assert.Equal(StateClosed, b.State())
+
+	// 2 of 5 failed
+	assert.NotNil(b.Do(context.Background(), func(_ context.Context) error { return fmt.Errorf("failed") }))
+	assert.Equal(StateClosed, b.State())
+
+	// 3 of 6 failed
+	assert.NotNil(b.Do(context.Background(), func(_ context.Context) error { return fmt.Errorf("failed") }))
+	assert.Equal(StateOpen, b.State())
+
+	var called bool
+	err := b.Do(context.Background(), func(_ context.Context) error { called = true; return nil })
+	assert.True(IsOpen(err))
+	assert.False(called)
+}
+
+func TestBreakerSlowCalls(t *testing.T) {
+	assert := assert.New(t)
+
+	b := New(OptMinRequests(2), OptSlowCalls(10*time.Millisecond, 0.5), OptOpenTimeout(time.Hour))
+	assert.Nil(b.Do(context.Background(), func(_ context.Context) error { return nil }))
+	assert.Nil(b.Do(context.Background(), func(_ context.Context) error { time.Sleep(20 * time.Millisecond); return nil }))
+	assert.Equal(StateOpen, b.State())
+	assert.Equal(Counts{Requests: 2, Slow: 1}, b.Counts())
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	assert := assert.New(t)
+
+	collector := &stats.MockCollector{Events: make(chan stats.MockMetric, 16)}
+	log := logger.MustNew(logger.OptAll(), logger.OptOutput(ioutil.Discard))
+	defer log.Close()
+	events := make(chan *Event, 16)
+	log.Listen(FlagStateChange, "test", NewEventListener(func(_ context.Context, e *Event) {
+		events <- e
+	}))
+
+	b := New(
+		OptName("test-breaker"),
+		OptMinRequests(1),
+		OptOpenTimeout(50*time.Millisecond),
+		OptHalfOpenRequests(2),
+		OptLog(log),
+		OptStats(collector),
+	)
+	assert.NotNil(b.Do(context.Background(), func(_ context.Context) error { return fmt.Errorf("failed") }))
+	assert.Equal(StateOpen, b.State())
+
+	e := <-events
+	assert.Equal("test-breaker", e.Name)
+	assert.Equal(StateClosed, e.From)
+	assert.Equal(StateOpen, e.To)
+	assert.Equal(1, e.Counts.Failures)
+	metric := <-collector.Events
+	assert.Equal(MetricNameStateChange, metric.Name)
+	assert.Equal([]string{"breaker:test-breaker", "from:closed", "to:open"}, metric.Tags)
+
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(StateHalfOpen, b.State())
+
+	first, err := b.Allow(context.Background())
+	assert.Nil(err)
+	second, err := b.Allow(context.Background())
+	assert.Nil(err)
+	_, err = b.Allow(context.Background())
+	assert.True(IsOpen(err), "only the configured number of trial calls should be allowed")
+	assert.Equal(StateHalfOpen, (<-events).To)
+
+	first(OutcomeSuccess)
+	assert.Equal(StateHalfOpen, b.State())
+	second(OutcomeSuccess)
+	assert.Equal(StateClosed, b.State())
+	assert.Equal(StateClosed, (<-events).To)
+
+	// a trial call failing re-opens the breaker.
+	assert.NotNil(b.Do(context.Background(), func(_ context.Context) error { return fmt.Errorf("failed") }))
+	time.Sleep(60 * time.Millisecond)
+	done, err := b.Allow(context.Background())
+	assert.Nil(err)
+	done(OutcomeFailure)
+	assert.Equal(StateOpen, b.State())
+}
+
+func TestBreakerIgnoresStaleOutcomes(t *testing.T) {
+	assert := assert.New(t)
+
+	b := New(OptMinRequests(1), OptOpenTimeout(time.Hour))
+	stale, err := b.Allow(context.Background())
+	assert.Nil(err)
+	assert.NotNil(b.Do(context.Background(), func(_ context.Context) error { return fmt.Errorf("failed") }))
+	assert.Equal(StateOpen, b.State())
+
+	stale(OutcomeSuccess)
+	assert.Equal(StateOpen, b.State())
+	assert.Equal(1, b.Counts().Failures)
+}
+
+func TestBreakerIgnoredOutcomes(t *testing.T) {
+	assert := assert.New(t)
+
+	b := New(OptMinRequests(1), OptOpenTimeout(50*time.Millisecond), OptHalfOpenRequests(1))
+	done, err := b.Allow(context.Background())
+	assert.Nil(err)
+	done(OutcomeIgnored)
+	assert.Equal(StateClosed, b.State())
+	assert.Zero(b.Counts().Requests, "ignored outcomes should not be tracked")
+
+	assert.NotNil(b.Do(context.Background(), func(_ context.Context) error { return fmt.Errorf("failed") }))
+	time.Sleep(60 * time.Millisecond)
+	done, err = b.Allow(context.Background())
+	assert.Nil(err)
+	done(OutcomeIgnored)
+	assert.Equal(StateHalfOpen, b.State())
+	done, err = b.Allow(context.Background())
+	assert.Nil(err, "ignored outcomes should free the trial call")
+	done(OutcomeSuccess)
+	assert.Equal(StateClosed, b.State())
+}
+
+func TestBreakerDoPanic(t *testing.T) {
+	assert := assert.New(t)
+
+	b := New(OptMinRequests(1), OptOpenTimeout(50*time.Millisecond), OptHalfOpenRequests(1))
+	assert.NotNil(b.Do(context.Background(), func(_ context.Context) error { return fmt.Errorf("failed") }))
+	time.Sleep(60 * time.Millisecond)
+
+	func() {
+		defer func() {
+			assert.NotNil(recover())
+		}()
+		b.Do(context.Background(), func(_ context.Context) error { panic("only a test") })
+	}()
+	assert.Equal(StateOpen, b.State(), "a panicking trial call should be recorded as failed")
+}
+
+func TestWindow(t *testing.T) {
+	assert := assert.New(t)
+
+	w := newWindow(10*time.Second, 10)
+	now := time.Date(2019, 10, 01, 12, 00, 00, 0, time.UTC)
+	w.Add(now, true, false)
+	w.Add(now.Add(500*time.Millisecond), false, true)
+	w.Add(now.Add(5*time.Second), false, false)
+	assert.Equal(Counts{Requests: 3, Failures: 1, Slow: 1}, w.Counts(now.Add(5*time.Second)))
+	assert.Equal(Counts{Requests: 3, Failures: 1, Slow: 1}, w.Counts(now.Add(9*time.Second)))
+	assert.Equal(Counts{Requests: 1}, w.Counts(now.Add(10*time.Second)))
+
+	// wrapping around the ring replaces the old bucket.
+	w.Add(now.Add(15*time.Second), true, false)
+	assert.Equal(Counts{Requests: 1, Failures: 1}, w.Counts(now.Add(15*time.Second)))
+	assert.Equal(1, w.Counts(now.Add(15*time.Second)).FailureRate())
+
+	w.Reset()
+	assert.Equal(Counts{}, w.Counts(now.Add(15*time.Second)))
+}
+
+func TestBreakerOptConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	b := New(OptConfig(Config{Name: "test-breaker", MinRequests: 5, SlowThreshold: time.Second}))
+	assert.Equal("test-breaker", b.Name)
+	assert.Equal(5, b.MinRequests)
+	assert.Equal(time.Second, b.SlowThreshold)
+	assert.Equal(DefaultFailureRate, b.FailureRate)
+	assert.Equal(DefaultOpenTimeout, b.OpenTimeout)
+	assert.Equal(StateClosed, b.State())
+	assert.Equal(StateClosed, new(Breaker).State())
+}
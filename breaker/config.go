@@ -0,0 +1,73 @@
+package breaker
+
+import "time"
+
+// Config is the configuration for a breaker.
+// Unset fields use the breaker defaults.
+type Config struct {
+	Name             string        `json:"name,omitempty" yaml:"name,omitempty"`
+	Window           time.Duration `json:"window,omitempty" yaml:"window,omitempty"`
+	WindowBuckets    int           `json:"windowBuckets,omitempty" yaml:"windowBuckets,omitempty"`
+	MinRequests      int64         `json:"minRequests,omitempty" yaml:"minRequests,omitempty"`
+	FailureRate      float64       `json:"failureRate,omitempty" yaml:"failureRate,omitempty"`
+	SlowThreshold    time.Duration `json:"slowThreshold,omitempty" yaml:"slowThreshold,omitempty"`
+	SlowRate         float64       `json:"slowRate,omitempty" yaml:"slowRate,omitempty"`
+	OpenTimeout      time.Duration `json:"openTimeout,omitempty" yaml:"openTimeout,omitempty"`
+	HalfOpenRequests int           `json:"halfOpenRequests,omitempty" yaml:"halfOpenRequests,omitempty"`
+}
+
+// WindowOrDefault returns the window or a default.
+func (c Config) WindowOrDefault() time.Duration {
+	if c.Window > 0 {
+		return c.Window
+	}
+	return DefaultWindow
+}
+
+// WindowBucketsOrDefault returns the window buckets or a default.
+func (c Config) WindowBucketsOrDefault() int {
+	if c.WindowBuckets > 0 {
+		return c.WindowBuckets
+	}
+	return DefaultWindowBuckets
+}
+
+// MinRequestsOrDefault returns the min requests or a default.
+func (c Config) MinRequestsOrDefault() int64 {
+	if c.MinRequests > 0 {
+		return c.MinRequests
+	}
+	return DefaultMinRequests
+}
+
+// FailureRateOrDefault returns the failure rate or a default.
+func (c Config) FailureRateOrDefault() float64 {
+	if c.FailureRate > 0 {
+		return c.FailureRate
+	}
+	return DefaultFailureRate
+}
+
+// SlowRateOrDefault returns the slow rate or a default.
+func (c Config) SlowRateOrDefault() float64 {
+	if c.SlowRate > 0 {
+		return c.SlowRate
+	}
+	return DefaultSlowRate
+}
+
+// OpenTimeoutOrDefault returns the open timeout or a default.
+func (c Config) OpenTimeoutOrDefault() time.Duration {
+	if c.OpenTimeout > 0 {
+		return c.OpenTimeout
+	}
+	return DefaultOpenTimeout
+}
+
+// HalfOpenRequestsOrDefault returns the half open requests or a default.
+func (c Config) HalfOpenRequestsOrDefault() int {
+	if c.HalfOpenRequests > 0 {
+		return c.HalfOpenRequests
+	}
+	return DefaultHalfOpenRequests
+}
@@ -0,0 +1,63 @@
+package breaker
+
+import "time"
+
+// State is a circuit breaker state.
+type State string
+
+// States
+const (
+	// StateClosed is the state calls are allowed and their outcomes are tracked.
+	StateClosed State = "closed"
+	// StateOpen is the state calls fail fast with `ErrOpen`.
+	StateOpen State = "open"
+	// StateHalfOpen is the state a limited number of trial calls are allowed to test if the dependency recovered.
+	StateHalfOpen State = "half-open"
+)
+
+// Outcome is the outcome of a call allowed by a breaker.
+type Outcome int
+
+// Outcomes
+const (
+	// OutcomeSuccess is the outcome of a call that succeeded.
+	OutcomeSuccess Outcome = iota
+	// OutcomeFailure is the outcome of a call that failed.
+	OutcomeFailure
+	// OutcomeIgnored is the outcome of a call that says nothing about the dependency, e.g. one canceled by the caller.
+	// It isn't tracked, but frees the trial call slot if the call was allowed while half-open.
+	OutcomeIgnored
+)
+
+// Defaults
+const (
+	// DefaultWindow is the default duration of the rolling window call outcomes are tracked over.
+	DefaultWindow = 10 * time.Second
+	// DefaultWindowBuckets is the default number of buckets the rolling window is divided into.
+	DefaultWindowBuckets = 10
+	// DefaultMinRequests is the default minimum number of calls in the window before the breaker can open.
+	DefaultMinRequests = 20
+	// DefaultFailureRate is the default rate of failed calls in the window that opens the breaker.
+	DefaultFailureRate = 0.5
+	// DefaultSlowRate is the default rate of slow calls in the window that opens the breaker, if slow calls are tracked.
+	DefaultSlowRate = 0.5
+	// DefaultOpenTimeout is the default duration the breaker stays open before allowing trial calls.
+	DefaultOpenTimeout = 30 * time.Second
+	// DefaultHalfOpenRequests is the default number of trial calls allowed, and required to succeed, while half-open.
+	DefaultHalfOpenRequests = 5
+)
+
+const (
+	// FlagStateChange is a logger flag for breaker state change events.
+	FlagStateChange = "breaker.state_change"
+)
+
+// Stats metric and tag names.
+const (
+	MetricNameStateChange string = FlagStateChange
+	MetricNameRejected    string = "breaker.rejected"
+
+	TagBreaker string = "breaker"
+	TagFrom    string = "from"
+	TagTo      string = "to"
+)
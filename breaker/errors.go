@@ -0,0 +1,14 @@
+package breaker
+
+import "github.com/blend/go-sdk/ex"
+
+const (
+	// ErrOpen is returned when a call is rejected because the breaker is open,
+	// or because it is half-open and the trial calls are already in flight.
+	ErrOpen ex.Class = "circuit breaker is open"
+)
+
+// IsOpen returns if an error is a breaker open error.
+func IsOpen(err error) bool {
+	return ex.Is(err, ErrOpen)
+}
@@ -0,0 +1,91 @@
+package breaker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/blend/go-sdk/ansi"
+	"github.com/blend/go-sdk/logger"
+)
+
+// these are compile time assertions
+var (
+	_ logger.Event        = (*Event)(nil)
+	_ logger.TextWritable = (*Event)(nil)
+	_ json.Marshaler      = (*Event)(nil)
+)
+
+// NewEventListener returns a new event listener.
+func NewEventListener(listener func(context.Context, *Event)) logger.Listener {
+	return func(ctx context.Context, e logger.Event) {
+		if typed, isTyped := e.(*Event); isTyped {
+			listener(ctx, typed)
+		}
+	}
+}
+
+// NewEvent returns a new state change event.
+func NewEvent(name string, from, to State, options ...EventOption) *Event {
+	e := &Event{
+		EventMeta: logger.NewEventMeta(FlagStateChange),
+		Name:      name,
+		From:      from,
+		To:        to,
+	}
+	for _, option := range options {
+		option(e)
+	}
+	return e
+}
+
+// EventOption is an option for an Event.
+type EventOption func(*Event)
+
+// OptEventCounts sets a field.
+func OptEventCounts(counts Counts) EventOption {
+	return func(e *Event) { e.Counts = counts }
+}
+
+// Event is a breaker state change event.
+type Event struct {
+	*logger.EventMeta
+
+	Name   string
+	From   State
+	To     State
+	Counts Counts
+}
+
+// WriteText implements logger.TextWritable.
+func (e Event) WriteText(tf logger.TextFormatter, wr io.Writer) {
+	if e.Name != "" {
+		io.WriteString(wr, fmt.Sprintf("[%s]", tf.Colorize(e.Name, ansi.ColorBlue)))
+		io.WriteString(wr, logger.Space)
+	}
+	io.WriteString(wr, fmt.Sprintf("%s -> %s", e.From, tf.Colorize(string(e.To), stateColor(e.To))))
+	io.WriteString(wr, logger.Space)
+	io.WriteString(wr, fmt.Sprintf("(requests: %d, failures: %d, slow: %d)", e.Counts.Requests, e.Counts.Failures, e.Counts.Slow))
+}
+
+// MarshalJSON implements json.Marshaler.
+func (e Event) MarshalJSON() ([]byte, error) {
+	return json.Marshal(logger.MergeDecomposed(e.EventMeta.Decompose(), map[string]interface{}{
+		"name":   e.Name,
+		"from":   e.From,
+		"to":     e.To,
+		"counts": e.Counts,
+	}))
+}
+
+func stateColor(state State) ansi.Color {
+	switch state {
+	case StateOpen:
+		return ansi.ColorRed
+	case StateHalfOpen:
+		return ansi.ColorYellow
+	default:
+		return ansi.ColorGreen
+	}
+}
@@ -0,0 +1,3 @@
+// Package breaker implements a circuit breaker that stops sending requests to a failing dependency.
+// It can be attached to `r2` requests with `r2.OptCircuitBreaker` and to `reverseproxy.Upstream`.
+package breaker
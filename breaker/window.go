@@ -0,0 +1,87 @@
+package breaker
+
+import "time"
+
+// Counts are the call outcomes tracked over the rolling window.
+type Counts struct {
+	Requests int64 `json:"requests"`
+	Failures int64 `json:"failures"`
+	Slow     int64 `json:"slow"`
+}
+
+// FailureRate returns the rate of failed calls.
+func (c Counts) FailureRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.Failures) / float64(c.Requests)
+}
+
+// SlowRate returns the rate of slow calls.
+func (c Counts) SlowRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.Slow) / float64(c.Requests)
+}
+
+// newWindow returns a new rolling window.
+func newWindow(duration time.Duration, buckets int) *window {
+	width := duration / time.Duration(buckets)
+	if width <= 0 {
+		width = 1
+	}
+	return &window{
+		width:   width,
+		buckets: make([]bucket, buckets),
+	}
+}
+
+// window is a rolling window of counts, divided into buckets of equal width.
+// It is not safe for concurrent use.
+type window struct {
+	width   time.Duration
+	buckets []bucket
+}
+
+type bucket struct {
+	start time.Time
+	Counts
+}
+
+// Add adds a call outcome to the bucket for a given time.
+func (w *window) Add(now time.Time, failed, slow bool) {
+	start := now.Truncate(w.width)
+	b := &w.buckets[int(start.UnixNano()/int64(w.width))%len(w.buckets)]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+	b.Requests++
+	if failed {
+		b.Failures++
+	}
+	if slow {
+		b.Slow++
+	}
+}
+
+// Counts returns the sum of the counts of the buckets within the window at a given time.
+func (w *window) Counts(now time.Time) (output Counts) {
+	oldest := now.Truncate(w.width).Add(-w.width * time.Duration(len(w.buckets)-1))
+	for _, b := range w.buckets {
+		if b.start.Before(oldest) {
+			continue
+		}
+		output.Requests += b.Requests
+		output.Failures += b.Failures
+		output.Slow += b.Slow
+	}
+	return
+}
+
+// Reset clears every bucket.
+func (w *window) Reset() {
+	for index := range w.buckets {
+		w.buckets[index] = bucket{}
+	}
+}
@@ -3,6 +3,7 @@ package certutil
 import (
 	"crypto/tls"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/blend/go-sdk/async"
@@ -64,7 +65,8 @@ func OptCertFileWatcherPollInterval(d time.Duration) CertFileWatcherOption {
 type CertFileWatcher struct {
 	*async.Latch
 
-	Certificate *tls.Certificate
+	certificateLock sync.RWMutex
+	Certificate     *tls.Certificate
 
 	CertPath     string
 	KeyPath      string
@@ -94,40 +96,55 @@ func (cw *CertFileWatcher) Reload() (err error) {
 		err = ex.New(loadErr)
 		return
 	}
+	cw.certificateLock.Lock()
 	cw.Certificate = &cert
+	cw.certificateLock.Unlock()
 	return
 }
 
 // GetCertificate gets the cached certificate, it blocks when the `cert` field is being updated
 func (cw *CertFileWatcher) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cw.certificateLock.RLock()
+	defer cw.certificateLock.RUnlock()
 	return cw.Certificate, nil
 }
 
-// Start watches the cert and triggers a reload on change
+// Start watches the cert and triggers a reload on change.
+// If a reload fails, e.g. because the cert has been written but the key hasn't yet, the previous
+// certificate is kept and the error is passed to the on reload handler; the next change retries the reload.
+// The files can also be briefly missing while they're rotated; errors reading them are passed to the
+// on reload handler the same way, and the key pair is reloaded once they can be read again.
 func (cw *CertFileWatcher) Start() error {
 	cw.Starting()
 
 	certLastMod, keyLastMod, err := cw.keyPairLastModified()
 	if err != nil {
+		cw.Stopped()
 		return err
 	}
 
 	ticker := time.Tick(cw.PollIntervalOrDefault())
 	cw.Started()
 	var certMod, keyMod time.Time
+	var statErr error
 	for {
 		select {
 		case <-ticker:
 			certMod, keyMod, err = cw.keyPairLastModified()
 			if err != nil {
-				return err
-			}
-			if keyMod.After(keyLastMod) || certMod.After(certLastMod) {
-				if err = cw.Reload(); err != nil {
-					return err
+				// only report the first error until the files can be read again.
+				if statErr == nil && cw.OnReload != nil {
+					cw.OnReload(cw, ex.New(err))
 				}
+				statErr = err
+				continue
+			}
+			if statErr != nil || keyMod.After(keyLastMod) || certMod.After(certLastMod) {
+				// reload errors are reported to the on reload handler.
+				_ = cw.Reload()
 				keyLastMod = keyMod
 				certLastMod = certMod
+				statErr = nil
 			}
 		case <-cw.NotifyStopping():
 			cw.Stopped()
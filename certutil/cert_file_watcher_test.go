@@ -3,7 +3,9 @@ package certutil
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/blend/go-sdk/assert"
 )
@@ -39,3 +41,102 @@ func TestCertFileWatcher(t *testing.T) {
 	assert.Nil(w.Reload())
 	assert.NotNil(w.Certificate)
 }
+
+func TestCertFileWatcherKeepsWatchingAfterReloadError(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	certPath := filepath.Join(tempDir, "cert.pem")
+	keyPath := filepath.Join(tempDir, "key.pem")
+	assert.Nil(ioutil.WriteFile(certPath, certLiteral, 0600))
+	assert.Nil(ioutil.WriteFile(keyPath, keyLiteral, 0600))
+
+	reloads := make(chan error, 8)
+	w, err := NewCertFileWatcher(certPath, keyPath,
+		OptCertFileWatcherPollInterval(time.Millisecond),
+		OptCertFileWatcherOnReload(func(_ *CertFileWatcher, err error) { reloads <- err }),
+	)
+	assert.Nil(err)
+	assert.Nil(<-reloads)
+	good := w.Certificate
+
+	go w.Start()
+	<-w.NotifyStarted()
+	defer w.Stop()
+
+	touch := func(path string, contents []byte, modTime time.Time) {
+		assert.Nil(ioutil.WriteFile(path, contents, 0600))
+		assert.Nil(os.Chtimes(path, modTime, modTime))
+	}
+
+	touch(certPath, []byte("not a cert"), time.Now().Add(time.Second))
+	assert.NotNil(<-reloads)
+	assert.True(good == w.Certificate, "the last good certificate should be kept")
+
+	touch(certPath, certLiteral, time.Now().Add(2*time.Second))
+	assert.Nil(<-reloads)
+	assert.True(w.IsStarted())
+}
+
+func TestCertFileWatcherKeepsWatchingAfterFilesRemoved(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	certPath := filepath.Join(tempDir, "cert.pem")
+	keyPath := filepath.Join(tempDir, "key.pem")
+	assert.Nil(ioutil.WriteFile(certPath, certLiteral, 0600))
+	assert.Nil(ioutil.WriteFile(keyPath, keyLiteral, 0600))
+
+	reloads := make(chan error, 8)
+	w, err := NewCertFileWatcher(certPath, keyPath,
+		OptCertFileWatcherPollInterval(time.Millisecond),
+		OptCertFileWatcherOnReload(func(_ *CertFileWatcher, err error) { reloads <- err }),
+	)
+	assert.Nil(err)
+	assert.Nil(<-reloads)
+
+	go w.Start()
+	<-w.NotifyStarted()
+
+	nextReload := func() error {
+		select {
+		case err := <-reloads:
+			return err
+		case <-time.After(5 * time.Second):
+			assert.FailNow("the watcher should keep watching the files")
+			return nil
+		}
+	}
+
+	assert.Nil(os.Remove(certPath))
+	assert.NotNil(nextReload(), "errors reading the files should be reported")
+	assert.Nil(ioutil.WriteFile(certPath, certLiteral, 0600))
+	assert.Nil(nextReload(), "the key pair should be reloaded once the files are back")
+	assert.True(w.IsStarted())
+
+	assert.Nil(os.Remove(certPath))
+	assert.NotNil(nextReload())
+	assert.Nil(w.Stop())
+}
+
+func TestCertFileWatcherStartError(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	certPath := filepath.Join(tempDir, "cert.pem")
+	keyPath := filepath.Join(tempDir, "key.pem")
+	assert.Nil(ioutil.WriteFile(certPath, certLiteral, 0600))
+	assert.Nil(ioutil.WriteFile(keyPath, keyLiteral, 0600))
+
+	w, err := NewCertFileWatcher(certPath, keyPath)
+	assert.Nil(err)
+	assert.Nil(os.Remove(keyPath))
+	assert.NotNil(w.Start())
+	assert.True(w.IsStopped(), "the watcher should be stopped if it can't start")
+}
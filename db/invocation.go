@@ -881,6 +881,7 @@ func (i *Invocation) Finish(statement string, r interface{}, err error) error {
 		qe.Database = i.Conn.Config.DatabaseOrDefault()
 		qe.QueryLabel = i.CachedPlanKey
 		qe.Engine = i.Conn.Config.EngineOrDefault()
+		qe.RequestID = logger.GetRequestID(i.Context)
 		qe.Err = err
 
 		i.Conn.Log.Trigger(i.Context, qe)
@@ -10,7 +10,12 @@ import (
 // NewManagementServer returns a new management server that lets you
 // trigger jobs or look at job statuses via. a json api.
 func NewManagementServer(jm *cron.JobManager, cfg Config, options ...web.Option) *web.App {
-	app := web.New(append([]web.Option{web.OptConfig(cfg.Web)}, options...)...)
+	// the job manager check is in process and cheap, so its results aren't cached.
+	health := web.NewHealth(
+		web.OptHealthCacheTTL(0),
+		web.OptHealthCheck("jobManager", web.HealthCheckStarted(jm), web.OptHealthCheckLiveness(true)),
+	)
+	app := web.New(append([]web.Option{web.OptConfig(cfg.Web), web.OptHealth(health)}, options...)...)
 	app.Views.AddLiterals(
 		headerTemplate,
 		footerTemplate,
@@ -20,12 +25,6 @@ func NewManagementServer(jm *cron.JobManager, cfg Config, options ...web.Option)
 	app.GET("/", func(r *web.Ctx) web.Result {
 		return r.Views.View("index", jm.Status())
 	})
-	app.GET("/healthz", func(_ *web.Ctx) web.Result {
-		if jm.IsStarted() {
-			return web.JSON.OK()
-		}
-		return web.JSON.InternalError(fmt.Errorf("job manager is stopped or in an inconsistent state"))
-	})
 	app.GET("/api/jobs", func(_ *web.Ctx) web.Result {
 		return web.JSON.Result(jm.Status())
 	})
@@ -63,7 +63,7 @@ func TestManagementServerHealthz(t *testing.T) {
 
 	meta, err = web.MockGet(app, "/healthz").DiscardWithResponse()
 	assert.Nil(err)
-	assert.Equal(http.StatusInternalServerError, meta.StatusCode)
+	assert.Equal(http.StatusServiceUnavailable, meta.StatusCode)
 }
 
 func TestManagementServerIndex(t *testing.T) {
@@ -72,6 +72,7 @@ const (
 	FieldTimestamp = "_timestamp"
 	FieldMessage   = "message"
 	FieldFields    = "fields"
+	FieldRequestID = "requestID"
 )
 
 // JSON Formatter defaults
@@ -63,12 +63,22 @@ func (sc Context) WithFields(fields Fields, options ...ContextOption) Context {
 
 // Trigger triggers an event in the subcontext.
 func (sc Context) Trigger(ctx context.Context, event Event) {
-	sc.Logger.trigger(WithSubContextMeta(ctx, sc.Path, sc.Fields), event, false)
+	sc.Logger.trigger(sc.withSubContextMeta(ctx), event, false)
 }
 
 // SyncTrigger triggers an event in the subcontext synchronously..
 func (sc Context) SyncTrigger(ctx context.Context, event Event) {
-	sc.Logger.trigger(WithSubContextMeta(ctx, sc.Path, sc.Fields), event, true)
+	sc.Logger.trigger(sc.withSubContextMeta(ctx), event, true)
+}
+
+// withSubContextMeta adds the subcontext path and fields to a context.
+// Fields already on the context, e.g. a request id, are kept unless the subcontext overrides them.
+func (sc Context) withSubContextMeta(ctx context.Context) context.Context {
+	var fields Fields
+	if ctx != nil {
+		_, fields = GetSubContextMeta(ctx)
+	}
+	return WithSubContextMeta(ctx, sc.Path, MergeFields(fields, sc.Fields))
 }
 
 // --------------------------------------------------------------------------------
@@ -28,3 +28,43 @@ func GetSubContextMeta(ctx context.Context) (path []string, fields Fields) {
 	}
 	return
 }
+
+// WithSubContextFields adds fields to the sub context fields of a context, keeping its path and existing fields.
+func WithSubContextFields(ctx context.Context, fields Fields) context.Context {
+	var path []string
+	var existing Fields
+	if ctx != nil {
+		path, existing = GetSubContextMeta(ctx)
+	}
+	return WithSubContextMeta(ctx, path, MergeFields(existing, fields))
+}
+
+// WithRequestID adds a request id to the sub context fields of a context.
+// Events triggered with the context include the request id.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return WithSubContextFields(ctx, Fields{FieldRequestID: requestID})
+}
+
+// GetRequestID returns the request id from the sub context fields of a context.
+func GetRequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	_, fields := GetSubContextMeta(ctx)
+	return fields[FieldRequestID]
+}
+
+// MergeFields merges sets of fields into a new set, with later sets taking precedence.
+// It returns nil if every set is empty.
+func MergeFields(sets ...Fields) Fields {
+	var output Fields
+	for _, set := range sets {
+		for key, value := range set {
+			if output == nil {
+				output = make(Fields)
+			}
+			output[key] = value
+		}
+	}
+	return output
+}
@@ -25,6 +25,9 @@ func NewHTTPRequestEvent(req *http.Request, options ...HTTPRequestEventOption) *
 	for _, option := range options {
 		option(hre)
 	}
+	if hre.RequestID == "" && hre.Request != nil {
+		hre.RequestID = GetRequestID(hre.Request.Context())
+	}
 	return hre
 }
 
@@ -56,12 +59,21 @@ func OptHTTPRequestEventRequest(req *http.Request) HTTPRequestEventOption {
 	}
 }
 
+// OptHTTPRequestEventRequestID sets a field on an HTTPRequestEvent.
+// It defaults to the request id on the request context.
+func OptHTTPRequestEventRequestID(requestID string) HTTPRequestEventOption {
+	return func(hre *HTTPRequestEvent) {
+		hre.RequestID = requestID
+	}
+}
+
 // HTTPRequestEvent is an event type for http responses.
 type HTTPRequestEvent struct {
 	*EventMeta
-	Request *http.Request
-	Route   string
-	State   map[interface{}]interface{}
+	Request   *http.Request
+	Route     string
+	RequestID string
+	State     map[interface{}]interface{}
 }
 
 // WriteText implements TextWritable.
@@ -77,5 +89,6 @@ func (e *HTTPRequestEvent) MarshalJSON() ([]byte, error) {
 		"host":      e.Request.Host,
 		"ip":        webutil.GetRemoteAddr(e.Request),
 		"userAgent": webutil.GetUserAgent(e.Request),
+		"requestID": e.RequestID,
 	}))
 }
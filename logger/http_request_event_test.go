@@ -1 +1,27 @@
 package logger
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestHTTPRequestEventRequestID(t *testing.T) {
+	assert := assert.New(t)
+
+	req, err := http.NewRequest("GET", "http://localhost/foo", nil)
+	assert.Nil(err)
+	req = req.WithContext(WithRequestID(context.Background(), "test-request-id"))
+
+	hre := NewHTTPRequestEvent(req)
+	assert.Equal("test-request-id", hre.RequestID)
+
+	contents, err := json.Marshal(hre)
+	assert.Nil(err)
+	assert.Contains(string(contents), `"requestID":"test-request-id"`)
+
+	assert.Equal("other-request-id", NewHTTPRequestEvent(req, OptHTTPRequestEventRequestID("other-request-id")).RequestID)
+}
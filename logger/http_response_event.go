@@ -26,6 +26,9 @@ func NewHTTPResponseEvent(req *http.Request, options ...HTTPResponseEventOption)
 	for _, option := range options {
 		option(hre)
 	}
+	if hre.RequestID == "" && hre.Request != nil {
+		hre.RequestID = GetRequestID(hre.Request.Context())
+	}
 	return hre
 }
 
@@ -55,6 +58,12 @@ func OptHTTPResponseRequest(req *http.Request) HTTPResponseEventOption {
 	return func(hre *HTTPResponseEvent) { hre.Request = req }
 }
 
+// OptHTTPResponseRequestID sets a field.
+// It defaults to the request id on the request context.
+func OptHTTPResponseRequestID(requestID string) HTTPResponseEventOption {
+	return func(hre *HTTPResponseEvent) { hre.RequestID = requestID }
+}
+
 // OptHTTPResponseRoute sets a field.
 func OptHTTPResponseRoute(route string) HTTPResponseEventOption {
 	return func(hre *HTTPResponseEvent) { hre.Route = route }
@@ -91,6 +100,7 @@ type HTTPResponseEvent struct {
 
 	Request         *http.Request
 	Route           string
+	RequestID       string
 	ContentLength   int
 	ContentType     string
 	ContentEncoding string
@@ -118,5 +128,6 @@ func (e HTTPResponseEvent) MarshalJSON() ([]byte, error) {
 		"contentEncoding": e.ContentEncoding,
 		"statusCode":      e.StatusCode,
 		"elapsed":         timeutil.Milliseconds(e.Elapsed),
+		"requestID":       e.RequestID,
 	}))
 }
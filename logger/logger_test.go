@@ -89,6 +89,29 @@ func TestLoggerE2ESubContextFields(t *testing.T) {
 	assert.Contains(output.String(), fmt.Sprintf("[info] this is a triggered message\t%s=%s", fieldKey, fieldValue))
 }
 
+func TestLoggerE2ERequestID(t *testing.T) {
+	assert := assert.New(t)
+
+	output := new(bytes.Buffer)
+	log, err := New(
+		OptOutput(output),
+		OptText(OptTextHideTimestamp(), OptTextNoColor()),
+	)
+	assert.Nil(err)
+
+	ctx := WithRequestID(context.Background(), "test-request-id")
+	assert.Equal("test-request-id", GetRequestID(ctx))
+	assert.Empty(GetRequestID(context.Background()))
+
+	// request ids on the context are kept alongside sub context fields.
+	log.WithFields(Fields{"foo": "bar"}).Trigger(ctx, NewMessageEvent(Info, "this is a triggered message"))
+	assert.Nil(log.DrainContext(context.Background()))
+
+	assert.Contains(output.String(), "[info] this is a triggered message")
+	assert.Contains(output.String(), "foo=bar")
+	assert.Contains(output.String(), FieldRequestID+"=test-request-id")
+}
+
 func TestLoggerSkipTrigger(t *testing.T) {
 	assert := assert.New(t)
 
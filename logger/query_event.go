@@ -46,6 +46,7 @@ type QueryEvent struct {
 	Engine     string
 	Username   string
 	QueryLabel string
+	RequestID  string
 	Body       string
 	Elapsed    time.Duration
 	Err        error
@@ -94,5 +95,6 @@ func (e QueryEvent) MarshalJSON() ([]byte, error) {
 		"body":       e.Body,
 		"err":        e.Err,
 		"elapsed":    timeutil.Milliseconds(e.Elapsed),
+		"requestID":  e.RequestID,
 	}))
 }
@@ -0,0 +1,405 @@
+package r2
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCacheMaxBodySize is the default maximum size of response bodies stored by a cache transport.
+const DefaultCacheMaxBodySize = 1 << 20
+
+var (
+	_ http.RoundTripper = (*CacheTransport)(nil)
+)
+
+// cacheableStatusCodes are the status codes that are cacheable by default.
+var cacheableStatusCodes = map[int]bool{
+	http.StatusOK:                   true,
+	http.StatusNonAuthoritativeInfo: true,
+	http.StatusNoContent:            true,
+	http.StatusMultipleChoices:      true,
+	http.StatusMovedPermanently:     true,
+	http.StatusNotFound:             true,
+	http.StatusMethodNotAllowed:     true,
+	http.StatusGone:                 true,
+	http.StatusRequestURITooLong:    true,
+	http.StatusNotImplemented:       true,
+}
+
+// OptCache caches responses in a storage, e.g. `NewLRUCacheStorage(0)`.
+// The storage should be shared between requests for the cache to be useful.
+// It wraps the client transport, so it should be set after any options that configure the transport.
+func OptCache(storage CacheStorage) Option {
+	return func(r *Request) error {
+		if r.Client == nil {
+			r.Client = &http.Client{}
+		}
+		r.Client.Transport = NewCacheTransport(r.Client.Transport, storage)
+		return nil
+	}
+}
+
+// NewCacheTransport returns a new cache transport.
+// If the transport is nil, `http.DefaultTransport` is used, and if the storage is nil, a new lru storage is used.
+func NewCacheTransport(transport http.RoundTripper, storage CacheStorage) *CacheTransport {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if storage == nil {
+		storage = NewLRUCacheStorage(DefaultCacheMaxEntries)
+	}
+	return &CacheTransport{
+		Transport:   transport,
+		Storage:     storage,
+		MaxBodySize: DefaultCacheMaxBodySize,
+	}
+}
+
+// CacheTransport is a shared http cache, as described by RFC 7234, that wraps a round tripper.
+/*
+Responses to GET requests are stored if their status code is cacheable, they don't have a `no-store`
+directive, and they have an explicit freshness lifetime (`s-maxage`, `max-age` or `Expires`) or a validator
+(`ETag` or `Last-Modified`).
+
+The storage is shared by every request made with it, which may be made for different users, so responses
+with a `private` directive are not stored, and responses to requests with an `Authorization` header are
+only stored if they're explicitly shareable with a `public`, `s-maxage` or `must-revalidate` directive. Fresh responses are served from the storage; stale responses are revalidated with
+`If-None-Match` and `If-Modified-Since`, and a `304 Not Modified` refreshes the stored response.
+
+Stored responses are keyed by url; responses with a `Vary` header are only served to requests with
+the same values for the headers it names, and `Vary: *` responses are not stored.
+Successful unsafe requests, e.g. POST, remove the stored response for their url.
+
+The request `no-store`, `no-cache`, `max-age`, `max-stale` and `min-fresh` directives are honored.
+Heuristic freshness is not used, so responses without an explicit lifetime are always revalidated.
+*/
+type CacheTransport struct {
+	// Transport sends requests that aren't served from the storage.
+	Transport http.RoundTripper
+	// Storage stores responses.
+	Storage CacheStorage
+	// MaxBodySize is the maximum size of response bodies that are stored.
+	MaxBodySize int64
+}
+
+// RoundTrip implements http.RoundTripper.
+func (ct *CacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := req.URL.String()
+	if req.Method != http.MethodGet && req.Method != "" {
+		res, err := ct.Transport.RoundTrip(req)
+		if err == nil && !isSafeMethod(req.Method) && res.StatusCode < http.StatusBadRequest {
+			ct.Storage.Delete(key)
+		}
+		return res, err
+	}
+
+	requestCacheControl := ParseCacheControl(req.Header)
+	if requestCacheControl.Has(CacheControlNoStore) || isConditional(req) || req.Header.Get(HeaderRange) != "" {
+		return ct.Transport.RoundTrip(req)
+	}
+
+	now := time.Now().UTC()
+	outgoing := req
+	cached, ok := ct.Storage.Get(key)
+	if ok && !cached.MatchesVary(req) {
+		cached, ok = nil, false
+	}
+	if ok {
+		noCache := requestCacheControl.Has(CacheControlNoCache) ||
+			(len(req.Header[HeaderCacheControl]) == 0 && strings.EqualFold(req.Header.Get(HeaderPragma), CacheControlNoCache))
+		if !noCache {
+			if fresh, stale := cached.isFresh(requestCacheControl, now); fresh {
+				return cached.Response(req, now, stale), nil
+			}
+		}
+		if !cached.HasValidators() {
+			cached, ok = nil, false
+		} else {
+			outgoing = withValidators(req, cached)
+		}
+	}
+
+	res, err := ct.Transport.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	responseTime := time.Now().UTC()
+
+	if ok && res.StatusCode == http.StatusNotModified {
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+		refreshed := cached.refresh(res.Header, now, responseTime)
+		ct.Storage.Set(key, refreshed)
+		return refreshed.Response(req, responseTime, false), nil
+	}
+
+	if !isCacheable(req, res) {
+		if ParseCacheControl(res.Header).Has(CacheControlNoStore) {
+			ct.Storage.Delete(key)
+		}
+		return res, nil
+	}
+
+	header := cloneHeader(res.Header)
+	varyHeader := varyHeaderValues(req, header)
+	statusCode := res.StatusCode
+	res.Body = &cachingBody{
+		ReadCloser:  res.Body,
+		maxBodySize: ct.MaxBodySize,
+		onEOF: func(body []byte) {
+			ct.Storage.Set(key, &CachedResponse{
+				StatusCode:   statusCode,
+				Header:       header,
+				Body:         body,
+				VaryHeader:   varyHeader,
+				RequestTime:  now,
+				ResponseTime: responseTime,
+			})
+		},
+	}
+	return res, nil
+}
+
+// MatchesVary returns if a request has the same values as the stored response's request
+// for the headers named by the response `Vary` header.
+func (cr *CachedResponse) MatchesVary(req *http.Request) bool {
+	for _, name := range varyHeaderNames(cr.Header) {
+		if name == "*" {
+			return false
+		}
+		if strings.Join(req.Header[name], ",") != strings.Join(cr.VaryHeader[name], ",") {
+			return false
+		}
+	}
+	return true
+}
+
+// HasValidators returns if the response has an `ETag` or `Last-Modified` header, so it can be revalidated.
+func (cr *CachedResponse) HasValidators() bool {
+	return cr.Header.Get(HeaderETag) != "" || cr.Header.Get(HeaderLastModified) != ""
+}
+
+// FreshnessLifetime returns how long the response is fresh for after it was generated,
+// from the `s-maxage` or `max-age` directives or the `Expires` header.
+func (cr *CachedResponse) FreshnessLifetime() time.Duration {
+	cc := ParseCacheControl(cr.Header)
+	if cc.Has(CacheControlNoCache) {
+		return 0
+	}
+	if sMaxAge, ok := cc.Duration(CacheControlSMaxAge); ok {
+		return sMaxAge
+	}
+	if maxAge, ok := cc.Duration(CacheControlMaxAge); ok {
+		return maxAge
+	}
+	if expires := cr.Header.Get(HeaderExpires); expires != "" {
+		expiresAt, err := http.ParseTime(expires)
+		if err != nil {
+			return 0
+		}
+		return expiresAt.Sub(cr.date())
+	}
+	return 0
+}
+
+// Age returns the current age of the response, as described by RFC 7234 section 4.2.3.
+func (cr *CachedResponse) Age(now time.Time) time.Duration {
+	apparentAge := cr.ResponseTime.Sub(cr.date())
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+	var ageValue time.Duration
+	if seconds, err := strconv.ParseInt(cr.Header.Get(HeaderAge), 10, 64); err == nil && seconds > 0 {
+		ageValue = time.Duration(seconds) * time.Second
+	}
+	correctedAgeValue := ageValue + cr.ResponseTime.Sub(cr.RequestTime)
+	initialAge := apparentAge
+	if correctedAgeValue > initialAge {
+		initialAge = correctedAgeValue
+	}
+	return initialAge + now.Sub(cr.ResponseTime)
+}
+
+// Response returns a new response for the stored response.
+func (cr *CachedResponse) Response(req *http.Request, now time.Time, stale bool) *http.Response {
+	header := cloneHeader(cr.Header)
+	header.Set(HeaderAge, strconv.FormatInt(int64(cr.Age(now)/time.Second), 10))
+	if stale {
+		header.Add(HeaderWarning, `110 - "Response is Stale"`)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", cr.StatusCode, http.StatusText(cr.StatusCode)),
+		StatusCode:    cr.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(cr.Body)),
+		ContentLength: int64(len(cr.Body)),
+		Request:       req,
+	}
+}
+
+// isFresh returns if the response can be served to a request with the given directives,
+// and if it is served stale because of a `max-stale` directive.
+func (cr *CachedResponse) isFresh(requestCacheControl CacheControl, now time.Time) (fresh, stale bool) {
+	lifetime := cr.FreshnessLifetime()
+	age := cr.Age(now)
+	if maxAge, ok := requestCacheControl.Duration(CacheControlMaxAge); ok && age > maxAge {
+		return false, false
+	}
+	if minFresh, ok := requestCacheControl.Duration(CacheControlMinFresh); ok {
+		age = age + minFresh
+	}
+	if age < lifetime {
+		return true, false
+	}
+	if requestCacheControl.Has(CacheControlMaxStale) && !ParseCacheControl(cr.Header).Has(CacheControlMustRevalidate) {
+		if maxStale, ok := requestCacheControl.Duration(CacheControlMaxStale); !ok || age-lifetime <= maxStale {
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// refresh returns a copy of the response updated with the headers of a `304 Not Modified` response.
+func (cr *CachedResponse) refresh(header http.Header, requestTime, responseTime time.Time) *CachedResponse {
+	refreshed := *cr
+	refreshed.Header = cloneHeader(cr.Header)
+	for key, values := range header {
+		if key == "Content-Length" {
+			continue
+		}
+		refreshed.Header[key] = values
+	}
+	refreshed.RequestTime = requestTime
+	refreshed.ResponseTime = responseTime
+	return &refreshed
+}
+
+// date returns the `Date` header of the response, or the time it was received.
+func (cr *CachedResponse) date() time.Time {
+	if date, err := http.ParseTime(cr.Header.Get(HeaderDate)); err == nil {
+		return date
+	}
+	return cr.ResponseTime
+}
+
+// cachingBody is a response body that calls a function with the full body once it has been read to EOF,
+// unless it is larger than the max body size.
+type cachingBody struct {
+	io.ReadCloser
+	buffer      bytes.Buffer
+	maxBodySize int64
+	onEOF       func([]byte)
+}
+
+// Read implements io.Reader.
+func (cb *cachingBody) Read(p []byte) (n int, err error) {
+	n, err = cb.ReadCloser.Read(p)
+	if cb.onEOF == nil {
+		return
+	}
+	if cb.maxBodySize > 0 && int64(cb.buffer.Len()+n) > cb.maxBodySize {
+		cb.onEOF = nil
+		cb.buffer = bytes.Buffer{}
+		return
+	}
+	cb.buffer.Write(p[:n])
+	if err == io.EOF {
+		cb.onEOF(cb.buffer.Bytes())
+		cb.onEOF = nil
+	}
+	return
+}
+
+// isCacheable returns if a response to a GET request may be stored by a shared cache.
+func isCacheable(req *http.Request, res *http.Response) bool {
+	if !cacheableStatusCodes[res.StatusCode] {
+		return false
+	}
+	cc := ParseCacheControl(res.Header)
+	if cc.Has(CacheControlNoStore) || cc.Has(CacheControlPrivate) {
+		return false
+	}
+	if req.Header.Get(HeaderAuthorization) != "" &&
+		!cc.Has(CacheControlPublic) && !cc.Has(CacheControlSMaxAge) && !cc.Has(CacheControlMustRevalidate) {
+		return false
+	}
+	for _, name := range varyHeaderNames(res.Header) {
+		if name == "*" {
+			return false
+		}
+	}
+	if cc.Has(CacheControlSMaxAge) || cc.Has(CacheControlMaxAge) || res.Header.Get(HeaderExpires) != "" {
+		return true
+	}
+	return res.Header.Get(HeaderETag) != "" || res.Header.Get(HeaderLastModified) != ""
+}
+
+// withValidators returns a copy of a request with conditional headers for a stored response.
+func withValidators(req *http.Request, cached *CachedResponse) *http.Request {
+	outgoing := new(http.Request)
+	*outgoing = *req
+	outgoing.Header = cloneHeader(req.Header)
+	if etag := cached.Header.Get(HeaderETag); etag != "" {
+		outgoing.Header.Set(HeaderIfNoneMatch, etag)
+	}
+	if lastModified := cached.Header.Get(HeaderLastModified); lastModified != "" {
+		outgoing.Header.Set(HeaderIfModifiedSince, lastModified)
+	}
+	return outgoing
+}
+
+// varyHeaderNames returns the canonical header names in the `Vary` header.
+func varyHeaderNames(header http.Header) (output []string) {
+	for _, value := range header[HeaderVary] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				output = append(output, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return
+}
+
+// varyHeaderValues returns the request values of the headers named by a response `Vary` header.
+func varyHeaderValues(req *http.Request, header http.Header) http.Header {
+	names := varyHeaderNames(header)
+	if len(names) == 0 {
+		return nil
+	}
+	output := http.Header{}
+	for _, name := range names {
+		if values, ok := req.Header[name]; ok {
+			output[name] = append([]string(nil), values...)
+		}
+	}
+	return output
+}
+
+func isConditional(req *http.Request) bool {
+	return req.Header.Get(HeaderIfNoneMatch) != "" || req.Header.Get(HeaderIfModifiedSince) != ""
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func cloneHeader(header http.Header) http.Header {
+	output := make(http.Header, len(header))
+	for key, values := range header {
+		output[key] = append([]string(nil), values...)
+	}
+	return output
+}
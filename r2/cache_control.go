@@ -0,0 +1,65 @@
+package r2
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache-Control directives.
+const (
+	CacheControlNoStore        = "no-store"
+	CacheControlNoCache        = "no-cache"
+	CacheControlMaxAge         = "max-age"
+	CacheControlMaxStale       = "max-stale"
+	CacheControlMinFresh       = "min-fresh"
+	CacheControlMustRevalidate = "must-revalidate"
+	CacheControlPublic         = "public"
+	CacheControlPrivate        = "private"
+	CacheControlSMaxAge        = "s-maxage"
+)
+
+// CacheControl is a parsed `Cache-Control` header, mapping lowercased directive names to their arguments.
+type CacheControl map[string]string
+
+// ParseCacheControl parses the `Cache-Control` directives of a header.
+func ParseCacheControl(header http.Header) CacheControl {
+	cc := CacheControl{}
+	for _, value := range header[HeaderCacheControl] {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+			var name, argument string
+			if index := strings.Index(directive, "="); index >= 0 {
+				name, argument = directive[:index], strings.Trim(strings.TrimSpace(directive[index+1:]), `"`)
+			} else {
+				name = directive
+			}
+			cc[strings.ToLower(strings.TrimSpace(name))] = argument
+		}
+	}
+	return cc
+}
+
+// Has returns if the directive is present.
+func (cc CacheControl) Has(directive string) bool {
+	_, ok := cc[directive]
+	return ok
+}
+
+// Duration returns the delta-seconds argument of a directive as a duration.
+// It returns false if the directive is missing or its argument is not a number of seconds.
+func (cc CacheControl) Duration(directive string) (time.Duration, bool) {
+	argument, ok := cc[directive]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(argument, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
@@ -0,0 +1,113 @@
+package r2
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultCacheMaxEntries is the default maximum number of responses held by an lru cache storage.
+const DefaultCacheMaxEntries = 1024
+
+var (
+	_ CacheStorage = (*LRUCacheStorage)(nil)
+)
+
+// CacheStorage stores responses for a cache transport.
+// Implementations must be safe for concurrent use.
+type CacheStorage interface {
+	Get(key string) (*CachedResponse, bool)
+	Set(key string, response *CachedResponse)
+	Delete(key string)
+}
+
+// CachedResponse is a stored response.
+type CachedResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	// VaryHeader are the values of the request headers named by the response `Vary` header.
+	VaryHeader http.Header
+	// RequestTime is when the request the response was received for was sent.
+	RequestTime time.Time
+	// ResponseTime is when the response was received.
+	ResponseTime time.Time
+}
+
+// NewLRUCacheStorage returns a new in-memory cache storage that holds at most `maxEntries` responses.
+// If `maxEntries` is not positive it defaults to `DefaultCacheMaxEntries`.
+func NewLRUCacheStorage(maxEntries int) *LRUCacheStorage {
+	if maxEntries <= 0 {
+		maxEntries = DefaultCacheMaxEntries
+	}
+	return &LRUCacheStorage{
+		MaxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+// LRUCacheStorage is a bounded, in-memory cache storage that evicts the least recently used responses when it is full.
+type LRUCacheStorage struct {
+	sync.Mutex
+	MaxEntries int
+
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+type lruCacheEntry struct {
+	key      string
+	response *CachedResponse
+}
+
+// Get returns a stored response for a key.
+func (lcs *LRUCacheStorage) Get(key string) (*CachedResponse, bool) {
+	lcs.Lock()
+	defer lcs.Unlock()
+
+	element, ok := lcs.entries[key]
+	if !ok {
+		return nil, false
+	}
+	lcs.lru.MoveToFront(element)
+	return element.Value.(*lruCacheEntry).response, true
+}
+
+// Set adds or replaces a stored response, evicting the least recently used responses if the storage is full.
+func (lcs *LRUCacheStorage) Set(key string, response *CachedResponse) {
+	lcs.Lock()
+	defer lcs.Unlock()
+
+	if element, ok := lcs.entries[key]; ok {
+		element.Value.(*lruCacheEntry).response = response
+		lcs.lru.MoveToFront(element)
+		return
+	}
+	lcs.entries[key] = lcs.lru.PushFront(&lruCacheEntry{key: key, response: response})
+	for lcs.MaxEntries > 0 && lcs.lru.Len() > lcs.MaxEntries {
+		lcs.removeElement(lcs.lru.Back())
+	}
+}
+
+// Delete removes a stored response.
+func (lcs *LRUCacheStorage) Delete(key string) {
+	lcs.Lock()
+	defer lcs.Unlock()
+	if element, ok := lcs.entries[key]; ok {
+		lcs.removeElement(element)
+	}
+}
+
+// Len returns the number of stored responses.
+func (lcs *LRUCacheStorage) Len() int {
+	lcs.Lock()
+	defer lcs.Unlock()
+	return lcs.lru.Len()
+}
+
+func (lcs *LRUCacheStorage) removeElement(element *list.Element) {
+	lcs.lru.Remove(element)
+	delete(lcs.entries, element.Value.(*lruCacheEntry).key)
+}
@@ -0,0 +1,272 @@
+package r2
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestOptCacheMaxAge(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		call := atomic.AddInt32(&calls, 1)
+		rw.Header().Set(HeaderCacheControl, "public, max-age=60")
+		fmt.Fprintf(rw, "call %d", call)
+	}))
+	defer server.Close()
+
+	storage := NewLRUCacheStorage(0)
+	contents, err := New(server.URL, OptCache(storage)).Bytes()
+	assert.Nil(err)
+	assert.Equal("call 1", string(contents))
+
+	contents, res, err := New(server.URL, OptCache(storage)).BytesWithResponse()
+	assert.Nil(err)
+	assert.Equal("call 1", string(contents))
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.NotEmpty(res.Header.Get(HeaderAge))
+	assert.Equal(1, calls)
+
+	// the request max-age can require a fresher response.
+	// the stored response can't be revalidated without validators, so it is replaced.
+	contents, err = New(server.URL, OptCache(storage), OptHeaderValue(HeaderCacheControl, "max-age=0")).Bytes()
+	assert.Nil(err)
+	assert.Equal("call 2", string(contents))
+
+	// request no-store bypasses the cache.
+	contents, err = New(server.URL, OptCache(storage), OptHeaderValue(HeaderCacheControl, "no-store")).Bytes()
+	assert.Nil(err)
+	assert.Equal("call 3", string(contents))
+
+	// unsafe requests invalidate the stored response.
+	_, err = New(server.URL, OptCache(storage), OptPost()).Bytes()
+	assert.Nil(err)
+	assert.Equal(0, storage.Len())
+	contents, err = New(server.URL, OptCache(storage)).Bytes()
+	assert.Nil(err)
+	assert.Equal("call 5", string(contents))
+}
+
+func TestOptCacheRevalidation(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rw.Header().Set(HeaderCacheControl, "no-cache")
+		rw.Header().Set(HeaderETag, `"v1"`)
+		if req.Header.Get(HeaderIfNoneMatch) == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fmt.Fprint(rw, "contents")
+	}))
+	defer server.Close()
+
+	storage := NewLRUCacheStorage(0)
+	for index := 0; index < 3; index++ {
+		contents, res, err := New(server.URL, OptCache(storage)).BytesWithResponse()
+		assert.Nil(err)
+		assert.Equal(http.StatusOK, res.StatusCode)
+		assert.Equal("contents", string(contents))
+	}
+	assert.Equal(3, calls)
+	assert.Equal(2, notModified)
+
+	// conditional requests from the caller are passed through.
+	res, err := New(server.URL, OptCache(storage), OptHeaderValue(HeaderIfNoneMatch, `"v1"`)).Do()
+	assert.Nil(err)
+	defer res.Body.Close()
+	assert.Equal(http.StatusNotModified, res.StatusCode)
+}
+
+func TestOptCacheNoStore(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rw.Header().Set(HeaderCacheControl, "no-store, max-age=60")
+		fmt.Fprint(rw, "contents")
+	}))
+	defer server.Close()
+
+	storage := NewLRUCacheStorage(0)
+	for index := 0; index < 2; index++ {
+		_, err := New(server.URL, OptCache(storage)).Bytes()
+		assert.Nil(err)
+	}
+	assert.Equal(2, calls)
+	assert.Equal(0, storage.Len())
+}
+
+func TestOptCacheVary(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rw.Header().Set(HeaderCacheControl, "max-age=60")
+		rw.Header().Set(HeaderVary, "Accept-Language")
+		fmt.Fprint(rw, req.Header.Get("Accept-Language"))
+	}))
+	defer server.Close()
+
+	storage := NewLRUCacheStorage(0)
+	get := func(language string) string {
+		contents, err := New(server.URL, OptCache(storage), OptHeaderValue("Accept-Language", language)).Bytes()
+		assert.Nil(err)
+		return string(contents)
+	}
+
+	assert.Equal("en", get("en"))
+	assert.Equal("en", get("en"))
+	assert.Equal(1, calls)
+	assert.Equal("fr", get("fr"))
+	assert.Equal(2, calls)
+}
+
+func TestOptCacheAuthorization(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	var cacheControl atomic.Value
+	cacheControl.Store("max-age=60")
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rw.Header().Set(HeaderCacheControl, cacheControl.Load().(string))
+		fmt.Fprint(rw, req.Header.Get(HeaderAuthorization))
+	}))
+	defer server.Close()
+
+	storage := NewLRUCacheStorage(0)
+	get := func(token string) string {
+		contents, err := New(server.URL, OptCache(storage), OptHeaderValue(HeaderAuthorization, token)).Bytes()
+		assert.Nil(err)
+		return string(contents)
+	}
+
+	// responses to authorized requests aren't shared unless they say they can be.
+	assert.Equal("Bearer a", get("Bearer a"))
+	assert.Equal("Bearer b", get("Bearer b"))
+	assert.Equal(2, calls)
+	assert.Equal(0, storage.Len())
+
+	cacheControl.Store("public, max-age=60")
+	assert.Equal("Bearer a", get("Bearer a"))
+	assert.Equal("Bearer a", get("Bearer b"))
+	assert.Equal(3, calls)
+
+	storage = NewLRUCacheStorage(0)
+	cacheControl.Store("s-maxage=60")
+	assert.Equal("Bearer a", get("Bearer a"))
+	assert.Equal("Bearer a", get("Bearer b"))
+	assert.Equal(4, calls)
+}
+
+func TestOptCachePrivate(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rw.Header().Set(HeaderCacheControl, "private, max-age=60")
+		fmt.Fprint(rw, "contents")
+	}))
+	defer server.Close()
+
+	storage := NewLRUCacheStorage(0)
+	for index := 0; index < 2; index++ {
+		_, err := New(server.URL, OptCache(storage)).Bytes()
+		assert.Nil(err)
+	}
+	assert.Equal(2, calls)
+	assert.Equal(0, storage.Len())
+}
+
+func TestCachedResponseFreshness(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2019, 10, 01, 12, 00, 00, 0, time.UTC)
+	cached := &CachedResponse{
+		StatusCode: http.StatusOK,
+		Header: http.Header{
+			HeaderDate:         []string{now.Add(-10 * time.Second).Format(http.TimeFormat)},
+			HeaderExpires:      []string{now.Add(50 * time.Second).Format(http.TimeFormat)},
+			HeaderAge:          []string{"5"},
+			HeaderCacheControl: []string{"public"},
+		},
+		RequestTime:  now.Add(-time.Second),
+		ResponseTime: now,
+	}
+	assert.Equal(time.Minute, cached.FreshnessLifetime())
+	assert.Equal(10*time.Second, cached.Age(now))
+	assert.Equal(40*time.Second, cached.Age(now.Add(30*time.Second)))
+
+	fresh, stale := cached.isFresh(CacheControl{}, now.Add(30*time.Second))
+	assert.True(fresh)
+	assert.False(stale)
+
+	fresh, _ = cached.isFresh(CacheControl{CacheControlMaxAge: "30"}, now.Add(30*time.Second))
+	assert.False(fresh)
+
+	fresh, _ = cached.isFresh(CacheControl{CacheControlMinFresh: "30"}, now.Add(30*time.Second))
+	assert.False(fresh)
+
+	fresh, stale = cached.isFresh(CacheControl{CacheControlMaxStale: "60"}, now.Add(90*time.Second))
+	assert.True(fresh)
+	assert.True(stale)
+
+	cached.Header.Set(HeaderCacheControl, "max-age=60, s-maxage=120")
+	assert.Equal(2*time.Minute, cached.FreshnessLifetime(), "s-maxage should take precedence for a shared cache")
+
+	cached.Header.Set(HeaderCacheControl, CacheControlMustRevalidate)
+	fresh, _ = cached.isFresh(CacheControl{CacheControlMaxStale: ""}, now.Add(90*time.Second))
+	assert.False(fresh)
+
+	res := cached.Response(nil, now.Add(30*time.Second), true)
+	assert.Equal("40", res.Header.Get(HeaderAge))
+	assert.NotEmpty(res.Header.Get(HeaderWarning))
+}
+
+func TestParseCacheControl(t *testing.T) {
+	assert := assert.New(t)
+
+	cc := ParseCacheControl(http.Header{HeaderCacheControl: []string{`Public, MAX-AGE="60"`, "no-cache"}})
+	assert.True(cc.Has(CacheControlPublic))
+	assert.True(cc.Has(CacheControlNoCache))
+	maxAge, ok := cc.Duration(CacheControlMaxAge)
+	assert.True(ok)
+	assert.Equal(time.Minute, maxAge)
+	_, ok = cc.Duration(CacheControlMaxStale)
+	assert.False(ok)
+}
+
+func TestLRUCacheStorage(t *testing.T) {
+	assert := assert.New(t)
+
+	storage := NewLRUCacheStorage(2)
+	storage.Set("a", &CachedResponse{StatusCode: http.StatusOK})
+	storage.Set("b", &CachedResponse{StatusCode: http.StatusOK})
+	_, ok := storage.Get("a")
+	assert.True(ok)
+	storage.Set("c", &CachedResponse{StatusCode: http.StatusOK})
+	assert.Equal(2, storage.Len())
+
+	_, ok = storage.Get("b")
+	assert.False(ok, "the least recently used response should be evicted")
+	_, ok = storage.Get("a")
+	assert.True(ok)
+
+	storage.Delete("a")
+	_, ok = storage.Get("a")
+	assert.False(ok)
+}
@@ -22,6 +22,36 @@ const (
 	HeaderConnection = "Connection"
 	// HeaderContentType is a http header.
 	HeaderContentType = "Content-Type"
+	// HeaderContentDisposition is a http header.
+	HeaderContentDisposition = "Content-Disposition"
+	// HeaderRetryAfter is a http header.
+	HeaderRetryAfter = "Retry-After"
+	// HeaderCacheControl is a http header.
+	HeaderCacheControl = "Cache-Control"
+	// HeaderPragma is a http header.
+	HeaderPragma = "Pragma"
+	// HeaderExpires is a http header.
+	HeaderExpires = "Expires"
+	// HeaderDate is a http header.
+	HeaderDate = "Date"
+	// HeaderAge is a http header.
+	HeaderAge = "Age"
+	// HeaderVary is a http header.
+	HeaderVary = "Vary"
+	// HeaderETag is a http header.
+	HeaderETag = "ETag"
+	// HeaderLastModified is a http header.
+	HeaderLastModified = "Last-Modified"
+	// HeaderIfNoneMatch is a http header.
+	HeaderIfNoneMatch = "If-None-Match"
+	// HeaderIfModifiedSince is a http header.
+	HeaderIfModifiedSince = "If-Modified-Since"
+	// HeaderRange is a http header.
+	HeaderRange = "Range"
+	// HeaderWarning is a http header.
+	HeaderWarning = "Warning"
+	// HeaderAuthorization is a http header.
+	HeaderAuthorization = "Authorization"
 )
 
 const (
@@ -38,4 +68,6 @@ const (
 	ContentTypeApplicationFormEncoded = webutil.ContentTypeApplicationFormEncoded
 	// ContentTypeApplicationOctetStream is a content type header value.
 	ContentTypeApplicationOctetStream = webutil.ContentTypeApplicationOctetStream
+	// ContentTypeMultipartFormData is a content type header value.
+	ContentTypeMultipartFormData = "multipart/form-data"
 )
@@ -0,0 +1,38 @@
+package r2
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/blend/go-sdk/breaker"
+)
+
+// OptCircuitBreaker sets a circuit breaker for the request.
+// Requests fail fast with a `breaker.ErrOpen` error while the breaker is open.
+// Attempts that fail with an error or a 5xx status code are recorded as failures, attempts canceled by the
+// caller are ignored, and each attempt of a request with a retry policy is checked and recorded separately.
+func OptCircuitBreaker(b *breaker.Breaker) Option {
+	return func(r *Request) error {
+		r.CircuitBreaker = b
+		return nil
+	}
+}
+
+// CircuitBreakerOutcome returns the outcome a circuit breaker should record for an attempt.
+// Attempts canceled by the caller are ignored, as they don't reflect the health of the upstream.
+func CircuitBreakerOutcome(res *http.Response, err error) breaker.Outcome {
+	if err != nil {
+		if typed, ok := err.(*url.Error); ok {
+			err = typed.Err
+		}
+		if err == context.Canceled {
+			return breaker.OutcomeIgnored
+		}
+		return breaker.OutcomeFailure
+	}
+	if res != nil && res.StatusCode >= http.StatusInternalServerError {
+		return breaker.OutcomeFailure
+	}
+	return breaker.OutcomeSuccess
+}
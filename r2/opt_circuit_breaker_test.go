@@ -0,0 +1,101 @@
+package r2
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/breaker"
+)
+
+func TestOptCircuitBreaker(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	b := breaker.New(breaker.OptMinRequests(2), breaker.OptOpenTimeout(time.Hour))
+	res, err := New(server.URL,
+		OptCircuitBreaker(b),
+		OptRetry(OptRetryMaxAttempts(5), OptRetryBackoff(time.Millisecond, time.Millisecond)),
+	).Do()
+	assert.Nil(res)
+	assert.True(breaker.IsOpen(err))
+	assert.Equal(2, calls, "the breaker should open after the second failed attempt")
+	assert.Equal(breaker.StateOpen, b.State())
+
+	_, err = New(server.URL, OptCircuitBreaker(b)).Do()
+	assert.True(breaker.IsOpen(err))
+	assert.Equal(2, calls)
+}
+
+func TestOptCircuitBreakerOnRequestError(t *testing.T) {
+	assert := assert.New(t)
+
+	var healthy int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		if atomic.LoadInt32(&healthy) == 0 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	b := breaker.New(breaker.OptMinRequests(1), breaker.OptOpenTimeout(10*time.Millisecond), breaker.OptHalfOpenRequests(1))
+	err := New(server.URL, OptCircuitBreaker(b)).Discard()
+	assert.Nil(err)
+	assert.Equal(breaker.StateOpen, b.State())
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(20 * time.Millisecond)
+
+	// a request listener error shouldn't use up the half-open trial request.
+	err = New(server.URL, OptCircuitBreaker(b), OptOnRequest(func(_ *http.Request) error {
+		return fmt.Errorf("listener failed")
+	})).Discard()
+	assert.NotNil(err)
+	assert.False(breaker.IsOpen(err))
+
+	res, err := New(server.URL, OptCircuitBreaker(b)).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(breaker.StateClosed, b.State())
+}
+
+func TestOptCircuitBreakerCanceled(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b := breaker.New(breaker.OptMinRequests(1), breaker.OptOpenTimeout(time.Hour))
+	err := New(server.URL, OptCircuitBreaker(b), OptContext(ctx)).Discard()
+	assert.NotNil(err)
+	assert.Equal(breaker.StateClosed, b.State(), "caller cancellations shouldn't open the breaker")
+	assert.Zero(b.Counts().Requests, "caller cancellations shouldn't be tracked")
+}
+
+func TestCircuitBreakerOutcome(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(breaker.OutcomeFailure, CircuitBreakerOutcome(nil, http.ErrHandlerTimeout))
+	assert.Equal(breaker.OutcomeFailure, CircuitBreakerOutcome(nil, &url.Error{Op: "Get", URL: "http://localhost", Err: context.DeadlineExceeded}))
+	assert.Equal(breaker.OutcomeIgnored, CircuitBreakerOutcome(nil, &url.Error{Op: "Get", URL: "http://localhost", Err: context.Canceled}))
+	assert.Equal(breaker.OutcomeFailure, CircuitBreakerOutcome(&http.Response{StatusCode: http.StatusBadGateway}, nil))
+	assert.Equal(breaker.OutcomeSuccess, CircuitBreakerOutcome(&http.Response{StatusCode: http.StatusNotFound}, nil))
+	assert.Equal(breaker.OutcomeSuccess, CircuitBreakerOutcome(&http.Response{StatusCode: http.StatusOK}, nil))
+}
@@ -0,0 +1,215 @@
+package r2
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+var (
+	_ io.ReadCloser = (*MultipartBody)(nil)
+
+	multipartQuoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+)
+
+// OptMultipartField adds a form field to a multipart/form-data body.
+func OptMultipartField(name, value string) Option {
+	header := textproto.MIMEHeader{}
+	header.Set(HeaderContentDisposition, fmt.Sprintf(`form-data; name="%s"`, multipartQuoteEscaper.Replace(name)))
+	return OptMultipartPart(header, strings.NewReader(value))
+}
+
+// OptMultipartFile adds a file to a multipart/form-data body.
+// The part content type is guessed from the file name extension, and defaults to `application/octet-stream`.
+// The contents are not read until the request is sent, and are closed once they're written if they're an `io.Closer`.
+func OptMultipartFile(fieldName, fileName string, contents io.Reader) Option {
+	contentType := mime.TypeByExtension(filepath.Ext(fileName))
+	if contentType == "" {
+		contentType = ContentTypeApplicationOctetStream
+	}
+	header := textproto.MIMEHeader{}
+	header.Set(HeaderContentDisposition, fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		multipartQuoteEscaper.Replace(fieldName), multipartQuoteEscaper.Replace(fileName)))
+	header.Set(HeaderContentType, contentType)
+	return OptMultipartPart(header, contents)
+}
+
+// OptMultipartPart adds a part with a given header to a multipart/form-data body.
+func OptMultipartPart(header textproto.MIMEHeader, contents io.Reader) Option {
+	return func(r *Request) error {
+		mb := multipartBody(r)
+		mb.Parts = append(mb.Parts, MultipartPart{Header: header, Contents: contents})
+		return nil
+	}
+}
+
+// OptMultipartBoundary sets the boundary of a multipart/form-data body, which is otherwise random.
+func OptMultipartBoundary(boundary string) Option {
+	return func(r *Request) error {
+		if err := multipart.NewWriter(ioutil.Discard).SetBoundary(boundary); err != nil {
+			return ex.New(err, ex.OptMessagef("boundary: %s", boundary))
+		}
+		mb := multipartBody(r)
+		mb.Boundary = boundary
+		r.Header.Set(HeaderContentType, mb.ContentType())
+		return nil
+	}
+}
+
+// OptMultipartProgress sets a function that is called with the total number of bytes of
+// the multipart/form-data body written so far, as the body is written.
+// It is called from the goroutine writing the body.
+func OptMultipartProgress(onProgress func(written int64)) Option {
+	return func(r *Request) error {
+		multipartBody(r).OnProgress = onProgress
+		return nil
+	}
+}
+
+// multipartBody returns the request multipart body, setting a new one with a random boundary
+// as the request body if the body isn't already a multipart body.
+func multipartBody(r *Request) *MultipartBody {
+	if typed, ok := r.Body.(*MultipartBody); ok {
+		return typed
+	}
+	mb := &MultipartBody{Boundary: multipart.NewWriter(ioutil.Discard).Boundary()}
+	r.Body = mb
+	r.ContentLength = 0
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+	r.Header.Set(HeaderContentType, mb.ContentType())
+	return mb
+}
+
+// MultipartPart is a part of a multipart body.
+type MultipartPart struct {
+	Header   textproto.MIMEHeader
+	Contents io.Reader
+}
+
+// MultipartBody is a multipart/form-data request body that is written as it is read.
+/*
+The body is streamed through an `io.Pipe`; nothing is written, and no goroutine is started,
+until the body is first read, and the part contents are never buffered as a whole:
+
+	res, err := r2.New("https://partner.example.com/reports",
+		r2.OptPost(),
+		r2.OptMultipartField("period", "2019-10"),
+		r2.OptMultipartFile("report", "report.csv", reportFile),
+		r2.OptMultipartProgress(func(written int64) { ... }),
+	).Do()
+
+Because the contents are read as the body is sent, the body can only be sent once; with a
+retry policy the body is buffered in memory so it can be re-sent.
+*/
+type MultipartBody struct {
+	Boundary   string
+	Parts      []MultipartPart
+	OnProgress func(written int64)
+
+	mu     sync.Mutex
+	reader *io.PipeReader
+	closed bool
+}
+
+// ContentType returns the `Content-Type` header value for the body.
+func (mb *MultipartBody) ContentType() string {
+	mw := multipart.NewWriter(ioutil.Discard)
+	if err := mw.SetBoundary(mb.Boundary); err != nil {
+		return ContentTypeMultipartFormData
+	}
+	return mw.FormDataContentType()
+}
+
+// Read implements io.Reader, and starts writing the body on the first read.
+func (mb *MultipartBody) Read(p []byte) (int, error) {
+	mb.mu.Lock()
+	if mb.closed {
+		mb.mu.Unlock()
+		return 0, io.ErrClosedPipe
+	}
+	if mb.reader == nil {
+		var writer *io.PipeWriter
+		mb.reader, writer = io.Pipe()
+		go func() {
+			writer.CloseWithError(mb.write(writer))
+		}()
+	}
+	reader := mb.reader
+	mb.mu.Unlock()
+	return reader.Read(p)
+}
+
+// Close implements io.Closer.
+// If the body hasn't been read, the part contents that are `io.Closer`s are closed.
+func (mb *MultipartBody) Close() error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	if mb.closed {
+		return nil
+	}
+	mb.closed = true
+	if mb.reader != nil {
+		return mb.reader.Close()
+	}
+	closeMultipartContents(mb.Parts)
+	return nil
+}
+
+// write writes the body to a writer.
+func (mb *MultipartBody) write(w io.Writer) error {
+	if mb.OnProgress != nil {
+		w = &progressWriter{Writer: w, OnProgress: mb.OnProgress}
+	}
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(mb.Boundary); err != nil {
+		return ex.New(err)
+	}
+	for index, part := range mb.Parts {
+		partWriter, err := mw.CreatePart(part.Header)
+		if err == nil {
+			_, err = io.Copy(partWriter, part.Contents)
+		}
+		if err != nil {
+			closeMultipartContents(mb.Parts[index:])
+			return ex.New(err)
+		}
+		closeMultipartContents(mb.Parts[index : index+1])
+	}
+	return ex.New(mw.Close())
+}
+
+func closeMultipartContents(parts []MultipartPart) {
+	for _, part := range parts {
+		if closer, ok := part.Contents.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+}
+
+// progressWriter is a writer that reports the total number of bytes written.
+type progressWriter struct {
+	io.Writer
+	Written    int64
+	OnProgress func(int64)
+}
+
+// Write implements io.Writer.
+func (pw *progressWriter) Write(p []byte) (n int, err error) {
+	n, err = pw.Writer.Write(p)
+	if n > 0 {
+		pw.Written += int64(n)
+		pw.OnProgress(pw.Written)
+	}
+	return
+}
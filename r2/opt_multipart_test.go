@@ -0,0 +1,103 @@
+package r2
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+type mockReadCloser struct {
+	*strings.Reader
+	closed bool
+}
+
+func (mrc *mockReadCloser) Close() error {
+	mrc.closed = true
+	return nil
+}
+
+func TestOptMultipart(t *testing.T) {
+	assert := assert.New(t)
+
+	type upload struct {
+		ContentType     string
+		Period          string
+		FileName        string
+		FileContentType string
+		FileContents    string
+	}
+	uploads := make(chan upload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var u upload
+		u.ContentType = req.Header.Get(HeaderContentType)
+		if err := req.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		u.Period = req.FormValue("period")
+		file, header, err := req.FormFile("report")
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer file.Close()
+		contents, _ := ioutil.ReadAll(file)
+		u.FileName = header.Filename
+		u.FileContentType = header.Header.Get(HeaderContentType)
+		u.FileContents = string(contents)
+		uploads <- u
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	report := &mockReadCloser{Reader: strings.NewReader(strings.Repeat("a,b,c\n", 1024))}
+	var progress []int64
+	res, err := New(server.URL,
+		OptPost(),
+		OptMultipartField("period", "2019-10"),
+		OptMultipartFile("report", "report.pdf", report),
+		OptMultipartBoundary("test-boundary"),
+		OptMultipartProgress(func(written int64) { progress = append(progress, written) }),
+	).Do()
+	assert.Nil(err)
+	defer res.Body.Close()
+	assert.Equal(http.StatusOK, res.StatusCode)
+
+	u := <-uploads
+	assert.Equal("multipart/form-data; boundary=test-boundary", u.ContentType)
+	assert.Equal("2019-10", u.Period)
+	assert.Equal("report.pdf", u.FileName)
+	assert.True(strings.HasPrefix(u.FileContentType, "application/pdf"), u.FileContentType)
+	assert.Equal(strings.Repeat("a,b,c\n", 1024), u.FileContents)
+	assert.True(report.closed)
+
+	assert.NotEmpty(progress)
+	for index := 1; index < len(progress); index++ {
+		assert.True(progress[index] > progress[index-1])
+	}
+	assert.True(progress[len(progress)-1] > int64(len(u.FileContents)))
+}
+
+func TestOptMultipartBodyLazy(t *testing.T) {
+	assert := assert.New(t)
+
+	report := &mockReadCloser{Reader: strings.NewReader("contents")}
+	r := New("http://localhost", OptMultipartFile("report", "report.bin", report))
+	assert.Nil(r.Err)
+
+	mb, ok := r.Body.(*MultipartBody)
+	assert.True(ok)
+	assert.Len(mb.Parts, 1)
+	assert.Equal(ContentTypeApplicationOctetStream, mb.Parts[0].Header.Get(HeaderContentType))
+	assert.Equal(len("contents"), report.Len(), "the contents should not be read until the body is read")
+
+	assert.Nil(r.Body.Close())
+	assert.True(report.closed)
+
+	r = New("http://localhost", OptMultipartBoundary(strings.Repeat("x", 71)))
+	assert.NotNil(r.Err)
+}
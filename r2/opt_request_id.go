@@ -0,0 +1,20 @@
+package r2
+
+import (
+	"context"
+
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/webutil"
+)
+
+// OptRequestID forwards the request id on a context, typically an inbound request context,
+// in the `X-Request-ID` header.
+// It does nothing if the context does not have a request id.
+func OptRequestID(ctx context.Context) Option {
+	return func(r *Request) error {
+		if requestID := logger.GetRequestID(ctx); requestID != "" {
+			return OptHeaderValue(webutil.HeaderXRequestID, requestID)(r)
+		}
+		return nil
+	}
+}
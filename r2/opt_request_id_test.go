@@ -0,0 +1,22 @@
+package r2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/webutil"
+)
+
+func TestOptRequestID(t *testing.T) {
+	assert := assert.New(t)
+
+	req := New("https://foo.bar.local")
+	assert.Nil(OptRequestID(context.Background())(req))
+	assert.Empty(req.Header.Get(webutil.HeaderXRequestID))
+
+	ctx := logger.WithRequestID(context.Background(), "test-request-id")
+	assert.Nil(OptRequestID(ctx)(req))
+	assert.Equal("test-request-id", req.Header.Get(webutil.HeaderXRequestID))
+}
@@ -0,0 +1,280 @@
+package r2
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// Retry defaults.
+const (
+	// DefaultRetryMaxAttempts is the default maximum number of attempts, including the first attempt.
+	DefaultRetryMaxAttempts = 3
+	// DefaultRetryBaseDelay is the default delay the exponential backoff starts from.
+	DefaultRetryBaseDelay = 100 * time.Millisecond
+	// DefaultRetryMaxDelay is the default maximum delay between attempts, including delays from `Retry-After` headers.
+	DefaultRetryMaxDelay = 10 * time.Second
+)
+
+var (
+	// DefaultRetryStatusCodes are the response status codes retried by default.
+	DefaultRetryStatusCodes = []int{
+		http.StatusTooManyRequests,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+	}
+	// IdempotentMethods are the methods retried by default.
+	IdempotentMethods = []string{
+		http.MethodGet,
+		http.MethodHead,
+		http.MethodOptions,
+		http.MethodTrace,
+		http.MethodPut,
+		http.MethodDelete,
+	}
+)
+
+// RetryPredicate returns if an attempt should be retried.
+// The response is nil if the attempt failed with an error.
+type RetryPredicate func(req *http.Request, res *http.Response, err error) bool
+
+// RetryOption is an option for a retry policy.
+type RetryOption func(*Retry)
+
+// OptRetryMaxAttempts sets the maximum number of attempts, including the first attempt.
+func OptRetryMaxAttempts(maxAttempts int) RetryOption {
+	return func(r *Retry) { r.MaxAttempts = maxAttempts }
+}
+
+// OptRetryBackoff sets the delay the exponential backoff starts from and the maximum delay between attempts.
+func OptRetryBackoff(baseDelay, maxDelay time.Duration) RetryOption {
+	return func(r *Retry) {
+		r.BaseDelay = baseDelay
+		r.MaxDelay = maxDelay
+	}
+}
+
+// OptRetryStatusCodes sets the response status codes that are retried.
+func OptRetryStatusCodes(statusCodes ...int) RetryOption {
+	return func(r *Retry) { r.StatusCodes = statusCodes }
+}
+
+// OptRetryNetworkErrors sets if network errors, e.g. connection refused or reset, are retried.
+func OptRetryNetworkErrors(networkErrors bool) RetryOption {
+	return func(r *Retry) { r.NetworkErrors = networkErrors }
+}
+
+// OptRetryOn adds a predicate that retries attempts it returns true for.
+func OptRetryOn(predicate RetryPredicate) RetryOption {
+	return func(r *Retry) { r.Predicates = append(r.Predicates, predicate) }
+}
+
+// OptRetryNonIdempotent sets if requests with non-idempotent methods, e.g. POST, are retried.
+// It should only be set if the remote handles duplicate requests safely, e.g. with idempotency keys.
+func OptRetryNonIdempotent(nonIdempotent bool) RetryOption {
+	return func(r *Retry) { r.NonIdempotent = nonIdempotent }
+}
+
+// OptRetry sets a retry policy for the request.
+/*
+By default, requests with idempotent methods are attempted up to 3 times if they fail with a network
+error or with a 429, 502, 503 or 504 status. Delays between attempts back off exponentially with full jitter,
+and a `Retry-After` response header overrides the delay, up to the max delay.
+
+Request bodies, e.g. from `OptBody` or `OptJSONBody`, are buffered so they can be re-sent with every attempt.
+Every attempt is reported to the tracer and the `OnRequest` and `OnResponse` listeners.
+*/
+func OptRetry(options ...RetryOption) Option {
+	return func(r *Request) error {
+		r.Retry = NewRetry(options...)
+		return nil
+	}
+}
+
+// NewRetry returns a new retry policy.
+func NewRetry(options ...RetryOption) *Retry {
+	r := &Retry{
+		MaxAttempts:   DefaultRetryMaxAttempts,
+		BaseDelay:     DefaultRetryBaseDelay,
+		MaxDelay:      DefaultRetryMaxDelay,
+		StatusCodes:   DefaultRetryStatusCodes,
+		NetworkErrors: true,
+	}
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+// Retry is a retry policy for requests.
+type Retry struct {
+	// MaxAttempts is the maximum number of attempts, including the first attempt.
+	MaxAttempts int
+	// BaseDelay is the delay the exponential backoff starts from.
+	BaseDelay time.Duration
+	// MaxDelay is the maximum delay between attempts.
+	MaxDelay time.Duration
+	// StatusCodes are the response status codes that are retried.
+	StatusCodes []int
+	// NetworkErrors sets if network errors are retried.
+	NetworkErrors bool
+	// Predicates are additional predicates that retry attempts they return true for.
+	Predicates []RetryPredicate
+	// NonIdempotent sets if requests with non-idempotent methods are retried.
+	NonIdempotent bool
+}
+
+// ShouldRetry returns if an attempt should be retried, ignoring the attempt count.
+func (r *Retry) ShouldRetry(req *http.Request, res *http.Response, err error) bool {
+	if !r.NonIdempotent && !isIdempotent(req.Method) {
+		return false
+	}
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil && r.NetworkErrors {
+		if _, ok := err.(*url.Error); ok {
+			return true
+		}
+	}
+	if res != nil {
+		for _, statusCode := range r.StatusCodes {
+			if res.StatusCode == statusCode {
+				return true
+			}
+		}
+	}
+	for _, predicate := range r.Predicates {
+		if predicate(req, res, err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns the delay before the next attempt after a given attempt, starting from 1.
+// It uses the `Retry-After` header of the response if it has one, otherwise an exponential backoff with full jitter.
+func (r *Retry) Delay(attempt int, res *http.Response) time.Duration {
+	if res != nil {
+		if delay, ok := RetryAfter(res); ok {
+			if r.MaxDelay > 0 && delay > r.MaxDelay {
+				return r.MaxDelay
+			}
+			return delay
+		}
+	}
+	if r.BaseDelay <= 0 {
+		return 0
+	}
+	backoff := r.BaseDelay
+	for index := 1; index < attempt; index++ {
+		backoff = backoff * 2
+		if (r.MaxDelay > 0 && backoff >= r.MaxDelay) || backoff <= 0 {
+			backoff = r.MaxDelay
+			break
+		}
+	}
+	if r.MaxDelay > 0 && backoff > r.MaxDelay {
+		backoff = r.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(backoff) + 1))
+}
+
+// RetryAfter returns the delay from the `Retry-After` header of a response,
+// which is either a number of seconds or an http date.
+func RetryAfter(res *http.Response) (time.Duration, bool) {
+	value := res.Header.Get(HeaderRetryAfter)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// doWithRetry sends the request, retrying failed attempts according to the retry policy.
+func (r *Request) doWithRetry() (*http.Response, error) {
+	if !r.Retry.NonIdempotent && !isIdempotent(r.Method) {
+		return r.attempt()
+	}
+	if err := r.bufferBody(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && r.GetBody != nil {
+			body, err := r.GetBody()
+			if err != nil {
+				return nil, ex.New(err)
+			}
+			r.Body = body
+		}
+
+		res, err := r.attempt()
+		if (r.Retry.MaxAttempts > 0 && attempt >= r.Retry.MaxAttempts) || !r.Retry.ShouldRetry(&r.Request, res, err) {
+			return res, err
+		}
+
+		delay := r.Retry.Delay(attempt, res)
+		if res != nil {
+			// drain the body so the connection can be re-used.
+			io.Copy(ioutil.Discard, io.LimitReader(res.Body, 1<<16))
+			res.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			timer.Stop()
+			return nil, ex.New(r.Context().Err())
+		}
+	}
+}
+
+// bufferBody reads the request body into memory, if it can't already be re-read, so it can be re-sent.
+func (r *Request) bufferBody() error {
+	if r.Body == nil || r.Body == http.NoBody || r.GetBody != nil {
+		return nil
+	}
+	contents, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return ex.New(err)
+	}
+	r.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(contents)), nil
+	}
+	r.Body, _ = r.GetBody()
+	if r.ContentLength <= 0 {
+		r.ContentLength = int64(len(contents))
+	}
+	return nil
+}
+
+func isIdempotent(method string) bool {
+	if method == "" {
+		method = http.MethodGet
+	}
+	for _, idempotent := range IdempotentMethods {
+		if method == idempotent {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package r2
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestOptRetry(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		if atomic.AddInt32(&calls, 1) < 3 {
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var onRequest, onResponse int
+	res, err := New(server.URL,
+		OptPut(),
+		OptJSONBody(map[string]string{"foo": "bar"}),
+		OptRetry(OptRetryBackoff(time.Millisecond, 5*time.Millisecond)),
+		OptOnRequest(func(_ *http.Request) error { onRequest++; return nil }),
+		OptOnResponse(func(_ *http.Request, _ *http.Response, _ time.Time, _ error) error { onResponse++; return nil }),
+	).Do()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(3, calls)
+	assert.Equal(3, onRequest)
+	assert.Equal(3, onResponse)
+	assert.Len(bodies, 3)
+	for _, body := range bodies {
+		assert.Equal(`{"foo":"bar"}`, body)
+	}
+}
+
+func TestOptRetryMaxAttempts(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rw.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	res, err := New(server.URL, OptRetry(OptRetryMaxAttempts(2), OptRetryBackoff(time.Millisecond, time.Millisecond))).Do()
+	assert.Nil(err)
+	assert.Equal(http.StatusBadGateway, res.StatusCode)
+	assert.Equal(2, calls)
+}
+
+func TestOptRetryNonIdempotent(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	res, err := New(server.URL, OptPost(), OptRetry(OptRetryBackoff(time.Millisecond, time.Millisecond))).Do()
+	assert.Nil(err)
+	assert.Equal(http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(1, calls)
+
+	res, err = New(server.URL, OptPost(), OptRetry(OptRetryNonIdempotent(true), OptRetryBackoff(time.Millisecond, time.Millisecond))).Do()
+	assert.Nil(err)
+	assert.Equal(http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(1+DefaultRetryMaxAttempts, calls)
+}
+
+func TestOptRetryOn(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			rw.WriteHeader(http.StatusConflict)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	res, err := New(server.URL, OptRetry(
+		OptRetryBackoff(time.Millisecond, time.Millisecond),
+		OptRetryOn(func(_ *http.Request, res *http.Response, _ error) bool {
+			return res != nil && res.StatusCode == http.StatusConflict
+		}),
+	)).Do()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(2, calls)
+}
+
+func TestOptRetryNetworkErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {}))
+	serverURL := server.URL
+	server.Close()
+
+	var onRequest int
+	_, err := New(serverURL,
+		OptRetry(OptRetryBackoff(time.Millisecond, time.Millisecond)),
+		OptOnRequest(func(_ *http.Request) error { onRequest++; return nil }),
+	).Do()
+	assert.NotNil(err)
+	assert.Equal(DefaultRetryMaxAttempts, onRequest)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert := assert.New(t)
+
+	retry := NewRetry(OptRetryBackoff(10*time.Millisecond, time.Second))
+	for attempt := 1; attempt < 10; attempt++ {
+		delay := retry.Delay(attempt, nil)
+		assert.True(delay >= 0)
+		assert.True(delay <= time.Second)
+	}
+	assert.True(retry.Delay(1, nil) <= 10*time.Millisecond)
+
+	res := &http.Response{Header: http.Header{HeaderRetryAfter: []string{"3"}}}
+	assert.Equal(time.Second, retry.Delay(1, res), "retry after should be capped at the max delay")
+
+	retry.MaxDelay = time.Minute
+	assert.Equal(3*time.Second, retry.Delay(1, res))
+
+	res.Header.Set(HeaderRetryAfter, time.Now().UTC().Add(-time.Minute).Format(http.TimeFormat))
+	assert.Equal(0, retry.Delay(1, res))
+
+	res.Header.Set(HeaderRetryAfter, "not a delay")
+	assert.True(retry.Delay(1, res) <= 10*time.Millisecond)
+}
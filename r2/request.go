@@ -10,6 +10,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/blend/go-sdk/breaker"
 	"github.com/blend/go-sdk/ex"
 )
 
@@ -54,6 +55,10 @@ type Request struct {
 	OnRequest []OnRequestListener
 	// OnResponse is an array of response lifecycle hooks used for logging.
 	OnResponse []OnResponseListener
+	// Retry is an optional retry policy; if it is unset the request is sent once.
+	Retry *Retry
+	// CircuitBreaker is an optional circuit breaker every attempt is checked against and recorded to.
+	CircuitBreaker *breaker.Breaker
 }
 
 // Do executes the request.
@@ -69,6 +74,14 @@ func (r Request) Do() (*http.Response, error) {
 		}
 	}
 
+	if r.Retry != nil {
+		return r.doWithRetry()
+	}
+	return r.attempt()
+}
+
+// attempt sends the request once, notifying the tracer and listeners.
+func (r *Request) attempt() (*http.Response, error) {
 	var err error
 	started := time.Now().UTC()
 
@@ -83,12 +96,26 @@ func (r Request) Do() (*http.Response, error) {
 		}
 	}
 
+	// the breaker is checked after the request listeners so every allowed attempt is recorded.
+	var breakerDone func(breaker.Outcome)
+	if r.CircuitBreaker != nil {
+		if breakerDone, err = r.CircuitBreaker.Allow(r.Context()); err != nil {
+			if finisher != nil {
+				finisher.Finish(&r.Request, nil, started, err)
+			}
+			return nil, err
+		}
+	}
+
 	var res *http.Response
 	if r.Client != nil {
 		res, err = r.Client.Do(&r.Request)
 	} else {
 		res, err = http.DefaultClient.Do(&r.Request)
 	}
+	if breakerDone != nil {
+		breakerDone(CircuitBreakerOutcome(res, err))
+	}
 	if finisher != nil {
 		finisher.Finish(&r.Request, res, started, err)
 	}
@@ -1,11 +1,13 @@
 package reverseproxy
 
 import (
+	"context"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"time"
 
+	"github.com/blend/go-sdk/breaker"
 	"github.com/blend/go-sdk/ex"
 	"github.com/blend/go-sdk/logger"
 	"github.com/blend/go-sdk/webutil"
@@ -30,6 +32,9 @@ type Upstream struct {
 	URL *url.URL
 	// ReverseProxy is what actually forwards requests.
 	ReverseProxy *httputil.ReverseProxy
+	// CircuitBreaker is an optional circuit breaker for the upstream.
+	// Requests are rejected with a 503 while it is open, and responses with 5xx status codes are recorded as failures.
+	CircuitBreaker *breaker.Breaker
 }
 
 // UseHTTP2 sets the upstream to use http2.
@@ -71,6 +76,25 @@ func (u *Upstream) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		}()
 	}
 
+	if u.CircuitBreaker != nil {
+		done, err := u.CircuitBreaker.Allow(req.Context())
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		defer func() {
+			switch {
+			case req.Context().Err() == context.Canceled:
+				// requests canceled by the client say nothing about the upstream.
+				done(breaker.OutcomeIgnored)
+			case w.StatusCode() >= http.StatusInternalServerError:
+				done(breaker.OutcomeFailure)
+			default:
+				done(breaker.OutcomeSuccess)
+			}
+		}()
+	}
+
 	// Add extra forwarded headers.
 	// these are required for a majority of services to function correctly behind
 	// a reverse proxy.
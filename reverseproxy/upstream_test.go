@@ -1,9 +1,15 @@
 package reverseproxy
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/breaker"
 )
 
 func TestUpstreamWithoutHopHeaders(t *testing.T) {
@@ -12,3 +18,49 @@ func TestUpstreamWithoutHopHeaders(t *testing.T) {
 	u := NewUpstream(MustParseURL("http://localhost:5000"))
 	assert.NotNil(u.ReverseProxy)
 }
+
+func TestUpstreamCircuitBreaker(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	mockedEndpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mockedEndpoint.Close()
+
+	upstream := NewUpstream(MustParseURL(mockedEndpoint.URL))
+	upstream.CircuitBreaker = breaker.New(breaker.OptMinRequests(2), breaker.OptOpenTimeout(time.Hour))
+	mockedProxy := httptest.NewServer(upstream)
+	defer mockedProxy.Close()
+
+	for _, expected := range []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		res, err := http.Get(mockedProxy.URL)
+		assert.Nil(err)
+		res.Body.Close()
+		assert.Equal(expected, res.StatusCode)
+	}
+	assert.Equal(2, calls)
+	assert.Equal(breaker.StateOpen, upstream.CircuitBreaker.State())
+}
+
+func TestUpstreamCircuitBreakerCanceled(t *testing.T) {
+	assert := assert.New(t)
+
+	mockedEndpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer mockedEndpoint.Close()
+
+	upstream := NewUpstream(MustParseURL(mockedEndpoint.URL))
+	upstream.CircuitBreaker = breaker.New(breaker.OptMinRequests(1), breaker.OptOpenTimeout(time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("GET", mockedEndpoint.URL, nil).WithContext(ctx)
+	res := httptest.NewRecorder()
+	upstream.ServeHTTP(res, req)
+	assert.Equal(http.StatusBadGateway, res.Code)
+	assert.Equal(breaker.StateClosed, upstream.CircuitBreaker.State(), "client cancellations shouldn't open the breaker")
+	assert.Zero(upstream.CircuitBreaker.Counts().Requests, "client cancellations shouldn't be tracked")
+}
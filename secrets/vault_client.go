@@ -183,6 +183,13 @@ func (c *VaultClient) backendKV(ctx context.Context, key string) (KV, error) {
 	}
 }
 
+// PingContext checks that vault is reachable, initialized and unsealed.
+func (c *VaultClient) PingContext(ctx context.Context) error {
+	req := c.createRequest(MethodGet, "/v1/sys/health")
+	req = req.WithContext(ctx)
+	return c.discard(c.send(req))
+}
+
 func (c *VaultClient) getVersion(ctx context.Context, key string) (string, error) {
 	meta, err := c.getMountMeta(ctx, filepath.Join(c.Mount, key))
 	if err != nil {
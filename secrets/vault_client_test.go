@@ -54,6 +54,17 @@ func TestVaultClientGetVersion(t *testing.T) {
 	assert.Equal(Version2, version)
 }
 
+func TestVaultClientPingContext(t *testing.T) {
+	assert := assert.New(t)
+
+	client, err := New()
+	assert.Nil(err)
+
+	m := NewMockHTTPClient().WithString("GET", MustURL("%s/v1/sys/health", client.Remote.String()), `{"initialized":true,"sealed":false}`)
+	client.Client = m
+	assert.Nil(client.PingContext(context.TODO()))
+}
+
 func TestVaultClientGetMountMeta(t *testing.T) {
 	assert := assert.New(t)
 	todo := context.TODO()
@@ -5,7 +5,12 @@ import (
 	"crypto/tls"
 	"net"
 	"net/http"
+	"os"
 	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"github.com/blend/go-sdk/async"
 	"github.com/blend/go-sdk/ex"
@@ -38,8 +43,10 @@ type App struct {
 	Log                     logger.Log
 	Views                   *ViewCache
 	TLSConfig               *tls.Config
+	TLSFiles                *TLSFiles
 	Server                  *http.Server
 	Listener                *net.TCPListener
+	NetListener             net.Listener
 	DefaultHeaders          map[string]string
 	Statics                 map[string]*StaticFileServer
 	Routes                  map[string]*RouteNode
@@ -50,12 +57,25 @@ type App struct {
 	Tracer                  Tracer
 	DefaultProvider         ResultProvider
 	State                   *SyncState
+	Docs                    map[string]RouteDoc
+	WebSocketUpgrader       WebSocketUpgrader
+	CORS                    *CORSPolicy
+	Health                  *Health
+
+	websockets     webSocketTracker
+	sessionSweeper *async.Interval
+	// authErr is the error creating the auth manager from the config, returned when the app starts.
+	authErr error
 }
 
 // CreateServer returns the basic http.Server for the app.
 func (a *App) CreateServer() *http.Server {
+	var handler http.Handler = a
+	if a.Config.H2C {
+		handler = h2c.NewHandler(a, &http2.Server{})
+	}
 	return &http.Server{
-		Handler:           a,
+		Handler:           handler,
 		TLSConfig:         a.TLSConfig,
 		Addr:              a.Config.BindAddrOrDefault(),
 		MaxHeaderBytes:    a.Config.MaxHeaderBytesOrDefault(),
@@ -68,11 +88,40 @@ func (a *App) CreateServer() *http.Server {
 
 // StartupTasks runs common startup tasks.
 func (a *App) StartupTasks() error {
+	if a.authErr != nil {
+		return a.authErr
+	}
+	if a.CORS != nil {
+		if err := a.CORS.Validate(); err != nil {
+			return err
+		}
+	}
 	return a.Views.Initialize()
 }
 
 // Start starts the server and binds to the given address.
+// If the server fails to start, or exits with an error, everything started alongside it is stopped.
 func (a *App) Start() (err error) {
+	defer func() {
+		if err != nil {
+			ctx, cancel := a.shutdownContext()
+			defer cancel()
+			a.teardown(ctx)
+			a.Listener = nil
+			a.NetListener = nil
+		}
+	}()
+
+	// load the tls config from files if they're set.
+	if a.TLSFiles != nil {
+		if a.TLSFiles.Log == nil {
+			a.TLSFiles.Log = a.Log
+		}
+		if a.TLSConfig, err = a.TLSFiles.TLSConfig(); err != nil {
+			return
+		}
+	}
+
 	// set up the underlying server.
 	a.Server = a.CreateServer()
 
@@ -85,36 +134,42 @@ func (a *App) Start() (err error) {
 	serverProtocol := "http"
 	if a.Server.TLSConfig != nil {
 		serverProtocol = "https (tls)"
+	} else if a.Config.H2C {
+		serverProtocol = "http (h2c)"
 	}
 
-	logger.MaybeInfof(a.Log, "%s server started, listening on %s", serverProtocol, a.Config.BindAddrOrDefault())
+	if a.NetListener == nil {
+		a.NetListener, err = a.listen()
+		if err != nil {
+			return
+		}
+	}
+	a.Listener, _ = a.NetListener.(*net.TCPListener)
+	logger.MaybeInfof(a.Log, "%s server started, listening on %s %s", serverProtocol, a.NetListener.Addr().Network(), a.NetListener.Addr().String())
 
 	if a.Server.TLSConfig != nil && a.Server.TLSConfig.ClientCAs != nil {
 		logger.MaybeInfof(a.Log, "%s using client cert pool with (%d) client certs", serverProtocol, len(a.Server.TLSConfig.ClientCAs.Subjects()))
 	}
 
-	var listener net.Listener
-	listener, err = net.Listen("tcp", a.Config.BindAddrOrDefault())
-	if err != nil {
-		err = ex.New(err)
-		return
+	listener := a.NetListener
+	if a.Listener != nil {
+		listener = TCPKeepAliveListener{a.Listener}
 	}
-	var ok bool
-	a.Listener, ok = listener.(*net.TCPListener)
-	if !ok {
-		err = ex.New("listener returned was not a net.TCPListener")
-		return
+	if a.Server.TLSConfig != nil {
+		listener = tls.NewListener(listener, a.Server.TLSConfig)
 	}
 
-	keepAliveListener := TCPKeepAliveListener{a.Listener}
-	var shutdownErr error
-
-	a.Started()
-	if a.Server.TLSConfig != nil {
-		shutdownErr = a.Server.Serve(tls.NewListener(keepAliveListener, a.Server.TLSConfig))
-	} else {
-		shutdownErr = a.Server.Serve(keepAliveListener)
+	if a.TLSFiles != nil {
+		if err = a.TLSFiles.Start(); err != nil {
+			return
+		}
 	}
+	a.startSessionSweeper()
+	if a.Health != nil {
+		a.Health.SetDraining(false)
+	}
+	a.Started()
+	shutdownErr := a.Server.Serve(listener)
 	if shutdownErr != nil && shutdownErr != http.ErrServerClosed {
 		err = ex.New(shutdownErr)
 	}
@@ -131,25 +186,119 @@ func (a *App) Stop() error {
 	}
 	a.Stopping()
 
-	ctx := context.Background()
-	var cancel context.CancelFunc
-	if a.Config.ShutdownGracePeriodOrDefault() > 0 {
-		ctx, cancel = context.WithTimeout(ctx, a.Config.ShutdownGracePeriodOrDefault())
-		defer cancel()
+	ctx, cancel := a.shutdownContext()
+	defer cancel()
+	if a.Health != nil {
+		// fail readiness first so load balancers stop sending requests before we stop accepting them.
+		a.Health.SetDraining(true)
+		if a.Health.DrainDelay > 0 {
+			// the drain delay comes out of the shutdown grace period.
+			logger.MaybeInfof(a.Log, "server draining for %v", a.Health.DrainDelay)
+			select {
+			case <-time.After(a.Health.DrainDelay):
+			case <-ctx.Done():
+			}
+		}
 	}
 	logger.MaybeInfof(a.Log, "server shutting down")
 	a.Server.SetKeepAlivesEnabled(false)
-	if err := a.Server.Shutdown(ctx); err != nil {
-		return ex.New(err)
+	shutdownErr := a.Server.Shutdown(ctx)
+	// the rest of the app is stopped even if the server didn't shut down gracefully.
+	a.teardown(ctx)
+	if shutdownErr != nil {
+		return ex.New(shutdownErr)
 	}
 
 	a.Server = nil
 	a.Listener = nil
+	a.NetListener = nil
 	logger.MaybeInfof(a.Log, "server shutdown complete")
 
 	return nil
 }
 
+// shutdownContext returns the context for stopping the app, which is bounded by the shutdown grace period.
+func (a *App) shutdownContext() (context.Context, context.CancelFunc) {
+	if a.Config.ShutdownGracePeriodOrDefault() > 0 {
+		return context.WithTimeout(context.Background(), a.Config.ShutdownGracePeriodOrDefault())
+	}
+	return context.WithCancel(context.Background())
+}
+
+// teardown stops everything the app starts alongside the server and closes the listener.
+// It can be called if only some of them were started.
+func (a *App) teardown(ctx context.Context) {
+	// hijacked connections are not tracked by the server.
+	a.websockets.shutdown(ctx)
+	a.stopSessionSweeper()
+	if a.TLSFiles != nil {
+		a.TLSFiles.Stop()
+	}
+	if a.Views != nil {
+		a.Views.StopWatching()
+	}
+	if a.NetListener != nil {
+		// the server closes the listener when it shuts down, so this only matters if it didn't serve.
+		a.NetListener.Close()
+	}
+}
+
+// listen creates the listener for the app from the config.
+// It listens on the unix socket if one is configured, and the bind address otherwise.
+func (a *App) listen() (net.Listener, error) {
+	if a.Config.UnixSocket != "" {
+		// remove a socket left behind by a process that didn't shut down cleanly.
+		// anything else at the path is left alone, and listening fails.
+		info, err := os.Lstat(a.Config.UnixSocket)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, ex.New(err)
+		}
+		if err == nil && info.Mode()&os.ModeSocket != 0 {
+			if err := os.Remove(a.Config.UnixSocket); err != nil && !os.IsNotExist(err) {
+				return nil, ex.New(err)
+			}
+		}
+		listener, err := net.Listen("unix", a.Config.UnixSocket)
+		if err != nil {
+			return nil, ex.New(err)
+		}
+		return listener, nil
+	}
+	listener, err := net.Listen("tcp", a.Config.BindAddrOrDefault())
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	return listener, nil
+}
+
+// startSessionSweeper starts removing expired sessions and revocations from the auth manager's
+// session store and revocation list, if it has them.
+func (a *App) startSessionSweeper() {
+	if a.Auth.SessionStore == nil && a.Auth.RevocationList == nil {
+		return
+	}
+	store, revocations := a.Auth.SessionStore, a.Auth.RevocationList
+	a.sessionSweeper = async.NewInterval(func(ctx context.Context) error {
+		if store != nil {
+			logger.MaybeError(a.Log, store.SweepSessions(ctx))
+		}
+		if revocations != nil {
+			logger.MaybeError(a.Log, revocations.SweepRevocations(ctx))
+		}
+		return nil
+	}, a.Config.SessionStore.SweepIntervalOrDefault())
+	go a.sessionSweeper.Start()
+	<-a.sessionSweeper.NotifyStarted()
+}
+
+func (a *App) stopSessionSweeper() {
+	if a.sessionSweeper == nil {
+		return
+	}
+	a.sessionSweeper.Stop()
+	a.sessionSweeper = nil
+}
+
 // Register registers controllers with the app's router.
 func (a *App) Register(controllers ...Controller) {
 	for _, c := range controllers {
@@ -334,6 +483,13 @@ func (a *App) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 
 	if req.Method == MethodOptions {
+		// Handle CORS preflight requests for paths without an OPTIONS route
+		if a.CORS != nil && a.CORS.IsPreflight(req) {
+			if allow := a.allowed(path, req.Method); len(allow) > 0 {
+				a.RenderAction(func(_ *Ctx) Result { return NoContent })(w, req, nil, nil)
+				return
+			}
+		}
 		// Handle OPTIONS requests
 		if a.Config.HandleOptions {
 			if allow := a.allowed(path, req.Method); len(allow) > 0 {
@@ -366,15 +522,19 @@ func (a *App) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 
 // RenderAction is the translation step from Action to Handler.
 // this is where the bulk of the "pipeline" happens.
+// The app's cors policy, if any, is applied to the action here so it can't be left out of the middleware.
 func (a *App) RenderAction(action Action) Handler {
 	return func(w http.ResponseWriter, r *http.Request, route *Route, p RouteParameters) {
 		var err error
 		var tf TraceFinisher
 
 		var response ResponseWriter
-		if strings.Contains(r.Header.Get(HeaderAcceptEncoding), ContentEncodingGZIP) {
-			w.Header().Set(HeaderContentEncoding, ContentEncodingGZIP)
-			response = NewCompressedResponseWriter(w)
+		if encoding := a.negotiateContentEncoding(r); encoding != ContentEncodingIdentity {
+			response = NewCompressedResponseWriter(w,
+				OptCompressedResponseEncoding(encoding),
+				OptCompressedResponseMinSize(a.Config.Compression.MinSizeOrDefault()),
+				OptCompressedResponseSkipContentTypes(a.Config.Compression.SkipContentTypesOrDefault()...),
+			)
 		} else {
 			w.Header().Set(HeaderContentEncoding, ContentEncodingIdentity)
 			response = NewRawResponseWriter(w)
@@ -382,11 +542,14 @@ func (a *App) RenderAction(action Action) Handler {
 
 		ctx := a.createCtx(response, r, route, p)
 		ctx.onRequestStart()
+		if a.Config.RequestIDs {
+			applyRequestID(ctx)
+		}
 		if a.Tracer != nil {
 			tf = a.Tracer.Start(ctx)
 		}
 		if a.Log != nil {
-			a.Log.Trigger(r.Context(), a.httpRequestEvent(ctx))
+			a.Log.Trigger(ctx.Context(), a.httpRequestEvent(ctx))
 		}
 
 		if len(a.DefaultHeaders) > 0 {
@@ -394,7 +557,12 @@ func (a *App) RenderAction(action Action) Handler {
 				response.Header().Set(key, value)
 			}
 		}
-		result := action(ctx)
+		var result Result
+		if a.CORS != nil {
+			result = a.CORS.Middleware(action)(ctx)
+		} else {
+			result = action(ctx)
+		}
 		if result != nil {
 			// check for a prerender step
 			if typed, ok := result.(ResultPreRender); ok {
@@ -425,7 +593,7 @@ func (a *App) RenderAction(action Action) Handler {
 			a.logFatal(err, r)
 		}
 		if a.Log != nil {
-			a.Log.Trigger(r.Context(), a.httpResponseEvent(ctx))
+			a.Log.Trigger(ctx.Context(), a.httpResponseEvent(ctx))
 		}
 		if tf != nil {
 			tf.Finish(ctx, err)
@@ -433,6 +601,14 @@ func (a *App) RenderAction(action Action) Handler {
 	}
 }
 
+// negotiateContentEncoding returns the content encoding to compress a response to a request with.
+func (a *App) negotiateContentEncoding(r *http.Request) string {
+	if a.Config.Compression.Disabled {
+		return ContentEncodingIdentity
+	}
+	return NegotiateContentEncoding(r.Header.Get(HeaderAcceptEncoding), a.Config.Compression.SupportedEncodings()...)
+}
+
 // Middleware wraps an action with a given set of middleware, including app level default middleware.
 func (a *App) Middleware(action Action, middleware ...Middleware) Action {
 	if len(middleware) == 0 && len(a.DefaultMiddleware) == 0 {
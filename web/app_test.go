@@ -2,13 +2,22 @@ package web
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"fmt"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"golang.org/x/net/http2"
+
 	"github.com/blend/go-sdk/assert"
 	"github.com/blend/go-sdk/env"
 	"github.com/blend/go-sdk/graceful"
@@ -608,3 +617,190 @@ func TestAppViewTracerError(t *testing.T) {
 	assert.True(hasError)
 	assert.True(hasViewError)
 }
+
+func TestAppStartUnixSocket(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "web")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	socketPath := filepath.Join(tempDir, "app.sock")
+
+	app := New(OptUnixSocket(socketPath))
+	app.GET("/", func(_ *Ctx) Result { return Text.Result("unix") })
+	go app.Start()
+	<-app.NotifyStarted()
+	assert.Equal("unix", app.NetListener.Addr().Network())
+	assert.Nil(app.Listener, "the tcp listener should only be set for tcp listeners")
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	res, err := client.Get("http://unix/")
+	assert.Nil(err)
+	contents, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Nil(err)
+	assert.Equal("unix", string(contents))
+
+	assert.Nil(app.Stop())
+	<-app.NotifyStopped()
+	_, err = os.Stat(socketPath)
+	assert.True(os.IsNotExist(err), "the socket should be removed on stop")
+}
+
+func TestAppStartUnixSocketKeepsOtherFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "web")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	socketPath := filepath.Join(tempDir, "app.sock")
+	assert.Nil(ioutil.WriteFile(socketPath, []byte("not a socket"), 0600))
+
+	app := New(OptUnixSocket(socketPath))
+	assert.NotNil(app.Start())
+	contents, err := ioutil.ReadFile(socketPath)
+	assert.Nil(err)
+	assert.Equal("not a socket", string(contents), "files that aren't sockets should not be removed")
+}
+
+type testAcceptCountListener struct {
+	net.Listener
+	accepted int32
+}
+
+func (tacl *testAcceptCountListener) Accept() (net.Conn, error) {
+	conn, err := tacl.Listener.Accept()
+	if err == nil {
+		atomic.AddInt32(&tacl.accepted, 1)
+	}
+	return conn, err
+}
+
+func TestAppStartListener(t *testing.T) {
+	assert := assert.New(t)
+
+	tcpListener, err := net.Listen("tcp", DefaultMockBindAddr)
+	assert.Nil(err)
+	listener := &testAcceptCountListener{Listener: tcpListener}
+
+	app := New(OptListener(listener))
+	app.GET("/", func(_ *Ctx) Result { return Text.Result("listener") })
+	go app.Start()
+	<-app.NotifyStarted()
+	assert.True(app.NetListener == listener)
+	assert.Nil(app.Listener, "the tcp listener should only be set for tcp listeners")
+
+	res, err := http.Get("http://" + tcpListener.Addr().String() + "/")
+	assert.Nil(err)
+	res.Body.Close()
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(1, atomic.LoadInt32(&listener.accepted))
+
+	assert.Nil(app.Stop())
+	<-app.NotifyStopped()
+	_, err = tcpListener.Accept()
+	assert.NotNil(err, "the listener should be closed on stop")
+}
+
+func TestAppH2C(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New(OptBindAddr(DefaultMockBindAddr), OptH2C(true))
+	app.GET("/", func(r *Ctx) Result { return Text.Result(r.Request.Proto) })
+	go app.Start()
+	<-app.NotifyStarted()
+	defer app.Stop()
+
+	// prior knowledge h2c requests are served over http/2.
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, _ *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	res, err := client.Get("http://" + app.Listener.Addr().String() + "/")
+	assert.Nil(err)
+	contents, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(2, res.ProtoMajor)
+	assert.Equal("HTTP/2.0", string(contents))
+
+	// http/1.1 requests are still served.
+	res, err = http.Get("http://" + app.Listener.Addr().String() + "/")
+	assert.Nil(err)
+	contents, err = ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal("HTTP/1.1", string(contents))
+}
+
+func TestAppStopCleansUpOnShutdownError(t *testing.T) {
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	app := New(OptBindAddr(DefaultMockBindAddr), func(a *App) { a.Config.ShutdownGracePeriod = 10 * time.Millisecond })
+	app.Auth.RevocationList = NewLocalSessionRevocationList()
+	started := make(chan struct{})
+	app.GET("/slow", func(_ *Ctx) Result {
+		close(started)
+		<-release
+		return NoContent
+	})
+	go app.Start()
+	<-app.NotifyStarted()
+	assert.NotNil(app.sessionSweeper)
+
+	go http.Get("http://" + app.Listener.Addr().String() + "/slow")
+	<-started
+
+	assert.NotNil(app.Stop(), "the server should not shut down within the grace period")
+	assert.Nil(app.sessionSweeper, "the session sweeper should be stopped regardless")
+}
+
+type testFailingListener struct {
+	net.Listener
+}
+
+func (tfl testFailingListener) Accept() (net.Conn, error) {
+	return nil, fmt.Errorf("only a test")
+}
+
+func TestAppStartErrorsStopWatchers(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "web")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	viewPath := filepath.Join(tempDir, "index.html")
+	assert.Nil(ioutil.WriteFile(viewPath, []byte(`{{ define "index" }}index{{ end }}`), 0600))
+	newViews := func() *ViewCache {
+		return NewViewCache(OptViewCachePaths(viewPath), OptViewCacheDevMode(true))
+	}
+
+	// the bind address is in use, so listening fails.
+	inUse, err := net.Listen("tcp", DefaultMockBindAddr)
+	assert.Nil(err)
+	defer inUse.Close()
+	app := New(OptBindAddr(inUse.Addr().String()), OptViews(newViews()))
+	assert.NotNil(app.Start())
+	assert.Empty(app.Views.watchers, "the view watchers should be stopped if listening fails")
+
+	// the server exits with an error.
+	tcpListener, err := net.Listen("tcp", DefaultMockBindAddr)
+	assert.Nil(err)
+	app = New(OptListener(testFailingListener{tcpListener}), OptViews(newViews()))
+	assert.NotNil(app.Start())
+	assert.Empty(app.Views.watchers, "the view watchers should be stopped if the server exits with an error")
+	assert.Nil(app.NetListener)
+	_, err = tcpListener.Accept()
+	assert.NotNil(err, "the listener should be closed if the server exits with an error")
+}
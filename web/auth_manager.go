@@ -6,19 +6,40 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/blend/go-sdk/ex"
 	"github.com/blend/go-sdk/webutil"
 )
 
 // NewAuthManager returns a new auth manager from a given config.
-// For remote mode, you must provide a fetch, persist, and remove handler, and optionally a login redirect handler.
-func NewAuthManager(cfg Config) (manager AuthManager) {
+// For remote mode, you must either configure a session store provider, or provide a fetch, persist, and remove handler,
+// and optionally a login redirect handler.
+// It panics if the configured session store can't be created; use `NewAuthManagerWithError` to handle that error.
+func NewAuthManager(cfg Config) AuthManager {
+	manager, err := NewAuthManagerWithError(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return manager
+}
+
+// NewAuthManagerWithError returns a new auth manager from a given config, or an error
+// if the configured session store provider is not registered or the store can't be created.
+func NewAuthManagerWithError(cfg Config) (manager AuthManager, err error) {
 	switch cfg.AuthManagerModeOrDefault() {
 	case AuthManagerModeJWT:
 		manager = NewJWTAuthManager(cfg.MustAuthSecret())
 	case AuthManagerModeLocal: // local should only be used for debugging.
 		manager = NewLocalAuthManager()
 	case AuthManagerModeRemote:
-		manager = NewRemoteAuthManager()
+		if cfg.SessionStore.IsZero() {
+			manager = NewRemoteAuthManager()
+			break
+		}
+		var store SessionStore
+		if store, err = NewSessionStore(cfg.SessionStore); err != nil {
+			return
+		}
+		manager = NewAuthManagerFromSessionStore(store)
 	default:
 		panic("invalid auth manager mode")
 	}
@@ -29,7 +50,7 @@ func NewAuthManager(cfg Config) (manager AuthManager) {
 	manager.CookiePath = cfg.CookiePathOrDefault()
 	manager.CookieSameSite = cfg.CookieSameSiteOrDefault()
 	manager.SessionTimeoutProvider = SessionTimeoutProvider(!cfg.SessionTimeoutIsRelative, cfg.SessionTimeoutOrDefault())
-	return manager
+	return
 }
 
 // NewRemoteAuthManager returns an empty auth manager.
@@ -43,6 +64,24 @@ func NewRemoteAuthManager() AuthManager {
 	}
 }
 
+// NewAuthManagerFromSessionStore returns a new remote auth manager that saves sessions to a given session store.
+// Verifying a session touches its expiry in the store rather than persisting the whole session.
+func NewAuthManagerFromSessionStore(store SessionStore) AuthManager {
+	return AuthManager{
+		Mode:           AuthManagerModeRemote,
+		CookieSecure:   DefaultCookieSecure,
+		CookieHTTPOnly: DefaultCookieHTTPOnly,
+		CookieSameSite: DefaultCookieSameSite,
+		SessionStore:   store,
+		PersistHandler: store.PersistSession,
+		FetchHandler:   store.FetchSession,
+		RemoveHandler:  store.RemoveSession,
+		TouchHandler: func(ctx context.Context, session *Session) error {
+			return store.TouchSession(ctx, session.SessionID, session.ExpiresUTC)
+		},
+	}
+}
+
 // NewLocalAuthManagerFromCache returns a new locally cached session manager that saves sessions to the cache provided
 func NewLocalAuthManagerFromCache(cache *LocalSessionCache) AuthManager {
 	return AuthManager{
@@ -97,6 +136,9 @@ type AuthManagerFetchHandler func(context.Context, string) (*Session, error)
 // AuthManagerRemoveHandler removes a session based on a session value.
 type AuthManagerRemoveHandler func(context.Context, string) error
 
+// AuthManagerTouchHandler updates the expiry of a session in a stable store.
+type AuthManagerTouchHandler func(context.Context, *Session) error
+
 // AuthManagerValidateHandler validates a session.
 type AuthManagerValidateHandler func(context.Context, *Session) error
 
@@ -121,9 +163,19 @@ type AuthManager struct {
 	SerializeSessionValueHandler AuthManagerSerializeSessionValueHandler
 	ParseSessionValueHandler     AuthManagerParseSessionValueHandler
 
+	// SessionStore is the store sessions are persisted to, if the manager was created from one.
+	// Expired sessions are swept from it while the app is running.
+	SessionStore SessionStore
+
 	PersistHandler AuthManagerPersistHandler
 	FetchHandler   AuthManagerFetchHandler
 	RemoveHandler  AuthManagerRemoveHandler
+	// TouchHandler, if set, is used instead of the persist handler to update a session's expiry when it's verified.
+	TouchHandler AuthManagerTouchHandler
+
+	// RevocationList, if set, is consulted when sessions are verified and records sessions that are
+	// logged out or revoked. It is required to revoke jwt sessions.
+	RevocationList SessionRevocationList
 
 	ValidateHandler          AuthManagerValidateHandler
 	SessionTimeoutProvider   AuthManagerSessionTimeoutProvider
@@ -137,37 +189,113 @@ type AuthManager struct {
 
 // Login logs a userID in.
 func (am AuthManager) Login(userID string, ctx *Ctx) (session *Session, err error) {
-	// create a new session value
-	sessionValue := NewSessionID()
 	// userID and sessionID are required
-	session = NewSession(userID, sessionValue)
-	if am.SessionTimeoutProvider != nil {
-		session.ExpiresUTC = am.SessionTimeoutProvider(session)
-	}
+	session = NewSession(userID, NewSessionID())
 	session.UserAgent = webutil.GetUserAgent(ctx.Request)
 	session.RemoteAddr = webutil.GetRemoteAddr(ctx.Request)
+	if err = am.issue(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
 
-	// call the perist handler if one's been provided
-	if am.PersistHandler != nil {
-		err = am.PersistHandler(ctx.Context(), session)
-		if err != nil {
+// RotateSession replaces the current session with a new session id, keeping its user, base url and state.
+/*
+Rotate the session when its privileges change, e.g. after a user re-authenticates to elevate their
+access, so that a session id captured before the change can't be used after it. The previous session
+is removed and, if there is a revocation list, revoked.
+*/
+func (am AuthManager) RotateSession(ctx *Ctx) (*Session, error) {
+	previous := ctx.Session
+	if previous == nil {
+		var err error
+		if previous, err = am.VerifySession(ctx); err != nil {
 			return nil, err
 		}
+		if previous == nil {
+			return nil, ex.New(ErrSessionIDEmpty)
+		}
 	}
+	previousValue := am.readSessionValue(ctx)
 
-	// if we're in jwt mode, serialize the jwt.
-	if am.SerializeSessionValueHandler != nil {
-		sessionValue, err = am.SerializeSessionValueHandler(ctx.Context(), session)
-		if err != nil {
+	session := NewSession(previous.UserID, NewSessionID())
+	session.BaseURL = previous.BaseURL
+	session.UserAgent = webutil.GetUserAgent(ctx.Request)
+	session.RemoteAddr = webutil.GetRemoteAddr(ctx.Request)
+	for key, value := range previous.State {
+		session.State[key] = value
+	}
+	if err := am.issue(ctx, session); err != nil {
+		return nil, err
+	}
+
+	if am.RemoveHandler != nil && previousValue != "" {
+		if err := am.RemoveHandler(ctx.Context(), previousValue); err != nil {
 			return nil, err
 		}
 	}
-
-	// inject cookies into the response
-	am.injectCookie(ctx, am.CookieNameOrDefault(), sessionValue, session.ExpiresUTC)
+	if am.RevocationList != nil {
+		if err := am.RevocationList.RevokeSession(ctx.Context(), previous.SessionID, previous.ExpiresUTC); err != nil {
+			return nil, err
+		}
+	}
+	ctx.Session = session
 	return session, nil
 }
 
+// UserSessions returns every session for a user.
+// It requires the auth manager to have a session store.
+func (am AuthManager) UserSessions(ctx context.Context, userID string) ([]*Session, error) {
+	if am.SessionStore == nil {
+		return nil, ex.New(ErrSessionStoreUnset)
+	}
+	return am.SessionStore.SessionsByUserID(ctx, userID)
+}
+
+// RevokeSession removes and revokes a session, e.g. one returned by `UserSessions`.
+func (am AuthManager) RevokeSession(ctx context.Context, session *Session) error {
+	if am.SessionStore == nil && am.RevocationList == nil {
+		return ex.New(ErrSessionRevocationUnsupported)
+	}
+	if am.SessionStore != nil {
+		if err := am.SessionStore.RemoveSession(ctx, session.SessionID); err != nil {
+			return err
+		}
+	}
+	if am.RevocationList != nil {
+		return am.RevocationList.RevokeSession(ctx, session.SessionID, session.ExpiresUTC)
+	}
+	return nil
+}
+
+// RevokeUserSessions logs a user out everywhere by removing and revoking every session for the user.
+/*
+Sessions are removed from the session store, and if there is a revocation list every session created
+up to now is revoked, which is how jwt sessions are revoked. Because jwt issue times are in seconds,
+sessions are compared to the second, so sessions created in the same second as the revocation, including
+a login right after it, are revoked too and have to log in again.
+*/
+func (am AuthManager) RevokeUserSessions(ctx context.Context, userID string) error {
+	if am.SessionStore == nil && am.RevocationList == nil {
+		return ex.New(ErrSessionRevocationUnsupported)
+	}
+	if am.SessionStore != nil {
+		if err := am.SessionStore.RemoveSessionsByUserID(ctx, userID); err != nil {
+			return err
+		}
+	}
+	if am.RevocationList != nil {
+		now := time.Now().UTC().Truncate(time.Second)
+		// keep the revocation until sessions issued before it would have expired.
+		var expiresUTC time.Time
+		if am.SessionTimeoutProvider != nil {
+			expiresUTC = am.SessionTimeoutProvider(NewSession(userID, ""))
+		}
+		return am.RevocationList.RevokeUserSessions(ctx, userID, now, expiresUTC)
+	}
+	return nil
+}
+
 // Logout unauthenticates a session.
 func (am AuthManager) Logout(ctx *Ctx) error {
 	sessionValue := am.readSessionValue(ctx)
@@ -176,6 +304,22 @@ func (am AuthManager) Logout(ctx *Ctx) error {
 		return nil
 	}
 
+	// revoke the session so the session value can't be used again
+	if am.RevocationList != nil {
+		session := ctx.Session
+		if session == nil {
+			var err error
+			if session, err = am.readSession(ctx.Context(), sessionValue); err != nil && !IsErrSessionInvalid(err) {
+				return err
+			}
+		}
+		if session != nil && !session.IsZero() {
+			if err := am.RevocationList.RevokeSession(ctx.Context(), session.SessionID, session.ExpiresUTC); err != nil {
+				return err
+			}
+		}
+	}
+
 	// issue the expiration cookies to the response
 	ctx.ExpireCookie(am.CookieNameOrDefault(), am.CookiePathOrDefault())
 	ctx.Session = nil
@@ -222,6 +366,20 @@ func (am AuthManager) VerifySession(ctx *Ctx) (session *Session, err error) {
 		return
 	}
 
+	// if the session has been revoked, treat it as invalid
+	if am.RevocationList != nil {
+		var revoked bool
+		revoked, err = am.RevocationList.IsSessionRevoked(ctx.Context(), session)
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			session = nil
+			err = am.expire(ctx, sessionValue)
+			return
+		}
+	}
+
 	// call a custom validate handler if one's been provided.
 	if am.ValidateHandler != nil {
 		err = am.ValidateHandler(ctx.Context(), session)
@@ -232,7 +390,12 @@ func (am AuthManager) VerifySession(ctx *Ctx) (session *Session, err error) {
 
 	if am.SessionTimeoutProvider != nil {
 		session.ExpiresUTC = am.SessionTimeoutProvider(session)
-		if am.PersistHandler != nil {
+		if am.TouchHandler != nil {
+			err = am.TouchHandler(ctx.Context(), session)
+			if err != nil {
+				return nil, err
+			}
+		} else if am.PersistHandler != nil {
 			err = am.PersistHandler(ctx.Context(), session)
 			if err != nil {
 				return nil, err
@@ -304,6 +467,43 @@ func (am AuthManager) shouldUpdateSessionExpiry() bool {
 	return am.SessionTimeoutProvider != nil
 }
 
+// issue sets a new session's expiry, persists it and writes its cookie.
+func (am AuthManager) issue(ctx *Ctx, session *Session) (err error) {
+	sessionValue := session.SessionID
+	if am.SessionTimeoutProvider != nil {
+		session.ExpiresUTC = am.SessionTimeoutProvider(session)
+	}
+
+	// call the perist handler if one's been provided
+	if am.PersistHandler != nil {
+		if err = am.PersistHandler(ctx.Context(), session); err != nil {
+			return
+		}
+	}
+
+	// if we're in jwt mode, serialize the jwt.
+	if am.SerializeSessionValueHandler != nil {
+		if sessionValue, err = am.SerializeSessionValueHandler(ctx.Context(), session); err != nil {
+			return
+		}
+	}
+
+	// inject cookies into the response
+	am.injectCookie(ctx, am.CookieNameOrDefault(), sessionValue, session.ExpiresUTC)
+	return
+}
+
+// readSession parses or fetches the session for a session value.
+func (am AuthManager) readSession(ctx context.Context, sessionValue string) (*Session, error) {
+	if am.ParseSessionValueHandler != nil {
+		return am.ParseSessionValueHandler(ctx, sessionValue)
+	}
+	if am.FetchHandler != nil {
+		return am.FetchHandler(ctx, sessionValue)
+	}
+	return nil, nil
+}
+
 // InjectCookie injects a session cookie into the context.
 func (am AuthManager) injectCookie(ctx *Ctx, name, value string, expire time.Time) {
 	ctx.WriteNewCookie(&http.Cookie{
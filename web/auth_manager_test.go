@@ -8,6 +8,7 @@ import (
 
 	"github.com/blend/go-sdk/assert"
 	"github.com/blend/go-sdk/crypto"
+	"github.com/blend/go-sdk/ex"
 	"github.com/blend/go-sdk/uuid"
 	"github.com/blend/go-sdk/webutil"
 )
@@ -230,3 +231,216 @@ func TestAuthManagerVerifySessionExpired(t *testing.T) {
 	assert.Equal(am.CookiePathOrDefault(), cookie.Path)
 	assert.True(cookie.Expires.Before(time.Now().UTC()), "the cookie should be expired")
 }
+
+func TestNewAuthManagerSessionStore(t *testing.T) {
+	assert := assert.New(t)
+
+	am, err := NewAuthManagerWithError(Config{
+		SessionTimeoutIsRelative: true,
+		SessionStore:             SessionStoreConfig{Provider: SessionStoreProviderLocal},
+	})
+	assert.Nil(err)
+	assert.Equal(AuthManagerModeRemote, am.Mode)
+	assert.NotNil(am.SessionStore)
+	store := am.SessionStore.(*LocalSessionCache)
+
+	res := webutil.NewMockResponse(new(bytes.Buffer))
+	session, err := am.Login("example-string", NewCtx(res, webutil.NewMockRequest("GET", "/")))
+	assert.Nil(err)
+	assert.NotNil(store.Get(session.SessionID))
+
+	var persisted bool
+	am.PersistHandler = func(_ context.Context, _ *Session) error {
+		persisted = true
+		return nil
+	}
+	before := session.ExpiresUTC
+	ctx := NewCtx(webutil.NewMockResponse(new(bytes.Buffer)), webutil.NewMockRequest("GET", "/"))
+	ctx.Request.AddCookie(ReadSetCookies(res.Header())[0])
+	verified, err := am.VerifySession(ctx)
+	assert.Nil(err)
+	assert.NotNil(verified)
+	assert.False(persisted, "verifying a session should touch it rather than persist it")
+	assert.True(store.Get(session.SessionID).ExpiresUTC.After(before))
+
+	assert.Nil(am.Logout(ctx))
+	assert.Nil(store.Get(session.SessionID))
+
+	_, err = NewSessionStore(SessionStoreConfig{Provider: "not-a-provider"})
+	assert.True(ex.Is(err, ErrSessionStoreProviderUnknown))
+	_, err = NewAuthManagerWithError(Config{SessionStore: SessionStoreConfig{Provider: SessionStoreProviderPostgres}})
+	assert.True(ex.Is(err, ErrSessionStoreProviderUnknown), "the postgres provider is only registered by the dbsession package")
+	assert.Equal(AuthManagerModeRemote, NewAuthManager(Config{SessionStore: SessionStoreConfig{Provider: SessionStoreProviderLocal}}).Mode)
+
+	// the error is returned when the app starts.
+	app := New(OptConfig(Config{SessionStore: SessionStoreConfig{Provider: "not-a-provider"}}))
+	assert.True(ex.Is(app.Start(), ErrSessionStoreProviderUnknown))
+}
+
+func TestSessionStoreSweptWhileRunning(t *testing.T) {
+	assert := assert.New(t)
+
+	store := NewLocalSessionCache()
+	expired := NewSession("example-string", NewSessionID())
+	expired.ExpiresUTC = time.Now().UTC().Add(-time.Minute)
+	store.Upsert(expired)
+
+	app := New(OptBindAddr(DefaultMockBindAddr), OptAuth(NewAuthManagerFromSessionStore(store)))
+	app.Config.SessionStore.SweepInterval = time.Millisecond
+	go app.Start()
+	<-app.NotifyStarted()
+	defer app.Stop()
+
+	deadline := time.Now().Add(time.Second)
+	for store.Get(expired.SessionID) != nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Nil(store.Get(expired.SessionID))
+}
+
+func TestAuthManagerRotateSession(t *testing.T) {
+	assert := assert.New(t)
+
+	store := NewLocalSessionCache()
+	am := NewAuthManagerFromSessionStore(store)
+	am.RevocationList = NewLocalSessionRevocationList()
+
+	session, err := am.Login("example-string", NewCtx(webutil.NewMockResponse(new(bytes.Buffer)), webutil.NewMockRequest("GET", "/")))
+	assert.Nil(err)
+	session.State["foo"] = "bar"
+
+	res := webutil.NewMockResponse(new(bytes.Buffer))
+	ctx := NewCtx(res, webutil.NewMockRequestWithCookie("GET", "/", am.CookieNameOrDefault(), session.SessionID))
+	rotated, err := am.RotateSession(ctx)
+	assert.Nil(err)
+	assert.NotEqual(session.SessionID, rotated.SessionID)
+	assert.Equal(session.UserID, rotated.UserID)
+	assert.Equal("bar", rotated.State["foo"])
+	assert.Equal(rotated, ctx.Session)
+	assert.Nil(store.Get(session.SessionID), "the previous session should be removed")
+	assert.NotNil(store.Get(rotated.SessionID))
+
+	cookies := ReadSetCookies(res.Header())
+	assert.Equal(rotated.SessionID, cookies[len(cookies)-1].Value)
+
+	// the previous session value can no longer be used, even if it's persisted again.
+	store.Upsert(session)
+	ctx = NewCtx(webutil.NewMockResponse(new(bytes.Buffer)), webutil.NewMockRequestWithCookie("GET", "/", am.CookieNameOrDefault(), session.SessionID))
+	verified, err := am.VerifySession(ctx)
+	assert.Nil(err)
+	assert.Nil(verified)
+
+	_, err = am.RotateSession(NewCtx(webutil.NewMockResponse(new(bytes.Buffer)), webutil.NewMockRequest("GET", "/")))
+	assert.True(ex.Is(err, ErrSessionIDEmpty))
+}
+
+func TestAuthManagerRevokeUserSessions(t *testing.T) {
+	assert := assert.New(t)
+
+	am := NewAuthManagerFromSessionStore(NewLocalSessionCache())
+	var values []string
+	for x := 0; x < 3; x++ {
+		userID := "example-string"
+		if x == 2 {
+			userID = "other-user"
+		}
+		res := webutil.NewMockResponse(new(bytes.Buffer))
+		_, err := am.Login(userID, NewCtx(res, webutil.NewMockRequest("GET", "/")))
+		assert.Nil(err)
+		values = append(values, ReadSetCookies(res.Header())[0].Value)
+	}
+
+	sessions, err := am.UserSessions(context.Background(), "example-string")
+	assert.Nil(err)
+	assert.Len(sessions, 2)
+
+	assert.Nil(am.RevokeSession(context.Background(), sessions[0]))
+	sessions, err = am.UserSessions(context.Background(), "example-string")
+	assert.Nil(err)
+	assert.Len(sessions, 1)
+
+	assert.Nil(am.RevokeUserSessions(context.Background(), "example-string"))
+	for index, value := range values {
+		session, err := am.VerifySession(NewCtx(webutil.NewMockResponse(new(bytes.Buffer)), webutil.NewMockRequestWithCookie("GET", "/", am.CookieNameOrDefault(), value)))
+		assert.Nil(err)
+		if index < 2 {
+			assert.Nil(session)
+		} else {
+			assert.NotNil(session, "other users' sessions should not be revoked")
+		}
+	}
+
+	_, err = NewRemoteAuthManager().UserSessions(context.Background(), "example-string")
+	assert.True(ex.Is(err, ErrSessionStoreUnset))
+	assert.True(ex.Is(NewRemoteAuthManager().RevokeUserSessions(context.Background(), "example-string"), ErrSessionRevocationUnsupported))
+}
+
+func TestAuthManagerRevokeJWTSessions(t *testing.T) {
+	assert := assert.New(t)
+
+	am := NewJWTAuthManager(crypto.MustCreateKey(64))
+	am.CookieSameSite = DefaultCookieSameSite
+	am.RevocationList = NewLocalSessionRevocationList()
+
+	login := func() string {
+		res := webutil.NewMockResponse(new(bytes.Buffer))
+		_, err := am.Login("example-string", NewCtx(res, webutil.NewMockRequest("GET", "/")))
+		assert.Nil(err)
+		return ReadSetCookies(res.Header())[0].Value
+	}
+	// issue returns a token for a session created at a given time, as if it was issued then.
+	issue := func(createdUTC time.Time) string {
+		session := &Session{
+			SessionID:  NewSessionID(),
+			UserID:     "example-string",
+			CreatedUTC: createdUTC,
+			ExpiresUTC: createdUTC.Add(time.Hour),
+		}
+		token, err := am.SerializeSessionValueHandler(context.Background(), session)
+		assert.Nil(err)
+		return token
+	}
+	verify := func(token string) *Session {
+		session, err := am.VerifySession(NewCtx(webutil.NewMockResponse(new(bytes.Buffer)), webutil.NewMockRequestWithCookie("GET", "/", am.CookieNameOrDefault(), token)))
+		assert.Nil(err)
+		return session
+	}
+
+	loggedOut, other := login(), issue(time.Now().UTC().Add(-2*time.Second))
+	assert.NotNil(verify(loggedOut))
+	assert.Nil(am.Logout(NewCtx(webutil.NewMockResponse(new(bytes.Buffer)), webutil.NewMockRequestWithCookie("GET", "/", am.CookieNameOrDefault(), loggedOut))))
+	assert.Nil(verify(loggedOut), "logging out should revoke the token's jti")
+	assert.NotNil(verify(other))
+
+	assert.Nil(am.RevokeUserSessions(context.Background(), "example-string"))
+	assert.Nil(verify(other), "every token issued before the revocation should be revoked")
+}
+
+func TestAuthManagerRevokeJWTSessionsThenLogin(t *testing.T) {
+	assert := assert.New(t)
+
+	am := NewJWTAuthManager(crypto.MustCreateKey(64))
+	am.CookieSameSite = DefaultCookieSameSite
+	am.RevocationList = NewLocalSessionRevocationList()
+
+	verify := func(createdUTC time.Time) *Session {
+		token, err := am.SerializeSessionValueHandler(context.Background(), &Session{
+			SessionID:  NewSessionID(),
+			UserID:     "example-string",
+			CreatedUTC: createdUTC,
+			ExpiresUTC: createdUTC.Add(time.Hour),
+		})
+		assert.Nil(err)
+		session, err := am.VerifySession(NewCtx(webutil.NewMockResponse(new(bytes.Buffer)), webutil.NewMockRequestWithCookie("GET", "/", am.CookieNameOrDefault(), token)))
+		assert.Nil(err)
+		return session
+	}
+
+	revokedUTC := time.Now().UTC()
+	assert.Nil(am.RevokeUserSessions(context.Background(), "example-string"))
+	// a login in the same second as the revocation is revoked too, and has to be redone.
+	assert.Nil(verify(revokedUTC), "a session created in the same second as the revocation should be revoked")
+	// wait for the next second, as issue times are in seconds.
+	time.Sleep(time.Until(time.Now().UTC().Truncate(time.Second).Add(time.Second)))
+	assert.NotNil(verify(time.Now().UTC()), "a session created after the revocation should be verified")
+}
@@ -0,0 +1,264 @@
+package web
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// Bind struct tags.
+const (
+	// BindTagParam is the struct tag for fields populated from route parameters.
+	BindTagParam = "param"
+	// BindTagQuery is the struct tag for fields populated from query values.
+	BindTagQuery = "query"
+	// BindTagForm is the struct tag for fields populated from post form values.
+	BindTagForm = "form"
+	// BindTagHeader is the struct tag for fields populated from request headers.
+	BindTagHeader = "header"
+)
+
+var (
+	typeDuration        = reflect.TypeOf(time.Duration(0))
+	typeTime            = reflect.TypeOf(time.Time{})
+	typeTextUnmarshaler = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Bind populates a given struct from the request, and then validates it.
+/*
+The request body is decoded first if the content type is json or xml, then
+fields are populated from the route parameters, query, post form and headers
+based on struct tags:
+
+	type createUserArgs struct {
+		OrgID   int      `param:"orgID" validate:"required,min=1"`
+		DryRun  bool     `query:"dryRun"`
+		Tags    []string `query:"tag"`
+		TraceID string   `header:"X-Trace-ID"`
+		Email   string   `json:"email" validate:"required,regex=^.+@.+$"`
+		Role    string   `json:"role" validate:"enum=admin|member"`
+	}
+
+	var args createUserArgs
+	if err := ctx.Bind(&args); err != nil {
+		return ctx.DefaultProvider.BadRequest(err)
+	}
+
+Slice fields are populated with every value for a key. Nested struct fields
+without tags are bound recursively. See `Validate` for the supported validation rules.
+
+If a field cannot be bound or does not validate, a `FieldErrors` is returned, which
+the built in result providers render as a structured bad request.
+*/
+func (rc *Ctx) Bind(dst interface{}) error {
+	dstValue := reflect.ValueOf(dst)
+	if dstValue.Kind() != reflect.Ptr || dstValue.IsNil() || dstValue.Elem().Kind() != reflect.Struct {
+		return ex.New(ErrBindTarget, ex.OptMessagef("type: %T", dst))
+	}
+
+	if err := rc.bindBody(dst); err != nil {
+		return err
+	}
+	if fieldErrors := rc.bindFields(dstValue.Elem()); len(fieldErrors) > 0 {
+		return fieldErrors
+	}
+	return Validate(dst)
+}
+
+// bindBody decodes the post body into the target if it has a json or xml content type.
+func (rc *Ctx) bindBody(dst interface{}) error {
+	if rc.Request == nil || rc.Request.Header == nil {
+		return nil
+	}
+	contentType := rc.Request.Header.Get(HeaderContentType)
+	if contentType == "" {
+		return nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return FieldErrors{{Field: HeaderContentType, Source: FieldSourceHeader, Message: "invalid content type"}}
+	}
+
+	var unmarshal func([]byte, interface{}) error
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		unmarshal = json.Unmarshal
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		unmarshal = xml.Unmarshal
+	default:
+		return nil
+	}
+
+	body, err := rc.PostBody()
+	if err != nil {
+		return err
+	}
+	if len(body) == 0 {
+		return nil
+	}
+	if err := unmarshal(body, dst); err != nil {
+		return FieldErrors{{Field: FieldSourceBody, Source: FieldSourceBody, Message: err.Error()}}
+	}
+	return nil
+}
+
+// bindFields populates the fields of a struct value from tagged request sources.
+func (rc *Ctx) bindFields(structValue reflect.Value) (output FieldErrors) {
+	structType := structValue.Type()
+	for x := 0; x < structType.NumField(); x++ {
+		field := structType.Field(x)
+		fieldValue := structValue.Field(x)
+
+		source, name, values := rc.bindValues(field)
+		if source == "" {
+			// embedded structs are walked even if their type is unexported.
+			if isNestedStruct(field.Type) && (field.Anonymous || fieldValue.CanSet()) {
+				output = append(output, rc.bindFields(fieldValue)...)
+			}
+			continue
+		}
+		if !fieldValue.CanSet() {
+			continue
+		}
+		if len(values) == 0 {
+			continue
+		}
+		if err := setFieldValues(fieldValue, values); err != nil {
+			output = append(output, FieldError{
+				Field:   name,
+				Source:  source,
+				Message: err.Error(),
+			})
+		}
+	}
+	return
+}
+
+// bindValues returns the source, key and values for a given field based on its tags.
+func (rc *Ctx) bindValues(field reflect.StructField) (source, name string, values []string) {
+	if name = field.Tag.Get(BindTagParam); name != "" {
+		source = FieldSourceParam
+		if value, ok := rc.RouteParams[name]; ok {
+			values = []string{value}
+		}
+		return
+	}
+	if name = field.Tag.Get(BindTagQuery); name != "" {
+		source = FieldSourceQuery
+		if rc.Request != nil && rc.Request.URL != nil {
+			values = rc.Request.URL.Query()[name]
+		}
+		return
+	}
+	if name = field.Tag.Get(BindTagForm); name != "" {
+		source = FieldSourceForm
+		if rc.Request != nil && rc.ensureForm() == nil {
+			values = rc.Form[name]
+		}
+		return
+	}
+	if name = field.Tag.Get(BindTagHeader); name != "" {
+		source = FieldSourceHeader
+		if rc.Request != nil && rc.Request.Header != nil {
+			values = rc.Request.Header[http.CanonicalHeaderKey(name)]
+		}
+		return
+	}
+	return
+}
+
+// isNestedStruct returns if a field type should be bound or validated recursively.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	return !reflect.PtrTo(t).Implements(typeTextUnmarshaler)
+}
+
+// setFieldValues sets a field from a list of raw values.
+func setFieldValues(fieldValue reflect.Value, values []string) error {
+	if fieldValue.Kind() == reflect.Slice && fieldValue.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(fieldValue.Type(), len(values), len(values))
+		for index, value := range values {
+			if err := setFieldValue(slice.Index(index), value); err != nil {
+				return err
+			}
+		}
+		fieldValue.Set(slice)
+		return nil
+	}
+	return setFieldValue(fieldValue, values[0])
+}
+
+// setFieldValue parses a raw value into a field based on the field's type.
+func setFieldValue(fieldValue reflect.Value, raw string) error {
+	if fieldValue.Kind() == reflect.Ptr {
+		elem := reflect.New(fieldValue.Type().Elem())
+		if err := setFieldValue(elem.Elem(), raw); err != nil {
+			return err
+		}
+		fieldValue.Set(elem)
+		return nil
+	}
+
+	if fieldValue.CanAddr() {
+		if typed, ok := fieldValue.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			if err := typed.UnmarshalText([]byte(raw)); err != nil {
+				return fmt.Errorf("invalid %s value", fieldValue.Type().String())
+			}
+			return nil
+		}
+	}
+
+	if fieldValue.Type() == typeDuration {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration value")
+		}
+		fieldValue.SetInt(int64(parsed))
+		return nil
+	}
+
+	switch fieldValue.Kind() {
+	case reflect.String:
+		fieldValue.SetString(raw)
+	case reflect.Slice: // []byte
+		fieldValue.SetBytes([]byte(raw))
+	case reflect.Bool:
+		parsed, err := BoolValue(raw, nil)
+		if err != nil {
+			return err
+		}
+		fieldValue.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, fieldValue.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer value")
+		}
+		fieldValue.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, fieldValue.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer value")
+		}
+		fieldValue.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, fieldValue.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid float value")
+		}
+		fieldValue.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported field type %s", fieldValue.Type().String())
+	}
+	return nil
+}
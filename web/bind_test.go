@@ -0,0 +1,149 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/webutil"
+)
+
+type bindTestPaging struct {
+	Limit int `query:"limit" validate:"max=100"`
+}
+
+type bindTestArgs struct {
+	bindTestPaging
+
+	ID      int64         `param:"id" validate:"required,min=1"`
+	DryRun  bool          `query:"dryRun"`
+	Tags    []string      `query:"tag" validate:"max=3"`
+	Timeout time.Duration `query:"timeout"`
+	Since   *time.Time    `query:"since"`
+	TraceID string        `header:"X-Trace-ID"`
+	Email   string        `json:"email" validate:"required,regex=^[^@]+@[^@,]+$"`
+	Role    string        `json:"role" validate:"enum=admin|member"`
+}
+
+func TestCtxBind(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := MockCtx("POST", "/users/123",
+		OptCtxRouteParamValue("id", "123"),
+		OptCtxQueryValue("dryRun", "true"),
+		OptCtxQueryValue("timeout", "5s"),
+		OptCtxQueryValue("since", "2019-01-02T03:04:05Z"),
+		OptCtxQueryValue("limit", "50"),
+		OptCtxHeaderValue("X-Trace-ID", "trace-id"),
+		OptCtxHeaderValue(HeaderContentType, ContentTypeApplicationJSON),
+		OptCtxBodyBytes([]byte(`{"email":"foo@bar.com","role":"admin"}`)),
+	)
+	ctx.Request.URL.RawQuery = ctx.Request.URL.RawQuery + "&tag=a&tag=b"
+
+	var args bindTestArgs
+	assert.Nil(ctx.Bind(&args))
+	assert.Equal(123, args.ID)
+	assert.True(args.DryRun)
+	assert.Equal([]string{"a", "b"}, args.Tags)
+	assert.Equal(5*time.Second, args.Timeout)
+	assert.NotNil(args.Since)
+	assert.Equal(2019, args.Since.Year())
+	assert.Equal(50, args.Limit)
+	assert.Equal("trace-id", args.TraceID)
+	assert.Equal("foo@bar.com", args.Email)
+	assert.Equal("admin", args.Role)
+}
+
+func TestCtxBindForm(t *testing.T) {
+	assert := assert.New(t)
+
+	var args struct {
+		Name  string `form:"name" validate:"required"`
+		Count uint8  `form:"count"`
+	}
+	ctx := MockCtx("POST", "/",
+		OptCtxHeaderValue(HeaderContentType, webutil.ContentTypeApplicationFormEncoded),
+		OptCtxBodyBytes([]byte(url.Values{"name": {"foo"}, "count": {"7"}}.Encode())),
+	)
+	assert.Nil(ctx.Bind(&args))
+	assert.Equal("foo", args.Name)
+	assert.Equal(7, args.Count)
+}
+
+func TestCtxBindErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	var args bindTestArgs
+	ctx := MockCtx("GET", "/", OptCtxRouteParamValue("id", "not-a-number"))
+	err := ctx.Bind(&args)
+	assert.True(IsErrFieldValidation(err))
+	fieldErrors, _ := AsFieldErrors(err)
+	assert.Len(fieldErrors, 1)
+	assert.Equal("id", fieldErrors[0].Field)
+	assert.Equal(FieldSourceParam, fieldErrors[0].Source)
+
+	ctx = MockCtx("POST", "/",
+		OptCtxHeaderValue(HeaderContentType, ContentTypeApplicationJSON),
+		OptCtxBodyBytes([]byte(`{"email":`)),
+	)
+	fieldErrors, _ = AsFieldErrors(ctx.Bind(&args))
+	assert.Len(fieldErrors, 1)
+	assert.Equal(FieldSourceBody, fieldErrors[0].Source)
+
+	assert.True(ex.Is(ctx.Bind(args), ErrBindTarget))
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	err := Validate(bindTestArgs{
+		bindTestPaging: bindTestPaging{Limit: 101},
+		Tags:           []string{"a", "b", "c", "d"},
+		Email:          "not-an-email",
+		Role:           "owner",
+	})
+	fieldErrors, ok := AsFieldErrors(err)
+	assert.True(ok)
+	assert.Len(fieldErrors, 5)
+
+	byField := map[string]FieldError{}
+	for _, fieldErr := range fieldErrors {
+		byField[fieldErr.Field] = fieldErr
+	}
+	assert.Equal(ValidateRuleMax, byField["limit"].Rule)
+	assert.Equal(ValidateRuleRequired, byField["id"].Rule)
+	assert.Equal(ValidateRuleMax, byField["tag"].Rule)
+	assert.Equal(ValidateRuleRegex, byField["email"].Rule)
+	assert.Equal(FieldSourceBody, byField["email"].Source)
+	assert.Equal(ValidateRuleEnum, byField["role"].Rule)
+	assert.True(strings.Contains(byField["role"].Message, "admin, member"))
+
+	assert.Nil(Validate(&bindTestArgs{ID: 1, Email: "foo@bar.com"}))
+}
+
+func TestValidateUnknownRule(t *testing.T) {
+	assert := assert.New(t)
+
+	err := Validate(struct {
+		Name string `validate:"bogus"`
+	}{Name: "foo"})
+	assert.NotNil(err)
+	assert.False(IsErrFieldValidation(err))
+}
+
+func TestJSONResultProviderFieldErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	res, ok := JSON.BadRequest(FieldErrors{{Field: "id", Message: "is required"}}).(*JSONResult)
+	assert.True(ok)
+	assert.Equal(http.StatusBadRequest, res.StatusCode)
+	typed, ok := res.Response.(FieldErrorsResponse)
+	assert.True(ok)
+	assert.Len(typed.Fields, 1)
+	assert.Equal(fmt.Sprint(ErrFieldValidation), typed.Message)
+}
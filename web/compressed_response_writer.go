@@ -1,41 +1,89 @@
 package web
 
 import (
-	"compress/gzip"
+	"bufio"
+	"mime"
+	"net"
 	"net/http"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
 )
 
 var (
-	_ ResponseWriter = (*RawResponseWriter)(nil)
+	_ ResponseWriter = (*CompressedResponseWriter)(nil)
+	_ http.Hijacker  = (*CompressedResponseWriter)(nil)
 )
 
-// NewCompressedResponseWriter returns a new gzipped response writer.
-func NewCompressedResponseWriter(w http.ResponseWriter) *CompressedResponseWriter {
-	return &CompressedResponseWriter{
+// CompressedResponseWriterOption is an option for compressed response writers.
+type CompressedResponseWriterOption func(*CompressedResponseWriter)
+
+// OptCompressedResponseEncoding sets the content encoding used to compress the response.
+func OptCompressedResponseEncoding(encoding string) CompressedResponseWriterOption {
+	return func(crw *CompressedResponseWriter) { crw.encoding = encoding }
+}
+
+// OptCompressedResponseMinSize sets the smallest response, in bytes, that will be compressed.
+func OptCompressedResponseMinSize(minSize int) CompressedResponseWriterOption {
+	return func(crw *CompressedResponseWriter) { crw.minSize = minSize }
+}
+
+// OptCompressedResponseSkipContentTypes sets content types that will not be compressed.
+// Content types that end with `/` are matched as prefixes, e.g. `image/`.
+func OptCompressedResponseSkipContentTypes(contentTypes ...string) CompressedResponseWriterOption {
+	return func(crw *CompressedResponseWriter) { crw.skipContentTypes = contentTypes }
+}
+
+// NewCompressedResponseWriter returns a new compressed response writer.
+// By default it gzips every response; use options to set the encoding, a minimum size and skipped content types.
+func NewCompressedResponseWriter(w http.ResponseWriter, options ...CompressedResponseWriterOption) *CompressedResponseWriter {
+	crw := &CompressedResponseWriter{
 		innerResponse: w,
+		encoding:      ContentEncodingGZIP,
+	}
+	for _, option := range options {
+		option(crw)
 	}
+	return crw
 }
 
 // CompressedResponseWriter is a response writer that compresses output.
+/*
+Writes are buffered until the response reaches the minimum size, is flushed or is closed, at which
+point the writer decides whether to compress it. Responses are not compressed if they're smaller than the
+minimum size when closed, have a skipped content type, have no body, are partial, or already have a
+`Content-Encoding` (e.g. a precompressed static file).
+*/
 type CompressedResponseWriter struct {
-	gzipWriter    *gzip.Writer
-	innerResponse http.ResponseWriter
+	innerResponse    http.ResponseWriter
+	encoding         string
+	minSize          int
+	skipContentTypes []string
+
+	encoder       ContentEncoder
+	decided       bool
+	buffer        []byte
 	statusCode    int
 	contentLength int
 }
 
-func (crw *CompressedResponseWriter) ensureCompressedStream() {
-	if crw.gzipWriter == nil {
-		crw.gzipWriter = gzip.NewWriter(crw.innerResponse)
-	}
-}
-
 // Write writes the byes to the stream.
 func (crw *CompressedResponseWriter) Write(b []byte) (int, error) {
-	crw.ensureCompressedStream()
-	_, err := crw.gzipWriter.Write(b)
 	crw.contentLength += len(b)
-	return len(b), err
+	if !crw.decided {
+		crw.buffer = append(crw.buffer, b...)
+		if len(crw.buffer) < crw.minSize {
+			return len(b), nil
+		}
+		if err := crw.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+	if crw.encoder != nil {
+		return crw.encoder.Write(b)
+	}
+	return crw.innerResponse.Write(b)
 }
 
 // Header returns the headers for the response.
@@ -44,9 +92,15 @@ func (crw *CompressedResponseWriter) Header() http.Header {
 }
 
 // WriteHeader writes a status code.
+// The status code is sent when the writer decides whether to compress the response.
 func (crw *CompressedResponseWriter) WriteHeader(code int) {
+	if crw.decided {
+		return
+	}
 	crw.statusCode = code
-	crw.innerResponse.WriteHeader(code)
+	if !statusAllowsBody(code) {
+		_ = crw.decide(false)
+	}
 }
 
 // StatusCode returns the status code for the request.
@@ -55,22 +109,156 @@ func (crw *CompressedResponseWriter) StatusCode() int {
 }
 
 // ContentLength returns the content length for the request.
+// It is the length of the uncompressed response.
 func (crw *CompressedResponseWriter) ContentLength() int {
 	return crw.contentLength
 }
 
+// ContentEncoding returns the content encoding the response was compressed with, or `identity`.
+func (crw *CompressedResponseWriter) ContentEncoding() string {
+	if crw.encoder != nil {
+		return crw.encoding
+	}
+	return ContentEncodingIdentity
+}
+
 // Flush pushes any buffered data out to the response.
+// It flushes both the compressed stream and the underlying response writer.
+// Responses that are flushed are compressed regardless of the minimum size, as they are likely streams.
 func (crw *CompressedResponseWriter) Flush() {
-	crw.ensureCompressedStream()
-	crw.gzipWriter.Flush()
+	if !crw.decided {
+		_ = crw.decide(true)
+	}
+	if crw.encoder != nil {
+		_ = crw.encoder.Flush()
+	}
+	if typed, ok := crw.innerResponse.(http.Flusher); ok {
+		typed.Flush()
+	}
 }
 
 // Close closes any underlying resources.
 func (crw *CompressedResponseWriter) Close() error {
-	if crw.gzipWriter != nil {
-		err := crw.gzipWriter.Close()
-		crw.gzipWriter = nil
-		return err
+	var err error
+	if !crw.decided {
+		err = crw.decide(false)
+	}
+	if crw.encoder != nil {
+		err = ex.Nest(err, crw.encoder.Close())
+		putContentEncoder(crw.encoding, crw.encoder)
+		crw.encoder = nil
+	}
+	return err
+}
+
+// Hijack implements http.Hijacker.
+// It is an error to hijack the connection after writing to the response.
+// Because hijacking is used to switch protocols, the status code is recorded as `101 Switching Protocols`.
+func (crw *CompressedResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if crw.decided || len(crw.buffer) > 0 {
+		return nil, nil, ex.New(ErrHijackUnsupported, ex.OptMessage("the response has already been written to"))
+	}
+	typed, ok := crw.innerResponse.(http.Hijacker)
+	if !ok {
+		return nil, nil, ex.New(ErrHijackUnsupported)
+	}
+	conn, brw, err := typed.Hijack()
+	if err != nil {
+		return nil, nil, ex.New(err)
+	}
+	crw.decided = true
+	crw.statusCode = http.StatusSwitchingProtocols
+	return conn, brw, nil
+}
+
+// decide writes the response headers, choosing whether to compress the response, and then writes any buffered body.
+// If `force` is false, responses smaller than the minimum size are not compressed.
+func (crw *CompressedResponseWriter) decide(force bool) error {
+	crw.decided = true
+
+	header := crw.innerResponse.Header()
+	if crw.shouldCompress(force) {
+		if header.Get(HeaderContentType) == "" && len(crw.buffer) > 0 {
+			header.Set(HeaderContentType, http.DetectContentType(crw.buffer))
+		}
+		header.Set(HeaderContentEncoding, crw.encoding)
+		header.Del(HeaderContentLength)
+		crw.encoder = getContentEncoder(crw.encoding, crw.innerResponse)
+	} else if header.Get(HeaderContentEncoding) == "" {
+		header.Set(HeaderContentEncoding, ContentEncodingIdentity)
+	}
+	if !headerContainsToken(header, HeaderVary, HeaderAcceptEncoding) {
+		header.Add(HeaderVary, HeaderAcceptEncoding)
+	}
+	if crw.statusCode != 0 {
+		crw.innerResponse.WriteHeader(crw.statusCode)
+	}
+
+	if len(crw.buffer) == 0 {
+		return nil
+	}
+	buffer := crw.buffer
+	crw.buffer = nil
+	var err error
+	if crw.encoder != nil {
+		_, err = crw.encoder.Write(buffer)
+	} else {
+		_, err = crw.innerResponse.Write(buffer)
+	}
+	return err
+}
+
+func (crw *CompressedResponseWriter) shouldCompress(force bool) bool {
+	if !HasContentEncoder(crw.encoding) {
+		return false
+	}
+	if crw.statusCode != 0 && (!statusAllowsBody(crw.statusCode) || crw.statusCode == http.StatusPartialContent) {
+		return false
+	}
+	if !force && len(crw.buffer) < crw.minSize {
+		return false
+	}
+	header := crw.innerResponse.Header()
+	if contentEncoding := header.Get(HeaderContentEncoding); contentEncoding != "" && contentEncoding != ContentEncodingIdentity {
+		return false
+	}
+	if header.Get(HeaderContentRange) != "" {
+		return false
+	}
+	return !isSkippedContentType(header.Get(HeaderContentType), crw.skipContentTypes)
+}
+
+// isSkippedContentType returns if a content type matches a list of skipped content types or prefixes.
+func isSkippedContentType(contentType string, skipContentTypes []string) bool {
+	if contentType == "" || len(skipContentTypes) == 0 {
+		return false
+	}
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		contentType = mediaType
+	}
+	contentType = strings.ToLower(contentType)
+	for _, skip := range skipContentTypes {
+		skip = strings.ToLower(skip)
+		if strings.HasSuffix(skip, "/") {
+			if strings.HasPrefix(contentType, skip) {
+				return true
+			}
+			continue
+		}
+		if contentType == skip {
+			return true
+		}
+	}
+	return false
+}
+
+// statusAllowsBody returns if a response with a given status code can have a body.
+func statusAllowsBody(statusCode int) bool {
+	switch {
+	case statusCode >= 100 && statusCode < 200:
+		return false
+	case statusCode == http.StatusNoContent, statusCode == http.StatusNotModified:
+		return false
 	}
-	return nil
+	return true
 }
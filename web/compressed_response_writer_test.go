@@ -2,9 +2,14 @@ package web
 
 import (
 	"bytes"
+	"compress/zlib"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/r2"
 	"github.com/blend/go-sdk/webutil"
 )
 
@@ -19,3 +24,117 @@ func TestBufferedCompressedWriter(t *testing.T) {
 	assert.Nil(err)
 	assert.NotZero(written)
 }
+
+func TestCompressedResponseWriterMinSize(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := bytes.NewBuffer(nil)
+	res := webutil.NewMockResponse(buf)
+	crw := NewCompressedResponseWriter(res, OptCompressedResponseMinSize(8))
+	_, err := crw.Write([]byte("small"))
+	assert.Nil(err)
+	assert.Empty(buf.Bytes(), "writes below the minimum size should be buffered")
+	assert.Nil(crw.Close())
+	assert.Equal("small", buf.String())
+	assert.Equal(ContentEncodingIdentity, res.Header().Get(HeaderContentEncoding))
+	assert.Equal(HeaderAcceptEncoding, res.Header().Get(HeaderVary))
+
+	buf = bytes.NewBuffer(nil)
+	res = webutil.NewMockResponse(buf)
+	res.Header().Set(HeaderContentLength, "11")
+	crw = NewCompressedResponseWriter(res, OptCompressedResponseEncoding(ContentEncodingDeflate), OptCompressedResponseMinSize(8))
+	_, err = crw.Write([]byte("large "))
+	assert.Nil(err)
+	_, err = crw.Write([]byte("body"))
+	assert.Nil(err)
+	assert.Nil(crw.Close())
+	assert.Equal(ContentEncodingDeflate, res.Header().Get(HeaderContentEncoding))
+	assert.Empty(res.Header().Get(HeaderContentLength))
+	assert.Equal(10, crw.ContentLength())
+
+	reader, err := zlib.NewReader(buf)
+	assert.Nil(err)
+	contents, err := ioutil.ReadAll(reader)
+	assert.Nil(err)
+	assert.Equal("large body", string(contents))
+}
+
+func TestCompressedResponseWriterFlushCompresses(t *testing.T) {
+	assert := assert.New(t)
+
+	buf := bytes.NewBuffer(nil)
+	res := webutil.NewMockResponse(buf)
+	crw := NewCompressedResponseWriter(res, OptCompressedResponseMinSize(1024))
+	_, err := crw.Write([]byte("event"))
+	assert.Nil(err)
+	crw.Flush()
+	assert.Equal(ContentEncodingGZIP, res.Header().Get(HeaderContentEncoding))
+	assert.NotEmpty(buf.Bytes())
+	assert.Nil(crw.Close())
+}
+
+func TestCompressedResponseWriterSkips(t *testing.T) {
+	assert := assert.New(t)
+
+	testCases := []struct {
+		Name   string
+		Header http.Header
+		Status int
+	}{
+		{Name: "skipped content type", Header: http.Header{HeaderContentType: {"image/png"}}},
+		{Name: "skipped content type prefix", Header: http.Header{HeaderContentType: {"video/mp4; codecs=avc1"}}},
+		{Name: "already encoded", Header: http.Header{HeaderContentEncoding: {ContentEncodingBrotli}}},
+		{Name: "partial content", Header: http.Header{HeaderContentRange: {"bytes 0-3/10"}}, Status: http.StatusPartialContent},
+		{Name: "no content", Status: http.StatusNoContent},
+	}
+	for _, testCase := range testCases {
+		buf := bytes.NewBuffer(nil)
+		res := webutil.NewMockResponse(buf)
+		for key, values := range testCase.Header {
+			res.Header()[key] = values
+		}
+		crw := NewCompressedResponseWriter(res, OptCompressedResponseSkipContentTypes("image/png", "video/"))
+		if testCase.Status != 0 {
+			crw.WriteHeader(testCase.Status)
+		}
+		if testCase.Status != http.StatusNoContent {
+			_, err := crw.Write([]byte("body"))
+			assert.Nil(err, testCase.Name)
+		}
+		assert.Nil(crw.Close(), testCase.Name)
+		assert.Equal(ContentEncodingIdentity, crw.ContentEncoding(), testCase.Name)
+		if testCase.Status != http.StatusNoContent {
+			assert.Equal("body", buf.String(), testCase.Name)
+		}
+	}
+}
+
+func TestAppNegotiatesCompression(t *testing.T) {
+	assert := assert.New(t)
+
+	body := strings.Repeat("compress me ", 128)
+	app := New(OptCompression(CompressionConfig{MinSize: 64}))
+	app.GET("/large", func(_ *Ctx) Result { return Text.Result(body) })
+	app.GET("/small", func(_ *Ctx) Result { return Text.Result("small") })
+
+	contents, res, err := MockGet(app, "/large", r2.OptHeaderValue(HeaderAcceptEncoding, "gzip;q=0.5, deflate")).BytesWithResponse()
+	assert.Nil(err)
+	assert.Equal(ContentEncodingDeflate, res.Header.Get(HeaderContentEncoding))
+	assert.Equal(ContentTypeText, res.Header.Get(HeaderContentType))
+	reader, err := zlib.NewReader(bytes.NewReader(contents))
+	assert.Nil(err)
+	decoded, err := ioutil.ReadAll(reader)
+	assert.Nil(err)
+	assert.Equal(body, string(decoded))
+
+	contents, res, err = MockGet(app, "/small", r2.OptHeaderValue(HeaderAcceptEncoding, "gzip")).BytesWithResponse()
+	assert.Nil(err)
+	assert.Equal(ContentEncodingIdentity, res.Header.Get(HeaderContentEncoding))
+	assert.Equal("small", string(contents))
+
+	app.Config.Compression.Disabled = true
+	contents, res, err = MockGet(app, "/large", r2.OptHeaderValue(HeaderAcceptEncoding, "gzip")).BytesWithResponse()
+	assert.Nil(err)
+	assert.Equal(ContentEncodingIdentity, res.Header.Get(HeaderContentEncoding))
+	assert.Equal(body, string(contents))
+}
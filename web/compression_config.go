@@ -0,0 +1,79 @@
+package web
+
+import (
+	"github.com/blend/go-sdk/configutil"
+	"github.com/blend/go-sdk/env"
+)
+
+var (
+	_ configutil.ConfigResolver = (*CompressionConfig)(nil)
+)
+
+var (
+	// DefaultCompressionEncodings are the content encodings responses are compressed with, in order of preference.
+	// They are the encodings with a built in encoder; to compress with brotli, register an encoder
+	// for it with `RegisterContentEncoder` and list it in the config encodings.
+	DefaultCompressionEncodings = []string{ContentEncodingGZIP, ContentEncodingDeflate}
+	// DefaultCompressionSkipContentTypes are content types that are already compressed.
+	// Entries that end with `/` match any content type with that prefix.
+	DefaultCompressionSkipContentTypes = []string{
+		"image/png", "image/jpeg", "image/gif", "image/webp",
+		"video/", "audio/",
+		"font/woff", "font/woff2",
+		"application/zip", "application/gzip", "application/x-gzip", "application/x-brotli",
+		"application/x-7z-compressed", "application/x-rar-compressed",
+	}
+)
+
+// CompressionConfig is the response compression config for an app.
+type CompressionConfig struct {
+	// Disabled disables response compression.
+	Disabled bool `json:"disabled,omitempty" yaml:"disabled,omitempty" env:"COMPRESSION_DISABLED"`
+	// Encodings are the content encodings to negotiate, in order of preference.
+	// Encodings without a registered encoder are skipped.
+	Encodings []string `json:"encodings,omitempty" yaml:"encodings,omitempty"`
+	// MinSize is the smallest response, in bytes, that will be compressed.
+	// Responses that are flushed before reaching the minimum size are compressed.
+	MinSize int `json:"minSize,omitempty" yaml:"minSize,omitempty" env:"COMPRESSION_MIN_SIZE"`
+	// SkipContentTypes are content types that will not be compressed.
+	SkipContentTypes []string `json:"skipContentTypes,omitempty" yaml:"skipContentTypes,omitempty"`
+}
+
+// Resolve adds extra resolution steps when we setup the config.
+func (cc *CompressionConfig) Resolve() error {
+	return env.Env().ReadInto(cc)
+}
+
+// EncodingsOrDefault returns the encodings or a default.
+func (cc CompressionConfig) EncodingsOrDefault() []string {
+	if len(cc.Encodings) > 0 {
+		return cc.Encodings
+	}
+	return DefaultCompressionEncodings
+}
+
+// MinSizeOrDefault returns the minimum size or a default.
+func (cc CompressionConfig) MinSizeOrDefault() int {
+	if cc.MinSize > 0 {
+		return cc.MinSize
+	}
+	return DefaultCompressionMinSize
+}
+
+// SkipContentTypesOrDefault returns the skipped content types or a default.
+func (cc CompressionConfig) SkipContentTypesOrDefault() []string {
+	if len(cc.SkipContentTypes) > 0 {
+		return cc.SkipContentTypes
+	}
+	return DefaultCompressionSkipContentTypes
+}
+
+// SupportedEncodings returns the configured encodings that have a registered encoder.
+func (cc CompressionConfig) SupportedEncodings() (output []string) {
+	for _, encoding := range cc.EncodingsOrDefault() {
+		if HasContentEncoder(encoding) {
+			output = append(output, encoding)
+		}
+	}
+	return
+}
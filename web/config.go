@@ -14,6 +14,9 @@ import (
 type Config struct {
 	Port                      int32         `json:"port,omitempty" yaml:"port,omitempty" env:"PORT"`
 	BindAddr                  string        `json:"bindAddr,omitempty" yaml:"bindAddr,omitempty" env:"BIND_ADDR"`
+	UnixSocket                string        `json:"unixSocket,omitempty" yaml:"unixSocket,omitempty" env:"UNIX_SOCKET"`
+	H2C                       bool          `json:"h2c,omitempty" yaml:"h2c,omitempty" env:"H2C"`
+	RequestIDs                bool          `json:"requestIDs,omitempty" yaml:"requestIDs,omitempty" env:"REQUEST_IDS"`
 	BaseURL                   string        `json:"baseURL,omitempty" yaml:"baseURL,omitempty" env:"BASE_URL"`
 	SkipRedirectTrailingSlash bool          `json:"skipRedirectTrailingSlash,omitempty" yaml:"skipRedirectTrailingSlash,omitempty"`
 	HandleOptions             bool          `json:"handleOptions,omitempty" yaml:"handleOptions,omitempty"`
@@ -38,7 +41,10 @@ type Config struct {
 	IdleTimeout         time.Duration     `json:"idleTimeout,omitempty" yaml:"idleTimeout,omitempty" env:"IDLE_TIMEOUT"`
 	ShutdownGracePeriod time.Duration     `json:"shutdownGracePeriod" yaml:"shutdownGracePeriod" env:"SHUTDOWN_GRACE_PERIOD"`
 
-	Views ViewCacheConfig `json:"views,omitempty" yaml:"views,omitempty"`
+	Views        ViewCacheConfig    `json:"views,omitempty" yaml:"views,omitempty"`
+	CORS         CORSConfig         `json:"cors,omitempty" yaml:"cors,omitempty"`
+	Compression  CompressionConfig  `json:"compression,omitempty" yaml:"compression,omitempty"`
+	SessionStore SessionStoreConfig `json:"sessionStore,omitempty" yaml:"sessionStore,omitempty"`
 }
 
 // Resolve resolves the config from other sources.
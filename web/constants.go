@@ -30,6 +30,9 @@ const (
 	// HeaderCookie is the request cookie header.
 	HeaderCookie = "Cookie"
 
+	// HeaderAuthorization is the request header that carries credentials.
+	HeaderAuthorization = "Authorization"
+
 	// HeaderDate is the "Date" header.
 	// It provides a timestamp the response was generated at.
 	// It is typically used by client cache control to invalidate expired items.
@@ -56,10 +59,18 @@ const (
 	// If provided, it specifies the size of the request or response.
 	HeaderContentLength = "Content-Length"
 
+	// HeaderContentRange is the "Content-Range" header.
+	// It indicates where in a full body a partial response belongs.
+	HeaderContentRange = "Content-Range"
+
 	// HeaderContentType is the "Content-Type" header.
 	// It specifies the MIME-type of the request or response.
 	HeaderContentType = "Content-Type"
 
+	// HeaderOrigin is the "Origin" header.
+	// It indicates the origin (scheme, host and port) a request was initiated from.
+	HeaderOrigin = "Origin"
+
 	// HeaderServer is the "Server" header.
 	// It is an informational header to tell the client what server software was used.
 	HeaderServer = "Server"
@@ -75,6 +86,10 @@ const (
 	// It is an informational header that indicates what software was used to generate the response.
 	HeaderXServedBy = "X-Served-By"
 
+	// HeaderXRequestID is the "X-Request-ID" header.
+	// It carries an id that correlates a request across services and logs.
+	HeaderXRequestID = "X-Request-ID"
+
 	// HeaderXFrameOptions is the "X-Frame-Options" header.
 	// It indicates if a browser is allowed to render the response in a <frame> element or not.
 	HeaderXFrameOptions = "X-Frame-Options"
@@ -94,6 +109,9 @@ const (
 	// We specify chartset=utf-8 so that clients know to use the UTF-8 string encoding.
 	ContentTypeApplicationJSON = "application/json; charset=UTF-8"
 
+	// ContentTypeProblemJSON is a content type for RFC 7807 problem details responses.
+	ContentTypeProblemJSON = "application/problem+json; charset=UTF-8"
+
 	// ContentTypeHTML is a content type for html responses.
 	// We specify chartset=utf-8 so that clients know to use the UTF-8 string encoding.
 	ContentTypeHTML = "text/html; charset=utf-8"
@@ -115,6 +133,10 @@ const (
 	ContentEncodingIdentity = "identity"
 	// ContentEncodingGZIP is the gzip (compressed) content encoding.
 	ContentEncodingGZIP = "gzip"
+	// ContentEncodingDeflate is the deflate (zlib compressed) content encoding.
+	ContentEncodingDeflate = "deflate"
+	// ContentEncodingBrotli is the brotli (compressed) content encoding.
+	ContentEncodingBrotli = "br"
 )
 
 // AuthManagerMode is an auth manager mode.
@@ -150,6 +172,9 @@ const (
 	// MethodPut is an http verb.
 	MethodPut = "PUT"
 
+	// MethodPatch is an http verb.
+	MethodPatch = "PATCH"
+
 	// MethodDelete is an http verb.
 	MethodDelete = "DELETE"
 
@@ -245,6 +270,12 @@ const (
 	DefaultCookieSameSite = webutil.SameSiteDefault
 	// DefaultSessionTimeout is the default absolute timeout for a session (24 hours as a sane default).
 	DefaultSessionTimeout time.Duration = 24 * time.Hour
+	// DefaultSessionStorePath is the default directory for the file session store.
+	DefaultSessionStorePath = "_sessions"
+	// DefaultSessionStoreTable is the default table for the postgres session store.
+	DefaultSessionStoreTable = "web_session"
+	// DefaultSessionStoreSweepInterval is the default interval expired sessions are removed from a session store.
+	DefaultSessionStoreSweepInterval = 5 * time.Minute
 	// DefaultUseSessionCache is the default if we should use the auth manager session cache.
 	DefaultUseSessionCache = true
 	// DefaultSessionTimeoutIsAbsolute is the default if we should set absolute session expiries.
@@ -253,6 +284,8 @@ const (
 	// DefaultHTTPSUpgradeTargetPort is the default upgrade target port.
 	DefaultHTTPSUpgradeTargetPort = 443
 
+	// DefaultCompressionMinSize is the default minimum size of a compressed response in bytes.
+	DefaultCompressionMinSize = 1024
 	// DefaultShutdownGracePeriod is the default shutdown grace period.
 	DefaultShutdownGracePeriod = 30 * time.Second
 
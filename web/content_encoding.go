@@ -0,0 +1,139 @@
+package web
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentEncoder is a reusable compressing writer, e.g. `*gzip.Writer`.
+type ContentEncoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+// ContentEncoderFactory returns a new content encoder that writes to a given writer.
+type ContentEncoderFactory func(io.Writer) ContentEncoder
+
+var (
+	contentEncodersLock sync.RWMutex
+	contentEncoders     = map[string]*sync.Pool{}
+)
+
+func init() {
+	RegisterContentEncoder(ContentEncodingGZIP, func(w io.Writer) ContentEncoder {
+		return gzip.NewWriter(w)
+	})
+	RegisterContentEncoder(ContentEncodingDeflate, func(w io.Writer) ContentEncoder {
+		return zlib.NewWriter(w)
+	})
+}
+
+// RegisterContentEncoder registers an encoder for a content encoding.
+/*
+Gzip and deflate are registered by default. Brotli is not in the standard library,
+so to serve `br` compressed responses register an encoder from a brotli package,
+and add it to the compression config encodings, e.g.:
+
+	web.RegisterContentEncoder(web.ContentEncodingBrotli, func(w io.Writer) web.ContentEncoder {
+		return brotli.NewWriter(w)
+	})
+	app := web.New(web.OptConfig(web.Config{
+		Compression: web.CompressionConfig{
+			Encodings: []string{web.ContentEncodingBrotli, web.ContentEncodingGZIP, web.ContentEncodingDeflate},
+		},
+	}))
+
+Encoders are pooled and reset between responses.
+*/
+func RegisterContentEncoder(encoding string, factory ContentEncoderFactory) {
+	contentEncodersLock.Lock()
+	defer contentEncodersLock.Unlock()
+	contentEncoders[encoding] = &sync.Pool{
+		New: func() interface{} { return factory(nil) },
+	}
+}
+
+// HasContentEncoder returns if an encoder is registered for a content encoding.
+func HasContentEncoder(encoding string) bool {
+	contentEncodersLock.RLock()
+	defer contentEncodersLock.RUnlock()
+	_, ok := contentEncoders[encoding]
+	return ok
+}
+
+// getContentEncoder returns a pooled encoder for a given encoding reset to write to a given writer.
+func getContentEncoder(encoding string, w io.Writer) ContentEncoder {
+	contentEncodersLock.RLock()
+	pool, ok := contentEncoders[encoding]
+	contentEncodersLock.RUnlock()
+	if !ok {
+		return nil
+	}
+	encoder := pool.Get().(ContentEncoder)
+	encoder.Reset(w)
+	return encoder
+}
+
+// putContentEncoder returns an encoder to its pool.
+func putContentEncoder(encoding string, encoder ContentEncoder) {
+	contentEncodersLock.RLock()
+	pool, ok := contentEncoders[encoding]
+	contentEncodersLock.RUnlock()
+	if ok {
+		encoder.Reset(nil)
+		pool.Put(encoder)
+	}
+}
+
+// NegotiateContentEncoding returns the best content encoding from a list of supported encodings,
+// in order of preference, for an `Accept-Encoding` header value.
+/*
+Encodings are weighted by their q-values, and ties are broken by the order of the supported encodings.
+If none of the supported encodings are acceptable `identity` is returned.
+*/
+func NegotiateContentEncoding(acceptEncoding string, supported ...string) string {
+	if acceptEncoding == "" {
+		return ContentEncodingIdentity
+	}
+
+	weights := map[string]float64{}
+	wildcard := -1.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		pieces := strings.Split(part, ";")
+		encoding := strings.ToLower(strings.TrimSpace(pieces[0]))
+		if encoding == "" {
+			continue
+		}
+		weight := 1.0
+		for _, param := range pieces[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if parsed, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					weight = parsed
+				}
+			}
+		}
+		if encoding == "*" {
+			wildcard = weight
+			continue
+		}
+		weights[encoding] = weight
+	}
+
+	best, bestWeight := ContentEncodingIdentity, 0.0
+	for _, encoding := range supported {
+		weight, ok := weights[encoding]
+		if !ok {
+			weight = wildcard
+		}
+		if weight > bestWeight {
+			best, bestWeight = encoding, weight
+		}
+	}
+	return best
+}
@@ -0,0 +1,67 @@
+package web
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestNegotiateContentEncoding(t *testing.T) {
+	assert := assert.New(t)
+
+	supported := []string{ContentEncodingBrotli, ContentEncodingGZIP, ContentEncodingDeflate}
+	testCases := []struct {
+		AcceptEncoding string
+		Expected       string
+	}{
+		{"", ContentEncodingIdentity},
+		{"gzip", ContentEncodingGZIP},
+		{"gzip, deflate, br", ContentEncodingBrotli},
+		{"deflate, gzip", ContentEncodingGZIP},
+		{"br;q=0.5, gzip;q=0.8", ContentEncodingGZIP},
+		{"br;q=0, gzip;q=0", ContentEncodingIdentity},
+		{"GZIP;q=0.1", ContentEncodingGZIP},
+		{"*", ContentEncodingBrotli},
+		{"*;q=0.5, br;q=0", ContentEncodingGZIP},
+		{"identity", ContentEncodingIdentity},
+		{"compress, bogus;q=bad", ContentEncodingIdentity},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(testCase.Expected, NegotiateContentEncoding(testCase.AcceptEncoding, supported...), testCase.AcceptEncoding)
+	}
+}
+
+func TestContentEncoderPool(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(HasContentEncoder(ContentEncodingGZIP))
+	assert.True(HasContentEncoder(ContentEncodingDeflate))
+	assert.False(HasContentEncoder("bogus"))
+	assert.Nil(getContentEncoder("bogus", ioutil.Discard))
+
+	for x := 0; x < 2; x++ {
+		buffer := new(bytes.Buffer)
+		encoder := getContentEncoder(ContentEncodingGZIP, buffer)
+		_, err := encoder.Write([]byte("hello"))
+		assert.Nil(err)
+		assert.Nil(encoder.Close())
+		putContentEncoder(ContentEncodingGZIP, encoder)
+
+		reader, err := gzip.NewReader(buffer)
+		assert.Nil(err)
+		contents, err := ioutil.ReadAll(reader)
+		assert.Nil(err)
+		assert.Equal("hello", string(contents))
+	}
+}
+
+func TestDefaultCompressionEncodingsRegistered(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, encoding := range DefaultCompressionEncodings {
+		assert.True(HasContentEncoder(encoding), encoding)
+	}
+}
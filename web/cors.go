@@ -0,0 +1,244 @@
+package web
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// CORS headers.
+const (
+	// HeaderAccessControlAllowOrigin is the header for the origin allowed to read the response.
+	HeaderAccessControlAllowOrigin = "Access-Control-Allow-Origin"
+	// HeaderAccessControlAllowMethods is the header for the methods allowed in cross origin requests.
+	HeaderAccessControlAllowMethods = "Access-Control-Allow-Methods"
+	// HeaderAccessControlAllowHeaders is the header for the request headers allowed in cross origin requests.
+	HeaderAccessControlAllowHeaders = "Access-Control-Allow-Headers"
+	// HeaderAccessControlAllowCredentials is the header that indicates if credentials are allowed.
+	HeaderAccessControlAllowCredentials = "Access-Control-Allow-Credentials"
+	// HeaderAccessControlExposeHeaders is the header for the response headers exposed to cross origin requests.
+	HeaderAccessControlExposeHeaders = "Access-Control-Expose-Headers"
+	// HeaderAccessControlMaxAge is the header for how long a preflight response can be cached in seconds.
+	HeaderAccessControlMaxAge = "Access-Control-Max-Age"
+	// HeaderAccessControlRequestMethod is the preflight header for the method of the actual request.
+	HeaderAccessControlRequestMethod = "Access-Control-Request-Method"
+	// HeaderAccessControlRequestHeaders is the preflight header for the headers of the actual request.
+	HeaderAccessControlRequestHeaders = "Access-Control-Request-Headers"
+
+	// CORSOriginRegexPrefix is the prefix for allowed origins that are regular expressions.
+	CORSOriginRegexPrefix = "regex:"
+)
+
+var (
+	// DefaultCORSAllowedHeaders are the request headers allowed in cross origin requests by default.
+	DefaultCORSAllowedHeaders = []string{"Accept", "Accept-Language", "Content-Language", "Content-Type", "X-Requested-With", HeaderXCSRFToken}
+	// DefaultCORSAllowedMethods are the methods allowed by default if the app's routes can't be consulted.
+	DefaultCORSAllowedMethods = []string{MethodGet, http.MethodHead, http.MethodPost}
+)
+
+// CORS errors.
+const (
+	// ErrCORSOriginInvalid is returned if an allowed origin regular expression does not compile.
+	ErrCORSOriginInvalid ex.Class = "cors allowed origin is invalid"
+	// ErrCORSAnyOriginWithCredentials is returned if any origin is allowed along with credentials,
+	// which would let every site make credentialed requests.
+	ErrCORSAnyOriginWithCredentials ex.Class = "cors cannot allow credentials from any origin"
+)
+
+// OptCORS sets the app's cors policy, which is applied to every action the app renders.
+// It is applied by `OptConfig` if the config has allowed origins.
+func OptCORS(cfg CORSConfig) Option {
+	return func(a *App) {
+		a.Config.CORS = cfg
+		a.CORS = NewCORSPolicy(cfg)
+	}
+}
+
+// NewCORSPolicy returns a new cors policy from a config.
+// Invalid origin expressions never match, and are reported by `Validate`, as is allowing
+// credentials from any origin.
+// Origin expressions must match the whole origin.
+func NewCORSPolicy(cfg CORSConfig) *CORSPolicy {
+	policy := &CORSPolicy{
+		Config:         cfg,
+		exactOrigins:   map[string]bool{},
+		allowedHeaders: map[string]bool{},
+	}
+	for _, origin := range cfg.AllowedOrigins {
+		switch {
+		case origin == "*":
+			policy.anyOrigin = true
+		case strings.HasPrefix(origin, CORSOriginRegexPrefix):
+			expr, err := regexp.Compile("^(?:" + strings.TrimPrefix(origin, CORSOriginRegexPrefix) + ")$")
+			if err != nil {
+				policy.err = ex.Nest(policy.err, ex.New(ErrCORSOriginInvalid, ex.OptMessagef("origin: %s", origin), ex.OptInner(err)))
+				continue
+			}
+			policy.originExprs = append(policy.originExprs, expr)
+		case strings.Contains(origin, "*."):
+			index := strings.Index(origin, "*.")
+			policy.wildcardOrigins = append(policy.wildcardOrigins, [2]string{strings.ToLower(origin[:index]), strings.ToLower(origin[index+1:])})
+		default:
+			policy.exactOrigins[strings.ToLower(origin)] = true
+		}
+	}
+	if policy.anyOrigin && cfg.AllowCredentials {
+		policy.err = ex.Nest(policy.err, ex.New(ErrCORSAnyOriginWithCredentials))
+	}
+	for _, header := range cfg.AllowedHeadersOrDefault() {
+		if header == "*" {
+			policy.anyHeader = true
+			continue
+		}
+		policy.allowedHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+	return policy
+}
+
+// CORSPolicy applies a cors config to requests.
+type CORSPolicy struct {
+	Config CORSConfig
+
+	anyOrigin       bool
+	exactOrigins    map[string]bool
+	wildcardOrigins [][2]string
+	originExprs     []*regexp.Regexp
+	anyHeader       bool
+	allowedHeaders  map[string]bool
+	err             error
+}
+
+// Validate returns any errors in the policy's config.
+func (cp *CORSPolicy) Validate() error {
+	return cp.err
+}
+
+// IsPreflight returns if a request is a cors preflight request.
+func (cp *CORSPolicy) IsPreflight(req *http.Request) bool {
+	return req.Method == MethodOptions && req.Header.Get(HeaderOrigin) != "" && req.Header.Get(HeaderAccessControlRequestMethod) != ""
+}
+
+// AllowsOrigin returns if an origin is allowed.
+func (cp *CORSPolicy) AllowsOrigin(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if cp.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if cp.exactOrigins[origin] {
+		return true
+	}
+	for _, wildcard := range cp.wildcardOrigins {
+		if len(origin) > len(wildcard[0])+len(wildcard[1]) && strings.HasPrefix(origin, wildcard[0]) && strings.HasSuffix(origin, wildcard[1]) {
+			return true
+		}
+	}
+	for _, expr := range cp.originExprs {
+		if expr.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware adds cors headers to responses for allowed origins, and answers preflight requests.
+func (cp *CORSPolicy) Middleware(action Action) Action {
+	return func(ctx *Ctx) Result {
+		header := ctx.Response.Header()
+		origin := ctx.Request.Header.Get(HeaderOrigin)
+		if cp.IsPreflight(ctx.Request) {
+			header.Add(HeaderVary, HeaderOrigin)
+			header.Add(HeaderVary, HeaderAccessControlRequestMethod)
+			header.Add(HeaderVary, HeaderAccessControlRequestHeaders)
+			if cp.preflight(ctx, origin) {
+				return NoContent
+			}
+			return action(ctx)
+		}
+
+		if !cp.anyOrigin || cp.Config.AllowCredentials {
+			header.Add(HeaderVary, HeaderOrigin)
+		}
+		if cp.AllowsOrigin(origin) {
+			cp.setAllowOrigin(header, origin)
+			if len(cp.Config.ExposedHeaders) > 0 {
+				header.Set(HeaderAccessControlExposeHeaders, strings.Join(cp.Config.ExposedHeaders, ", "))
+			}
+		}
+		return action(ctx)
+	}
+}
+
+// preflight sets the preflight response headers and returns true if the request is allowed.
+func (cp *CORSPolicy) preflight(ctx *Ctx, origin string) bool {
+	if !cp.AllowsOrigin(origin) {
+		return false
+	}
+
+	allowedMethods := cp.allowedMethods(ctx)
+	requestMethod := ctx.Request.Header.Get(HeaderAccessControlRequestMethod)
+	if !containsString(allowedMethods, requestMethod) {
+		return false
+	}
+
+	var requestHeaders []string
+	for _, requestHeader := range strings.Split(ctx.Request.Header.Get(HeaderAccessControlRequestHeaders), ",") {
+		requestHeader = http.CanonicalHeaderKey(strings.TrimSpace(requestHeader))
+		if requestHeader == "" {
+			continue
+		}
+		if !cp.anyHeader && !cp.allowedHeaders[requestHeader] {
+			return false
+		}
+		requestHeaders = append(requestHeaders, requestHeader)
+	}
+
+	header := ctx.Response.Header()
+	cp.setAllowOrigin(header, origin)
+	header.Set(HeaderAccessControlAllowMethods, strings.Join(allowedMethods, ", "))
+	if len(requestHeaders) > 0 {
+		header.Set(HeaderAccessControlAllowHeaders, strings.Join(requestHeaders, ", "))
+	}
+	if cp.Config.MaxAge > 0 {
+		header.Set(HeaderAccessControlMaxAge, strconv.Itoa(int(cp.Config.MaxAge.Seconds())))
+	}
+	return true
+}
+
+// allowedMethods returns the configured methods, or the methods registered for the request path.
+func (cp *CORSPolicy) allowedMethods(ctx *Ctx) []string {
+	if len(cp.Config.AllowedMethods) > 0 {
+		return cp.Config.AllowedMethods
+	}
+	if ctx.App != nil {
+		if allow := ctx.App.allowed(ctx.Request.URL.Path, MethodOptions); allow != "" {
+			return strings.Split(allow, ", ")
+		}
+	}
+	return DefaultCORSAllowedMethods
+}
+
+func (cp *CORSPolicy) setAllowOrigin(header http.Header, origin string) {
+	if cp.anyOrigin && !cp.Config.AllowCredentials {
+		header.Set(HeaderAccessControlAllowOrigin, "*")
+		return
+	}
+	header.Set(HeaderAccessControlAllowOrigin, origin)
+	if cp.Config.AllowCredentials {
+		header.Set(HeaderAccessControlAllowCredentials, "true")
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package web
+
+import (
+	"time"
+
+	"github.com/blend/go-sdk/configutil"
+	"github.com/blend/go-sdk/env"
+)
+
+var (
+	_ configutil.ConfigResolver = (*CORSConfig)(nil)
+)
+
+// CORSConfig is the cross origin resource sharing policy for an app.
+type CORSConfig struct {
+	// AllowedOrigins are the origins allowed to make cross origin requests.
+	// Entries can be exact (`https://example.com`), wildcard subdomains (`https://*.example.com`),
+	// regular expressions prefixed with `regex:` that must match the whole origin (`regex:https://(foo|bar)\.example\.com`),
+	// or `*` for any origin.
+	AllowedOrigins []string `json:"allowedOrigins,omitempty" yaml:"allowedOrigins,omitempty"`
+	// AllowedMethods are the methods allowed in cross origin requests.
+	// If unset, the methods registered for the requested path are allowed.
+	AllowedMethods []string `json:"allowedMethods,omitempty" yaml:"allowedMethods,omitempty"`
+	// AllowedHeaders are the request headers allowed in cross origin requests; `*` allows any header.
+	AllowedHeaders []string `json:"allowedHeaders,omitempty" yaml:"allowedHeaders,omitempty"`
+	// ExposedHeaders are the response headers exposed to cross origin requests.
+	ExposedHeaders []string `json:"exposedHeaders,omitempty" yaml:"exposedHeaders,omitempty"`
+	// AllowCredentials indicates if cross origin requests can include credentials (i.e. cookies).
+	// It can't be combined with allowing any origin.
+	AllowCredentials bool `json:"allowCredentials,omitempty" yaml:"allowCredentials,omitempty" env:"CORS_ALLOW_CREDENTIALS"`
+	// MaxAge is how long preflight responses can be cached by clients.
+	MaxAge time.Duration `json:"maxAge,omitempty" yaml:"maxAge,omitempty" env:"CORS_MAX_AGE"`
+}
+
+// Resolve adds extra resolution steps when we setup the config.
+func (cc *CORSConfig) Resolve() error {
+	return env.Env().ReadInto(cc)
+}
+
+// IsZero returns if the config is unset, i.e. no origins are allowed.
+func (cc CORSConfig) IsZero() bool {
+	return len(cc.AllowedOrigins) == 0
+}
+
+// AllowedHeadersOrDefault returns the allowed headers or a default.
+func (cc CORSConfig) AllowedHeadersOrDefault() []string {
+	if len(cc.AllowedHeaders) > 0 {
+		return cc.AllowedHeaders
+	}
+	return DefaultCORSAllowedHeaders
+}
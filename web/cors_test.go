@@ -0,0 +1,161 @@
+package web
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/r2"
+)
+
+func TestCORSPolicyAllowsOrigin(t *testing.T) {
+	assert := assert.New(t)
+
+	policy := NewCORSPolicy(CORSConfig{
+		AllowedOrigins: []string{
+			"https://example.com",
+			"https://*.example.org",
+			`regex:^https://(foo|bar)\.example\.net$`,
+		},
+	})
+	assert.Nil(policy.Validate())
+
+	assert.True(policy.AllowsOrigin("https://example.com"))
+	assert.True(policy.AllowsOrigin("https://EXAMPLE.com"))
+	assert.False(policy.AllowsOrigin("http://example.com"))
+	assert.False(policy.AllowsOrigin("https://foo.example.com"))
+
+	assert.True(policy.AllowsOrigin("https://foo.example.org"))
+	assert.True(policy.AllowsOrigin("https://foo.bar.example.org"))
+	assert.False(policy.AllowsOrigin("https://example.org"))
+	assert.False(policy.AllowsOrigin("https://fooexample.org"))
+
+	assert.True(policy.AllowsOrigin("https://bar.example.net"))
+	assert.False(policy.AllowsOrigin("https://baz.example.net"))
+
+	assert.False(policy.AllowsOrigin(""))
+	assert.True(NewCORSPolicy(CORSConfig{AllowedOrigins: []string{"*"}}).AllowsOrigin("https://anything.com"))
+
+	invalid := NewCORSPolicy(CORSConfig{AllowedOrigins: []string{"regex:("}})
+	assert.True(ex.Is(invalid.Validate(), ErrCORSOriginInvalid))
+
+	anchored := NewCORSPolicy(CORSConfig{AllowedOrigins: []string{`regex:https://[a-z]+\.example\.com`}})
+	assert.Nil(anchored.Validate())
+	assert.True(anchored.AllowsOrigin("https://app.example.com"))
+	assert.False(anchored.AllowsOrigin("https://app.example.com.evil.com"))
+	assert.False(anchored.AllowsOrigin("https://evil.com/https://app.example.com"))
+
+	credentials := NewCORSPolicy(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	assert.True(ex.Is(credentials.Validate(), ErrCORSAnyOriginWithCredentials))
+	assert.NotNil(New(OptCORS(credentials.Config)).StartupTasks())
+}
+
+func TestCORS(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New(OptCORS(CORSConfig{
+		AllowedOrigins:   []string{"https://*.example.com"},
+		ExposedHeaders:   []string{"X-Total-Count"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}))
+	app.GET("/things", controllerNoOp)
+	app.POST("/things", controllerNoOp)
+
+	origin := r2.OptHeaderValue(HeaderOrigin, "https://app.example.com")
+
+	res, err := MockGet(app, "/things", origin).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal("https://app.example.com", res.Header.Get(HeaderAccessControlAllowOrigin))
+	assert.Equal("true", res.Header.Get(HeaderAccessControlAllowCredentials))
+	assert.Equal("X-Total-Count", res.Header.Get(HeaderAccessControlExposeHeaders))
+	assert.Equal(HeaderOrigin, res.Header.Get(HeaderVary))
+
+	res, err = MockGet(app, "/things", r2.OptHeaderValue(HeaderOrigin, "https://evil.com")).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Empty(res.Header.Get(HeaderAccessControlAllowOrigin))
+
+	// preflight without an OPTIONS route, with methods from the routes for the path.
+	res, err = MockMethod(app, MethodOptions, "/things",
+		origin,
+		r2.OptHeaderValue(HeaderAccessControlRequestMethod, "POST"),
+		r2.OptHeaderValue(HeaderAccessControlRequestHeaders, "content-type, x-csrf-token"),
+	).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusNoContent, res.StatusCode)
+	assert.Equal("https://app.example.com", res.Header.Get(HeaderAccessControlAllowOrigin))
+	assert.Contains(res.Header.Get(HeaderAccessControlAllowMethods), "GET")
+	assert.Contains(res.Header.Get(HeaderAccessControlAllowMethods), "POST")
+	assert.Equal("Content-Type, X-Csrf-Token", res.Header.Get(HeaderAccessControlAllowHeaders))
+	assert.Equal("3600", res.Header.Get(HeaderAccessControlMaxAge))
+
+	// methods without routes are not allowed.
+	res, err = MockMethod(app, MethodOptions, "/things", origin, r2.OptHeaderValue(HeaderAccessControlRequestMethod, "DELETE")).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusNoContent, res.StatusCode)
+	assert.Empty(res.Header.Get(HeaderAccessControlAllowOrigin))
+
+	// headers not in the allowed list are not allowed.
+	res, err = MockMethod(app, MethodOptions, "/things",
+		origin,
+		r2.OptHeaderValue(HeaderAccessControlRequestMethod, "POST"),
+		r2.OptHeaderValue(HeaderAccessControlRequestHeaders, "X-Secret"),
+	).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Empty(res.Header.Get(HeaderAccessControlAllowOrigin))
+
+	// paths without routes are still not found.
+	res, err = MockMethod(app, MethodOptions, "/other", origin, r2.OptHeaderValue(HeaderAccessControlRequestMethod, "GET")).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusNotFound, res.StatusCode)
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New(OptCORS(CORSConfig{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "PUT"},
+	}))
+	app.GET("/", controllerNoOp)
+
+	res, err := MockGet(app, "/", r2.OptHeaderValue(HeaderOrigin, "https://example.com")).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal("*", res.Header.Get(HeaderAccessControlAllowOrigin))
+	assert.Empty(res.Header.Get(HeaderAccessControlAllowCredentials))
+
+	res, err = MockMethod(app, MethodOptions, "/", r2.OptHeaderValue(HeaderOrigin, "https://example.com"), r2.OptHeaderValue(HeaderAccessControlRequestMethod, "PUT")).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusNoContent, res.StatusCode)
+	assert.Equal("*", res.Header.Get(HeaderAccessControlAllowOrigin))
+	assert.Equal("GET, PUT", res.Header.Get(HeaderAccessControlAllowMethods))
+}
+
+func TestOptConfigCORS(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New(OptConfig(Config{}))
+	assert.Nil(app.CORS)
+
+	app = New(OptConfig(Config{CORS: CORSConfig{AllowedOrigins: []string{"https://example.com"}}}))
+	assert.NotNil(app.CORS)
+	assert.Empty(app.DefaultMiddleware)
+}
+
+func TestCORSDefaultMiddlewareReplaced(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New(
+		OptCORS(CORSConfig{AllowedOrigins: []string{"https://example.com"}}),
+		OptDefaultMiddleware(func(action Action) Action { return action }),
+	)
+	app.GET("/", controllerNoOp)
+
+	res, err := MockGet(app, "/", r2.OptHeaderValue(HeaderOrigin, "https://example.com")).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal("https://example.com", res.Header.Get(HeaderAccessControlAllowOrigin))
+}
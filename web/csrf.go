@@ -0,0 +1,227 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"html/template"
+	"net/http"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// CSRF constants.
+const (
+	// HeaderXCSRFToken is the header csrf tokens are read from.
+	HeaderXCSRFToken = "X-CSRF-Token"
+	// DefaultCSRFFieldName is the default form field csrf tokens are read from.
+	DefaultCSRFFieldName = "csrf_token"
+	// StateKeyCSRFToken is the ctx state key the csrf token for a request is stored under.
+	StateKeyCSRFToken = "csrf-token"
+	// StateKeyCSRFFieldName is the ctx state key the csrf form field name is stored under.
+	StateKeyCSRFFieldName = "csrf-field-name"
+)
+
+const (
+	// ErrCSRFTokenMissing is returned if an unsafe request does not include a csrf token.
+	ErrCSRFTokenMissing ex.Class = "csrf token missing"
+)
+
+// CSRFOption is an option for csrf protection.
+type CSRFOption func(*CSRFOptions)
+
+// CSRFOptions are the options for the csrf middleware.
+type CSRFOptions struct {
+	// Secret is the key tokens are derived from session ids with.
+	// If unset a random secret is generated, which will not be shared between processes.
+	Secret []byte
+	// FieldName is the form field tokens are read from.
+	FieldName string
+	// HeaderName is the header tokens are read from.
+	HeaderName string
+	// ExemptRoutes are route paths (as registered, e.g. `/api/hooks/:id`) that are not validated.
+	ExemptRoutes map[string]bool
+	// Exempt returns if a request should not be validated.
+	Exempt func(*Ctx) bool
+}
+
+// OptCSRFSecret sets the secret tokens are derived with.
+func OptCSRFSecret(secret []byte) CSRFOption {
+	return func(opts *CSRFOptions) { opts.Secret = secret }
+}
+
+// OptCSRFFieldName sets the form field tokens are read from.
+func OptCSRFFieldName(fieldName string) CSRFOption {
+	return func(opts *CSRFOptions) { opts.FieldName = fieldName }
+}
+
+// OptCSRFHeaderName sets the header tokens are read from.
+func OptCSRFHeaderName(headerName string) CSRFOption {
+	return func(opts *CSRFOptions) { opts.HeaderName = headerName }
+}
+
+// OptCSRFExemptRoutes exempts routes by their registered path.
+func OptCSRFExemptRoutes(routePaths ...string) CSRFOption {
+	return func(opts *CSRFOptions) {
+		if opts.ExemptRoutes == nil {
+			opts.ExemptRoutes = map[string]bool{}
+		}
+		for _, routePath := range routePaths {
+			opts.ExemptRoutes[routePath] = true
+		}
+	}
+}
+
+// OptCSRFExempt sets a func that exempts requests from validation.
+func OptCSRFExempt(exempt func(*Ctx) bool) CSRFOption {
+	return func(opts *CSRFOptions) { opts.Exempt = exempt }
+}
+
+// CSRF returns a middleware that protects session authenticated requests from cross site request forgery.
+/*
+Tokens are derived from the session id, so they are valid for the lifetime of a session
+and need no storage. The session is verified with the ctx's auth manager if it has not been already;
+requests without a session are not checked.
+
+For every request with a session a token is issued and made available with `ctx.CSRFToken()`,
+and in templates with `{{ .CSRFToken }}` or `{{ .CSRFField }}` (a hidden form input).
+Each issued token is masked differently, so tokens can be rendered into compressed responses.
+
+Unsafe requests (i.e. not GET, HEAD, OPTIONS or TRACE) must include the token in the
+`X-CSRF-Token` header or the `csrf_token` url encoded form field. Requests missing a token
+fail with a bad request, and requests with a token that doesn't match the session fail
+with not authorized, both from the ctx's default result provider.
+
+Routes can be exempted by path, e.g. for webhooks authenticated by other means:
+
+	app := web.New(web.OptUse(web.CSRF(web.OptCSRFSecret(secret), web.OptCSRFExemptRoutes("/hooks/:id"))))
+*/
+func CSRF(options ...CSRFOption) Middleware {
+	opts := CSRFOptions{
+		FieldName:  DefaultCSRFFieldName,
+		HeaderName: HeaderXCSRFToken,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
+	if len(opts.Secret) == 0 {
+		opts.Secret = make([]byte, 32)
+		if _, err := rand.Read(opts.Secret); err != nil {
+			panic(err)
+		}
+	}
+
+	return func(action Action) Action {
+		return func(ctx *Ctx) Result {
+			if ctx.Session == nil {
+				session, err := ctx.Auth.VerifySession(ctx)
+				if err != nil && !IsErrSessionInvalid(err) {
+					return ctx.DefaultProvider.InternalError(err)
+				}
+				ctx.Session = session
+			}
+			if ctx.Session == nil {
+				return action(ctx)
+			}
+
+			sessionToken := csrfSessionToken(opts.Secret, ctx.Session.SessionID)
+			ctx.WithStateValue(StateKeyCSRFToken, csrfMask(sessionToken))
+			ctx.WithStateValue(StateKeyCSRFFieldName, opts.FieldName)
+
+			if csrfIsSafeMethod(ctx.Request.Method) || opts.isExempt(ctx) {
+				return action(ctx)
+			}
+
+			token := ctx.Request.Header.Get(opts.HeaderName)
+			if token == "" {
+				token, _ = ctx.FormValue(opts.FieldName)
+			}
+			if token == "" {
+				return ctx.DefaultProvider.BadRequest(ex.New(ErrCSRFTokenMissing))
+			}
+			if !csrfVerify(sessionToken, token) {
+				return ctx.DefaultProvider.NotAuthorized()
+			}
+			return action(ctx)
+		}
+	}
+}
+
+// CSRFToken returns the csrf token issued for the request by the `CSRF` middleware.
+func (rc *Ctx) CSRFToken() string {
+	if rc.State == nil {
+		return ""
+	}
+	if token, ok := rc.StateValue(StateKeyCSRFToken).(string); ok {
+		return token
+	}
+	return ""
+}
+
+// CSRFToken returns the csrf token issued for the request.
+func (vm ViewModel) CSRFToken() string {
+	if vm.Ctx == nil {
+		return ""
+	}
+	return vm.Ctx.CSRFToken()
+}
+
+// CSRFField returns a hidden form input containing the csrf token issued for the request.
+func (vm ViewModel) CSRFField() template.HTML {
+	fieldName := DefaultCSRFFieldName
+	if vm.Ctx != nil && vm.Ctx.State != nil {
+		if typed, ok := vm.Ctx.StateValue(StateKeyCSRFFieldName).(string); ok {
+			fieldName = typed
+		}
+	}
+	return template.HTML(`<input type="hidden" name="` + template.HTMLEscapeString(fieldName) + `" value="` + template.HTMLEscapeString(vm.CSRFToken()) + `">`)
+}
+
+func (opts CSRFOptions) isExempt(ctx *Ctx) bool {
+	if ctx.Route != nil && opts.ExemptRoutes[ctx.Route.Path] {
+		return true
+	}
+	return opts.Exempt != nil && opts.Exempt(ctx)
+}
+
+func csrfIsSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// csrfSessionToken derives the unmasked token for a session.
+func csrfSessionToken(secret []byte, sessionID string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(sessionID))
+	return mac.Sum(nil)
+}
+
+// csrfMask returns the token xor'd with a random pad, prefixed with the pad.
+func csrfMask(token []byte) string {
+	masked := make([]byte, 2*len(token))
+	if _, err := rand.Read(masked[:len(token)]); err != nil {
+		panic(err)
+	}
+	for index := range token {
+		masked[len(token)+index] = masked[index] ^ token[index]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// csrfVerify returns if a masked token matches the session token.
+func csrfVerify(sessionToken []byte, maskedToken string) bool {
+	masked, err := base64.RawURLEncoding.DecodeString(maskedToken)
+	if err != nil || len(masked) != 2*len(sessionToken) {
+		return false
+	}
+	unmasked := make([]byte, len(sessionToken))
+	for index := range unmasked {
+		unmasked[index] = masked[index] ^ masked[len(sessionToken)+index]
+	}
+	return subtle.ConstantTimeCompare(unmasked, sessionToken) == 1
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/r2"
+)
+
+func TestCSRF(t *testing.T) {
+	assert := assert.New(t)
+
+	sessionID := NewSessionID()
+	app := New(OptAuth(NewLocalAuthManager()), OptUse(CSRF(OptCSRFExemptRoutes("/hooks/:id"))))
+	app.Auth.PersistHandler(context.TODO(), &Session{SessionID: sessionID, UserID: "bailey"})
+
+	app.GET("/", func(ctx *Ctx) Result {
+		return Text.Result(ctx.CSRFToken())
+	})
+	app.POST("/", func(_ *Ctx) Result {
+		return Text.OK()
+	})
+	app.POST("/hooks/:id", func(_ *Ctx) Result {
+		return Text.OK()
+	})
+
+	sessionCookie := r2.OptCookieValue(app.Auth.CookieNameOrDefault(), sessionID)
+
+	// requests without a session are neither issued tokens nor checked.
+	contents, err := MockGet(app, "/").Bytes()
+	assert.Nil(err)
+	assert.Empty(contents)
+	res, err := MockMethod(app, "POST", "/").DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+
+	contents, err = MockGet(app, "/", sessionCookie).Bytes()
+	assert.Nil(err)
+	token := string(contents)
+	assert.NotEmpty(token)
+
+	// tokens are masked differently on every request.
+	contents, err = MockGet(app, "/", sessionCookie).Bytes()
+	assert.Nil(err)
+	otherToken := string(contents)
+	assert.NotEqual(token, otherToken)
+
+	res, err = MockMethod(app, "POST", "/", sessionCookie).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusBadRequest, res.StatusCode)
+
+	res, err = MockMethod(app, "POST", "/", sessionCookie, r2.OptHeaderValue(HeaderXCSRFToken, token)).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+
+	res, err = MockMethod(app, "POST", "/", sessionCookie, r2.OptPostFormValue(DefaultCSRFFieldName, otherToken)).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+
+	res, err = MockMethod(app, "POST", "/", sessionCookie, r2.OptHeaderValue(HeaderXCSRFToken, "not-a-token")).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusForbidden, res.StatusCode)
+
+	// tokens for one session are not valid for another.
+	otherSessionID := NewSessionID()
+	app.Auth.PersistHandler(context.TODO(), &Session{SessionID: otherSessionID, UserID: "bailey"})
+	res, err = MockMethod(app, "POST", "/", r2.OptCookieValue(app.Auth.CookieNameOrDefault(), otherSessionID), r2.OptHeaderValue(HeaderXCSRFToken, token)).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusForbidden, res.StatusCode)
+
+	res, err = MockMethod(app, "POST", "/hooks/foo", sessionCookie).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+}
+
+func TestCSRFVerify(t *testing.T) {
+	assert := assert.New(t)
+
+	sessionToken := csrfSessionToken([]byte("secret"), "session")
+	assert.True(csrfVerify(sessionToken, csrfMask(sessionToken)))
+	assert.False(csrfVerify(csrfSessionToken([]byte("secret"), "other session"), csrfMask(sessionToken)))
+	assert.False(csrfVerify(csrfSessionToken([]byte("other secret"), "session"), csrfMask(sessionToken)))
+	assert.False(csrfVerify(sessionToken, ""))
+}
+
+func TestViewModelCSRFField(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := MockCtx("GET", "/")
+	ctx.WithStateValue(StateKeyCSRFToken, "foo")
+	ctx.WithStateValue(StateKeyCSRFFieldName, "_csrf")
+
+	view := template.Must(template.New("test").Parse(`<form>{{ .CSRFField }}</form>`))
+	buffer := new(bytes.Buffer)
+	assert.Nil(view.Execute(buffer, ViewModel{Ctx: ctx}))
+	assert.True(strings.Contains(buffer.String(), `<input type="hidden" name="_csrf" value="foo">`), buffer.String())
+}
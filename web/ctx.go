@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"encoding/xml"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -222,6 +223,35 @@ func (rc *Ctx) PostBodyAsXML(response interface{}) error {
 	return nil
 }
 
+// MultipartReader returns a reader that streams the parts of a multipart request.
+// Unlike `FormValue` and `webutil.PostedFiles`, it does not read the whole body into memory.
+func (rc *Ctx) MultipartReader(options ...MultipartOption) (*MultipartReader, error) {
+	return NewMultipartReader(rc.Request, options...)
+}
+
+// EachMultipartPart calls a handler with each part of a multipart request in order.
+// It stops at the first error, which can be rendered with `MultipartErrorResult`.
+func (rc *Ctx) EachMultipartPart(handler func(*MultipartPart) error, options ...MultipartOption) error {
+	reader, err := rc.MultipartReader(options...)
+	if err != nil {
+		return err
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = handler(part)
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
+
 // CookieDomain returns the cookie domain for a request.
 func (rc *Ctx) CookieDomain() string {
 	if rc.App != nil && rc.App.Config.BaseURL != "" {
@@ -0,0 +1,35 @@
+package dbsession
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/uuid"
+
+	_ "github.com/lib/pq"
+)
+
+func TestMain(m *testing.M) {
+	conn, err := db.New(db.OptConfigFromEnv())
+	if err != nil {
+		logger.FatalExit(err)
+	}
+	err = db.OpenDefault(conn)
+	if err != nil {
+		logger.FatalExit(err)
+	}
+	os.Exit(m.Run())
+}
+
+// testTable returns a unique table name for a test.
+func testTable(prefix string) string {
+	return prefix + "_" + uuid.V4().ToShortString()
+}
+
+// dropTestTable drops a table created for a test.
+func dropTestTable(table string) error {
+	return db.Default().ExecContext(context.Background(), "DROP TABLE IF EXISTS "+table)
+}
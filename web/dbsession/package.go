@@ -0,0 +1,15 @@
+/*
+Package dbsession provides a postgres backed `web.SessionStore` using a `db.Connection`.
+
+Register it as the `postgres` session store provider before creating the auth manager from config:
+
+	conn, err := db.New(db.OptConfig(cfg.DB))
+	...
+	dbsession.Register(conn)
+	app := web.New(web.OptConfig(cfg.Web)) // with `sessionStore: { provider: postgres }`
+
+The sessions table must exist; `Store.EnsureSchema` creates it if it does not.
+
+JWT sessions are not stored, so to revoke them use a `RevocationList` as the auth manager revocation list.
+*/
+package dbsession
@@ -0,0 +1,107 @@
+package dbsession
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/web"
+)
+
+var (
+	_ web.SessionRevocationList = (*RevocationList)(nil)
+)
+
+// DefaultRevocationTable is the default table session revocations are stored in.
+const DefaultRevocationTable = "web_session_revocation"
+
+// RevocationListOption is an option for a revocation list.
+type RevocationListOption func(*RevocationList)
+
+// OptRevocationTable sets the table revocations are stored in.
+func OptRevocationTable(table string) RevocationListOption {
+	return func(rl *RevocationList) { rl.Table = table }
+}
+
+// NewRevocationList returns a new postgres session revocation list.
+/*
+It is shared between every replica using the same database, so jwt sessions can be revoked everywhere:
+
+	revocations, err := dbsession.NewRevocationList(conn)
+	...
+	app.Auth.RevocationList = revocations
+
+It returns an error if the table is not a valid table name.
+*/
+func NewRevocationList(conn *db.Connection, options ...RevocationListOption) (*RevocationList, error) {
+	rl := &RevocationList{
+		Conn:  conn,
+		Table: DefaultRevocationTable,
+	}
+	for _, option := range options {
+		option(rl)
+	}
+	if err := ValidateTable(rl.Table); err != nil {
+		return nil, err
+	}
+	return rl, nil
+}
+
+// RevocationList is a postgres backed session revocation list.
+// Each row revokes either a session id or every session for a user created at or before `revoked_utc`, to the second.
+// The table is interpolated into statements, so it must be validated with `ValidateTable` if it is set directly.
+type RevocationList struct {
+	Conn  *db.Connection
+	Table string
+}
+
+// Schema returns the statements that create the revocations table and its indexes.
+func (rl *RevocationList) Schema() []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	session_id TEXT,
+	user_id TEXT,
+	revoked_utc TIMESTAMP NOT NULL,
+	expires_utc TIMESTAMP
+)`, rl.Table),
+		fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS uk_%s_session_id ON %s (session_id) WHERE session_id IS NOT NULL", indexName(rl.Table), rl.Table),
+		fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS uk_%s_user_id ON %s (user_id) WHERE user_id IS NOT NULL", indexName(rl.Table), rl.Table),
+	}
+}
+
+// EnsureSchema creates the revocations table and its indexes if they don't exist.
+func (rl *RevocationList) EnsureSchema(ctx context.Context) error {
+	for _, statement := range rl.Schema() {
+		if err := rl.Conn.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RevokeSession implements web.SessionRevocationList.
+func (rl *RevocationList) RevokeSession(ctx context.Context, sessionID string, expiresUTC time.Time) error {
+	statement := fmt.Sprintf(`INSERT INTO %s (session_id, revoked_utc, expires_utc) VALUES ($1, $2, $3)
+ON CONFLICT (session_id) WHERE session_id IS NOT NULL DO UPDATE SET expires_utc = excluded.expires_utc`, rl.Table)
+	return rl.Conn.ExecContext(ctx, statement, sessionID, time.Now().UTC(), nullTime(expiresUTC))
+}
+
+// RevokeUserSessions implements web.SessionRevocationList.
+func (rl *RevocationList) RevokeUserSessions(ctx context.Context, userID string, revokedUTC, expiresUTC time.Time) error {
+	statement := fmt.Sprintf(`INSERT INTO %s (user_id, revoked_utc, expires_utc) VALUES ($1, $2, $3)
+ON CONFLICT (user_id) WHERE user_id IS NOT NULL DO UPDATE SET revoked_utc = excluded.revoked_utc, expires_utc = excluded.expires_utc`, rl.Table)
+	return rl.Conn.ExecContext(ctx, statement, userID, revokedUTC.UTC().Truncate(time.Second), nullTime(expiresUTC))
+}
+
+// IsSessionRevoked implements web.SessionRevocationList.
+func (rl *RevocationList) IsSessionRevoked(ctx context.Context, session *web.Session) (bool, error) {
+	statement := fmt.Sprintf("SELECT 1 FROM %s WHERE session_id = $1 OR (user_id = $2 AND revoked_utc >= $3) LIMIT 1", rl.Table)
+	return rl.Conn.QueryContext(ctx, statement, session.SessionID, session.UserID, session.CreatedUTC.UTC().Truncate(time.Second)).Any()
+}
+
+// SweepRevocations implements web.SessionRevocationList.
+func (rl *RevocationList) SweepRevocations(ctx context.Context) error {
+	statement := fmt.Sprintf("DELETE FROM %s WHERE expires_utc IS NOT NULL AND expires_utc < $1", rl.Table)
+	return rl.Conn.ExecContext(ctx, statement, time.Now().UTC())
+}
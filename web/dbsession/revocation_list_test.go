@@ -0,0 +1,81 @@
+package dbsession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/uuid"
+	"github.com/blend/go-sdk/web"
+)
+
+func TestRevocationList(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	revocations, err := NewRevocationList(db.Default(), OptRevocationTable(testTable("test_web_session_revocation")))
+	assert.Nil(err)
+	assert.Nil(revocations.EnsureSchema(ctx))
+	defer dropTestTable(revocations.Table)
+
+	now := time.Now().UTC()
+	session := &web.Session{SessionID: uuid.V4().String(), UserID: "example-user", CreatedUTC: now.Add(-time.Hour)}
+	revoked, err := revocations.IsSessionRevoked(ctx, session)
+	assert.Nil(err)
+	assert.False(revoked)
+
+	assert.Nil(revocations.RevokeSession(ctx, session.SessionID, now.Add(time.Hour)))
+	assert.Nil(revocations.RevokeSession(ctx, session.SessionID, now.Add(2*time.Hour)), "revoking a session again should update the revocation")
+	revoked, err = revocations.IsSessionRevoked(ctx, session)
+	assert.Nil(err)
+	assert.True(revoked)
+
+	other := &web.Session{SessionID: uuid.V4().String(), UserID: "example-user", CreatedUTC: now.Add(-time.Hour)}
+	revoked, err = revocations.IsSessionRevoked(ctx, other)
+	assert.Nil(err)
+	assert.False(revoked)
+
+	// revoking a user's sessions revokes the sessions created before the revocation.
+	assert.Nil(revocations.RevokeUserSessions(ctx, "example-user", now, now.Add(time.Hour)))
+	revoked, err = revocations.IsSessionRevoked(ctx, other)
+	assert.Nil(err)
+	assert.True(revoked)
+	later := &web.Session{SessionID: uuid.V4().String(), UserID: "example-user", CreatedUTC: now.Add(time.Minute)}
+	revoked, err = revocations.IsSessionRevoked(ctx, later)
+	assert.Nil(err)
+	assert.False(revoked)
+	sameSecond := &web.Session{SessionID: uuid.V4().String(), UserID: "example-user", CreatedUTC: now.Truncate(time.Second).Add(time.Second - time.Nanosecond)}
+	revoked, err = revocations.IsSessionRevoked(ctx, sameSecond)
+	assert.Nil(err)
+	assert.True(revoked, "revocations are compared to the second, so sessions created in the same second are revoked")
+	otherUser := &web.Session{SessionID: uuid.V4().String(), UserID: "other-user", CreatedUTC: now.Add(-time.Hour)}
+	revoked, err = revocations.IsSessionRevoked(ctx, otherUser)
+	assert.Nil(err)
+	assert.False(revoked)
+}
+
+func TestRevocationListSweepRevocations(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	revocations, err := NewRevocationList(db.Default(), OptRevocationTable(testTable("test_web_session_revocation")))
+	assert.Nil(err)
+	assert.Nil(revocations.EnsureSchema(ctx))
+	defer dropTestTable(revocations.Table)
+
+	now := time.Now().UTC()
+	expired := &web.Session{SessionID: uuid.V4().String(), UserID: "example-user", CreatedUTC: now.Add(-2 * time.Hour)}
+	active := &web.Session{SessionID: uuid.V4().String(), UserID: "example-user", CreatedUTC: now.Add(-2 * time.Hour)}
+	assert.Nil(revocations.RevokeSession(ctx, expired.SessionID, now.Add(-time.Hour)))
+	assert.Nil(revocations.RevokeSession(ctx, active.SessionID, now.Add(time.Hour)))
+
+	assert.Nil(revocations.SweepRevocations(ctx))
+	revoked, err := revocations.IsSessionRevoked(ctx, expired)
+	assert.Nil(err)
+	assert.False(revoked, "expired revocations should be swept")
+	revoked, err = revocations.IsSessionRevoked(ctx, active)
+	assert.Nil(err)
+	assert.True(revoked)
+}
@@ -0,0 +1,207 @@
+package dbsession
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/web"
+)
+
+var (
+	_ web.SessionStore = (*Store)(nil)
+)
+
+// Register registers a `postgres` session store provider that stores sessions with a given connection.
+func Register(conn *db.Connection) {
+	web.RegisterSessionStoreProvider(web.SessionStoreProviderPostgres, func(cfg web.SessionStoreConfig) (web.SessionStore, error) {
+		return New(conn, OptTable(cfg.TableOrDefault()))
+	})
+}
+
+// Option is an option for a store.
+type Option func(*Store)
+
+// OptTable sets the table sessions are stored in.
+func OptTable(table string) Option {
+	return func(s *Store) { s.Table = table }
+}
+
+// New returns a new postgres session store.
+// It returns an error if the table is not a valid table name.
+func New(conn *db.Connection, options ...Option) (*Store, error) {
+	store := &Store{
+		Conn:  conn,
+		Table: web.DefaultSessionStoreTable,
+	}
+	for _, option := range options {
+		option(store)
+	}
+	if err := ValidateTable(store.Table); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Store is a postgres backed session store.
+// The table is interpolated into statements, so it must be validated with `ValidateTable` if it is set directly.
+type Store struct {
+	Conn  *db.Connection
+	Table string
+}
+
+// Schema returns the statements that create the sessions table and its indexes.
+func (s *Store) Schema() []string {
+	return []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	session_id TEXT NOT NULL PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	base_url TEXT,
+	created_utc TIMESTAMP NOT NULL,
+	expires_utc TIMESTAMP,
+	user_agent TEXT,
+	remote_addr TEXT,
+	state JSONB
+)`, s.Table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS ix_%s_user_id ON %s (user_id)", indexName(s.Table), s.Table),
+		fmt.Sprintf("CREATE INDEX IF NOT EXISTS ix_%s_expires_utc ON %s (expires_utc)", indexName(s.Table), s.Table),
+	}
+}
+
+// EnsureSchema creates the sessions table and its indexes if they don't exist.
+func (s *Store) EnsureSchema(ctx context.Context) error {
+	for _, statement := range s.Schema() {
+		if err := s.Conn.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FetchSession implements web.SessionStore.
+func (s *Store) FetchSession(ctx context.Context, sessionID string) (session *web.Session, err error) {
+	statement := fmt.Sprintf("SELECT %s FROM %s WHERE session_id = $1", sessionColumns, s.Table)
+	err = s.Conn.QueryContext(ctx, statement, sessionID).First(func(r db.Rows) (scanErr error) {
+		session, scanErr = scanSession(r)
+		return
+	})
+	return
+}
+
+// PersistSession implements web.SessionStore.
+func (s *Store) PersistSession(ctx context.Context, session *web.Session) error {
+	state, err := json.Marshal(session.State)
+	if err != nil {
+		return ex.New(err)
+	}
+	statement := fmt.Sprintf(`INSERT INTO %s (%s) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+ON CONFLICT (session_id) DO UPDATE SET
+	user_id = excluded.user_id,
+	base_url = excluded.base_url,
+	expires_utc = excluded.expires_utc,
+	user_agent = excluded.user_agent,
+	remote_addr = excluded.remote_addr,
+	state = excluded.state`, s.Table, sessionColumns)
+	return s.Conn.ExecContext(ctx, statement,
+		session.SessionID,
+		session.UserID,
+		session.BaseURL,
+		session.CreatedUTC.UTC(),
+		nullTime(session.ExpiresUTC),
+		session.UserAgent,
+		session.RemoteAddr,
+		string(state),
+	)
+}
+
+// TouchSession implements web.SessionStore.
+func (s *Store) TouchSession(ctx context.Context, sessionID string, expiresUTC time.Time) error {
+	statement := fmt.Sprintf("UPDATE %s SET expires_utc = $2 WHERE session_id = $1", s.Table)
+	return s.Conn.ExecContext(ctx, statement, sessionID, nullTime(expiresUTC))
+}
+
+// RemoveSession implements web.SessionStore.
+func (s *Store) RemoveSession(ctx context.Context, sessionID string) error {
+	statement := fmt.Sprintf("DELETE FROM %s WHERE session_id = $1", s.Table)
+	return s.Conn.ExecContext(ctx, statement, sessionID)
+}
+
+// SessionsByUserID implements web.SessionStore.
+func (s *Store) SessionsByUserID(ctx context.Context, userID string) (output []*web.Session, err error) {
+	statement := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = $1 ORDER BY created_utc DESC", sessionColumns, s.Table)
+	err = s.Conn.QueryContext(ctx, statement, userID).Each(func(r db.Rows) error {
+		session, scanErr := scanSession(r)
+		if scanErr != nil {
+			return scanErr
+		}
+		output = append(output, session)
+		return nil
+	})
+	return
+}
+
+// RemoveSessionsByUserID implements web.SessionStore.
+func (s *Store) RemoveSessionsByUserID(ctx context.Context, userID string) error {
+	statement := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", s.Table)
+	return s.Conn.ExecContext(ctx, statement, userID)
+}
+
+// SweepSessions implements web.SessionStore.
+func (s *Store) SweepSessions(ctx context.Context) error {
+	statement := fmt.Sprintf("DELETE FROM %s WHERE expires_utc IS NOT NULL AND expires_utc < $1", s.Table)
+	return s.Conn.ExecContext(ctx, statement, time.Now().UTC())
+}
+
+const sessionColumns = "session_id, user_id, base_url, created_utc, expires_utc, user_agent, remote_addr, state"
+
+// scanSession scans a session from a row with the session columns.
+func scanSession(r db.Rows) (*web.Session, error) {
+	var session web.Session
+	var baseURL, userAgent, remoteAddr, state *string
+	var expiresUTC *time.Time
+	if err := r.Scan(
+		&session.SessionID,
+		&session.UserID,
+		&baseURL,
+		&session.CreatedUTC,
+		&expiresUTC,
+		&userAgent,
+		&remoteAddr,
+		&state,
+	); err != nil {
+		return nil, db.Error(err)
+	}
+	session.CreatedUTC = session.CreatedUTC.UTC()
+	if expiresUTC != nil {
+		session.ExpiresUTC = expiresUTC.UTC()
+	}
+	if baseURL != nil {
+		session.BaseURL = *baseURL
+	}
+	if userAgent != nil {
+		session.UserAgent = *userAgent
+	}
+	if remoteAddr != nil {
+		session.RemoteAddr = *remoteAddr
+	}
+	if state != nil && *state != "" {
+		if err := json.Unmarshal([]byte(*state), &session.State); err != nil {
+			return nil, ex.New(err)
+		}
+	}
+	if session.State == nil {
+		session.State = map[string]interface{}{}
+	}
+	return &session, nil
+}
+
+// nullTime returns nil for zero times so they're stored as null.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
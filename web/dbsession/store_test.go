@@ -0,0 +1,158 @@
+package dbsession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/db"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/uuid"
+	"github.com/blend/go-sdk/web"
+)
+
+func TestValidateTable(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(ValidateTable("web_session"))
+	assert.Nil(ValidateTable("auth.web_session"))
+	assert.True(ex.Is(ValidateTable(""), ErrInvalidTable))
+	assert.True(ex.Is(ValidateTable("1session"), ErrInvalidTable))
+	assert.True(ex.Is(ValidateTable("web_session; DROP TABLE users"), ErrInvalidTable))
+	assert.True(ex.Is(ValidateTable(`"web_session"`), ErrInvalidTable))
+
+	_, err := New(db.Default(), OptTable("sessions--"))
+	assert.True(ex.Is(err, ErrInvalidTable))
+	_, err = NewRevocationList(db.Default(), OptRevocationTable("revocations--"))
+	assert.True(ex.Is(err, ErrInvalidTable))
+}
+
+func TestStore(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store, err := New(db.Default(), OptTable(testTable("test_web_session")))
+	assert.Nil(err)
+	assert.Nil(store.EnsureSchema(ctx))
+	defer dropTestTable(store.Table)
+	assert.Nil(store.EnsureSchema(ctx), "ensuring the schema should be idempotent")
+
+	now := time.Now().UTC().Truncate(time.Second)
+	session := &web.Session{
+		SessionID:  uuid.V4().String(),
+		UserID:     "example-user",
+		BaseURL:    "https://example.com",
+		CreatedUTC: now,
+		ExpiresUTC: now.Add(time.Hour),
+		UserAgent:  "go-sdk",
+		RemoteAddr: "127.0.0.1",
+		State:      map[string]interface{}{"foo": "bar"},
+	}
+	assert.Nil(store.PersistSession(ctx, session))
+
+	fetched, err := store.FetchSession(ctx, session.SessionID)
+	assert.Nil(err)
+	assert.NotNil(fetched)
+	assert.Equal(session.UserID, fetched.UserID)
+	assert.Equal(session.BaseURL, fetched.BaseURL)
+	assert.True(session.CreatedUTC.Equal(fetched.CreatedUTC))
+	assert.True(session.ExpiresUTC.Equal(fetched.ExpiresUTC))
+	assert.Equal(session.UserAgent, fetched.UserAgent)
+	assert.Equal(session.RemoteAddr, fetched.RemoteAddr)
+	assert.Equal("bar", fetched.State["foo"])
+
+	// persisting again updates the session.
+	session.State["foo"] = "buzz"
+	assert.Nil(store.PersistSession(ctx, session))
+	fetched, err = store.FetchSession(ctx, session.SessionID)
+	assert.Nil(err)
+	assert.Equal("buzz", fetched.State["foo"])
+
+	touched := now.Add(2 * time.Hour)
+	assert.Nil(store.TouchSession(ctx, session.SessionID, touched))
+	fetched, err = store.FetchSession(ctx, session.SessionID)
+	assert.Nil(err)
+	assert.True(touched.Equal(fetched.ExpiresUTC))
+
+	missing, err := store.FetchSession(ctx, uuid.V4().String())
+	assert.Nil(err)
+	assert.Nil(missing)
+
+	assert.Nil(store.RemoveSession(ctx, session.SessionID))
+	fetched, err = store.FetchSession(ctx, session.SessionID)
+	assert.Nil(err)
+	assert.Nil(fetched)
+}
+
+func TestStoreSessionsByUserID(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store, err := New(db.Default(), OptTable(testTable("test_web_session")))
+	assert.Nil(err)
+	assert.Nil(store.EnsureSchema(ctx))
+	defer dropTestTable(store.Table)
+
+	now := time.Now().UTC()
+	for index := 0; index < 3; index++ {
+		assert.Nil(store.PersistSession(ctx, &web.Session{
+			SessionID:  uuid.V4().String(),
+			UserID:     "example-user",
+			CreatedUTC: now.Add(time.Duration(index) * time.Minute),
+		}))
+	}
+	assert.Nil(store.PersistSession(ctx, &web.Session{SessionID: uuid.V4().String(), UserID: "other-user", CreatedUTC: now}))
+
+	sessions, err := store.SessionsByUserID(ctx, "example-user")
+	assert.Nil(err)
+	assert.Len(sessions, 3)
+	assert.True(sessions[0].CreatedUTC.After(sessions[2].CreatedUTC), "sessions should be newest first")
+	assert.NotNil(sessions[0].State)
+
+	assert.Nil(store.RemoveSessionsByUserID(ctx, "example-user"))
+	sessions, err = store.SessionsByUserID(ctx, "example-user")
+	assert.Nil(err)
+	assert.Empty(sessions)
+	sessions, err = store.SessionsByUserID(ctx, "other-user")
+	assert.Nil(err)
+	assert.Len(sessions, 1)
+}
+
+func TestStoreSweepSessions(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	store, err := New(db.Default(), OptTable(testTable("test_web_session")))
+	assert.Nil(err)
+	assert.Nil(store.EnsureSchema(ctx))
+	defer dropTestTable(store.Table)
+
+	now := time.Now().UTC()
+	expired := &web.Session{SessionID: uuid.V4().String(), UserID: "example-user", CreatedUTC: now.Add(-2 * time.Hour), ExpiresUTC: now.Add(-time.Hour)}
+	active := &web.Session{SessionID: uuid.V4().String(), UserID: "example-user", CreatedUTC: now, ExpiresUTC: now.Add(time.Hour)}
+	forever := &web.Session{SessionID: uuid.V4().String(), UserID: "example-user", CreatedUTC: now}
+	for _, session := range []*web.Session{expired, active, forever} {
+		assert.Nil(store.PersistSession(ctx, session))
+	}
+
+	assert.Nil(store.SweepSessions(ctx))
+	sessions, err := store.SessionsByUserID(ctx, "example-user")
+	assert.Nil(err)
+	assert.Len(sessions, 2)
+	for _, session := range sessions {
+		assert.NotEqual(expired.SessionID, session.SessionID)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	assert := assert.New(t)
+
+	Register(db.Default())
+	store, err := web.NewSessionStore(web.SessionStoreConfig{Provider: web.SessionStoreProviderPostgres})
+	assert.Nil(err)
+	assert.Equal(web.DefaultSessionStoreTable, store.(*Store).Table)
+
+	_, err = web.NewSessionStore(web.SessionStoreConfig{Provider: web.SessionStoreProviderPostgres, Table: "sessions--"})
+	assert.True(ex.Is(err, ErrInvalidTable))
+}
@@ -0,0 +1,28 @@
+package dbsession
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// ErrInvalidTable is returned if a table name is not a valid unquoted identifier.
+const ErrInvalidTable ex.Class = "dbsession; invalid table name"
+
+// tableRegexp matches an unquoted, optionally schema qualified, table name.
+var tableRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// ValidateTable returns an error if a table name is not an unquoted, optionally schema qualified, identifier.
+// Table names are interpolated into statements, so they're restricted to letters, digits and underscores.
+func ValidateTable(table string) error {
+	if !tableRegexp.MatchString(table) {
+		return ex.New(ErrInvalidTable, ex.OptMessagef("table: %q", table))
+	}
+	return nil
+}
+
+// indexName returns the table name as used in index names, which can't be schema qualified.
+func indexName(table string) string {
+	return strings.Replace(table, ".", "_", -1)
+}
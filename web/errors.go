@@ -10,10 +10,22 @@ const (
 	ErrSessionIDEmpty ex.Class = "auth session id is empty"
 	// ErrSecureSessionIDEmpty is an error that is thrown if a given secure session id is invalid.
 	ErrSecureSessionIDEmpty ex.Class = "auth secure session id is empty"
+	// ErrSessionStoreUnset is an error returned if an auth manager operation requires a session store.
+	ErrSessionStoreUnset ex.Class = "auth session store is unset"
+	// ErrSessionRevocationUnsupported is an error returned if an auth manager has neither a session store nor a revocation list.
+	ErrSessionRevocationUnsupported ex.Class = "auth sessions can't be revoked without a session store or revocation list"
 	// ErrUnsetViewTemplate is an error that is thrown if a given secure session id is invalid.
 	ErrUnsetViewTemplate ex.Class = "view result template is unset"
+	// ErrViewLayoutNotFound is an error returned if a view uses a layout that does not exist.
+	ErrViewLayoutNotFound ex.Class = "view layout not found"
 	// ErrParameterMissing is an error on request validation.
 	ErrParameterMissing ex.Class = "parameter is missing"
+	// ErrFieldValidation is an error on request binding or validation.
+	ErrFieldValidation ex.Class = "request field validation failed"
+	// ErrHijackUnsupported is an error returned if the underlying response writer cannot be hijacked.
+	ErrHijackUnsupported ex.Class = "response writer does not support hijacking"
+	// ErrBindTarget is an error returned if a bind target is not a pointer to a struct.
+	ErrBindTarget ex.Class = "bind target must be a pointer to a struct"
 )
 
 // NewParameterMissingError returns a new parameter missing error.
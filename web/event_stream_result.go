@@ -0,0 +1,256 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+const (
+	// ContentTypeEventStream is the content type for server sent event streams.
+	ContentTypeEventStream = "text/event-stream"
+	// HeaderLastEventID is the header browsers send when reconnecting to an event stream.
+	HeaderLastEventID = "Last-Event-ID"
+	// HeaderXAccelBuffering is a header that disables response buffering in nginx based proxies.
+	HeaderXAccelBuffering = "X-Accel-Buffering"
+
+	// DefaultEventStreamHeartbeatInterval is the default interval between heartbeat comments.
+	DefaultEventStreamHeartbeatInterval = 15 * time.Second
+
+	// ErrEventStreamClosed is returned when writing to an event stream after the client has disconnected.
+	ErrEventStreamClosed ex.Class = "event stream closed"
+)
+
+// EventStreamHandler is a function that writes events to a stream.
+// It should return when it has no more events to send, or when `stream.Done()` is closed.
+type EventStreamHandler func(*Ctx, *EventStream) error
+
+// EventStreamFor returns a new event stream result for a given handler.
+func EventStreamFor(handler EventStreamHandler) *EventStreamResult {
+	return &EventStreamResult{
+		Handler:           handler,
+		HeartbeatInterval: DefaultEventStreamHeartbeatInterval,
+	}
+}
+
+// EventStreamResult is a result that streams server sent events to the client.
+/*
+The handler is called with an `EventStream` that events can be sent to:
+
+	app.GET("/jobs/:id/progress", func(ctx *web.Ctx) web.Result {
+		return web.EventStreamFor(func(ctx *web.Ctx, stream *web.EventStream) error {
+			for {
+				select {
+				case <-stream.Done():
+					return nil
+				case progress := <-updates:
+					if err := stream.SendJSON("progress", progress); err != nil {
+						return err
+					}
+				}
+			}
+		})
+	})
+
+Heartbeat comments are sent on an interval to keep the connection open through proxies.
+The id of the last event the client received (if it is reconnecting) is available as `stream.LastEventID`.
+
+Note the app `WriteTimeout` applies to the whole stream, and should be disabled for apps that serve event streams.
+*/
+type EventStreamResult struct {
+	// Handler writes the events to the stream.
+	Handler EventStreamHandler
+	// HeartbeatInterval is the interval between heartbeat comments; if unset heartbeats are disabled.
+	HeartbeatInterval time.Duration
+	// Retry, if set, tells the client how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+// Render implements Result.
+func (esr *EventStreamResult) Render(ctx *Ctx) error {
+	if esr.Handler == nil {
+		return ex.New("event stream handler is unset")
+	}
+
+	header := ctx.Response.Header()
+	header.Set(HeaderContentType, ContentTypeEventStream)
+	header.Set(HeaderCacheControl, "no-cache")
+	header.Set(HeaderConnection, ConnectionKeepAlive)
+	header.Set(HeaderXAccelBuffering, "no")
+	header.Del(HeaderContentLength)
+	ctx.Response.WriteHeader(http.StatusOK)
+
+	streamCtx, cancel := context.WithCancel(ctx.Context())
+	defer cancel()
+
+	stream := &EventStream{
+		LastEventID: ctx.Request.Header.Get(HeaderLastEventID),
+		ctx:         streamCtx,
+		response:    ctx.Response,
+	}
+	if esr.Retry > 0 {
+		if err := stream.send(ServerSentEvent{Retry: esr.Retry}); err != nil {
+			return err
+		}
+	} else if err := stream.flush(); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	if esr.HeartbeatInterval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stream.heartbeat(esr.HeartbeatInterval)
+		}()
+	}
+	err := esr.Handler(ctx, stream)
+	cancel()
+	wg.Wait()
+
+	if ex.Is(err, ErrEventStreamClosed) {
+		return nil
+	}
+	return err
+}
+
+// ServerSentEvent is a single server sent event.
+type ServerSentEvent struct {
+	// ID is the event id; the client will send the last id it received when it reconnects.
+	ID string
+	// Event is the event type; if unset clients treat the event as a "message".
+	Event string
+	// Data is the event payload. Multiline data is split across multiple data fields.
+	Data string
+	// Retry tells the client how long to wait before reconnecting.
+	Retry time.Duration
+}
+
+// WriteTo writes the event in the wire format.
+func (sse ServerSentEvent) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	if sse.ID != "" {
+		sb.WriteString("id: " + stripNewlines(sse.ID) + "\n")
+	}
+	if sse.Event != "" {
+		sb.WriteString("event: " + stripNewlines(sse.Event) + "\n")
+	}
+	if sse.Retry > 0 {
+		sb.WriteString("retry: " + strconv.FormatInt(int64(sse.Retry/time.Millisecond), 10) + "\n")
+	}
+	if sse.Data != "" || (sse.ID == "" && sse.Event == "" && sse.Retry == 0) {
+		for _, line := range strings.Split(strings.Replace(sse.Data, "\r\n", "\n", -1), "\n") {
+			sb.WriteString("data: " + line + "\n")
+		}
+	}
+	sb.WriteString("\n")
+	written, err := io.WriteString(w, sb.String())
+	return int64(written), err
+}
+
+// EventStream is an open server sent event stream.
+// It is safe to send events from multiple goroutines.
+type EventStream struct {
+	sync.Mutex
+	// LastEventID is the id of the last event the client received, if it is reconnecting.
+	LastEventID string
+
+	ctx      context.Context
+	response ResponseWriter
+}
+
+// Done returns a channel that is closed when the client disconnects or the request is cancelled.
+func (es *EventStream) Done() <-chan struct{} {
+	return es.ctx.Done()
+}
+
+// Send sends an event to the client.
+func (es *EventStream) Send(event ServerSentEvent) error {
+	return es.send(event)
+}
+
+// SendData sends a data only event to the client.
+func (es *EventStream) SendData(data string) error {
+	return es.send(ServerSentEvent{Data: data})
+}
+
+// SendJSON sends an event with a given type and a json serialized payload.
+func (es *EventStream) SendJSON(event string, obj interface{}) error {
+	contents, err := json.Marshal(obj)
+	if err != nil {
+		return ex.New(err)
+	}
+	return es.send(ServerSentEvent{Event: event, Data: string(contents)})
+}
+
+// Comment sends a comment, which clients ignore.
+func (es *EventStream) Comment(text string) error {
+	es.Lock()
+	defer es.Unlock()
+	if err := es.checkClosed(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(es.response, ": "+stripNewlines(text)+"\n\n"); err != nil {
+		return ex.New(ErrEventStreamClosed, ex.OptInner(err))
+	}
+	es.response.Flush()
+	return nil
+}
+
+func (es *EventStream) send(event ServerSentEvent) error {
+	es.Lock()
+	defer es.Unlock()
+	if err := es.checkClosed(); err != nil {
+		return err
+	}
+	if _, err := event.WriteTo(es.response); err != nil {
+		return ex.New(ErrEventStreamClosed, ex.OptInner(err))
+	}
+	es.response.Flush()
+	return nil
+}
+
+func (es *EventStream) flush() error {
+	es.Lock()
+	defer es.Unlock()
+	if err := es.checkClosed(); err != nil {
+		return err
+	}
+	es.response.Flush()
+	return nil
+}
+
+func (es *EventStream) checkClosed() error {
+	select {
+	case <-es.ctx.Done():
+		return ex.New(ErrEventStreamClosed)
+	default:
+		return nil
+	}
+}
+
+func (es *EventStream) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-es.ctx.Done():
+			return
+		case <-ticker.C:
+			if err := es.Comment("heartbeat"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func stripNewlines(value string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(value)
+}
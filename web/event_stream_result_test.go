@@ -0,0 +1,134 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/r2"
+)
+
+func TestServerSentEventWriteTo(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := new(bytes.Buffer)
+	_, err := ServerSentEvent{ID: "1", Event: "update", Data: "foo\nbar", Retry: 2 * time.Second}.WriteTo(buffer)
+	assert.Nil(err)
+	assert.Equal("id: 1\nevent: update\nretry: 2000\ndata: foo\ndata: bar\n\n", buffer.String())
+
+	buffer.Reset()
+	_, err = ServerSentEvent{Data: "foo", Event: "bad\nname"}.WriteTo(buffer)
+	assert.Nil(err)
+	assert.Equal("event: badname\ndata: foo\n\n", buffer.String())
+}
+
+func TestEventStreamResult(t *testing.T) {
+	assert := assert.New(t)
+
+	var lastEventID string
+	app := New()
+	app.GET("/events", func(_ *Ctx) Result {
+		return EventStreamFor(func(_ *Ctx, stream *EventStream) error {
+			lastEventID = stream.LastEventID
+			if err := stream.Send(ServerSentEvent{ID: "2", Data: "hello"}); err != nil {
+				return err
+			}
+			return stream.SendJSON("update", map[string]int{"progress": 50})
+		})
+	})
+
+	contents, res, err := MockGet(app, "/events",
+		r2.OptHeaderValue(HeaderLastEventID, "1"),
+		r2.OptHeaderValue(HeaderAcceptEncoding, ContentEncodingIdentity),
+	).BytesWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(ContentTypeEventStream, res.Header.Get(HeaderContentType))
+	assert.Equal("1", lastEventID)
+	assert.Equal("id: 2\ndata: hello\n\nevent: update\ndata: {\"progress\":50}\n\n", string(contents))
+}
+
+func TestEventStreamResultFlushesCompressed(t *testing.T) {
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+	defer close(release)
+	app := New()
+	app.GET("/events", func(_ *Ctx) Result {
+		return &EventStreamResult{
+			HeartbeatInterval: time.Millisecond,
+			Handler: func(_ *Ctx, stream *EventStream) error {
+				if err := stream.SendData("first"); err != nil {
+					return err
+				}
+				select {
+				case <-release:
+				case <-stream.Done():
+				}
+				return nil
+			},
+		}
+	})
+
+	mock := MockGet(app, "/events", r2.OptHeaderValue(HeaderAcceptEncoding, ContentEncodingGZIP))
+	defer mock.Close()
+	mock.Client = &http.Client{Transport: &http.Transport{DisableCompression: true}}
+	res, err := mock.Do()
+	assert.Nil(err)
+	defer res.Body.Close()
+	assert.Equal(ContentEncodingGZIP, res.Header.Get(HeaderContentEncoding))
+
+	// the first event and a heartbeat must arrive before the handler returns.
+	gz, err := gzip.NewReader(res.Body)
+	assert.Nil(err)
+	reader := bufio.NewReader(gz)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		assert.Nil(err)
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimSpace(line))
+		}
+	}
+	assert.Equal("data: first", lines[0])
+	assert.Equal(": heartbeat", lines[1])
+}
+
+func TestEventStreamResultClientDisconnect(t *testing.T) {
+	assert := assert.New(t)
+
+	finished := make(chan error, 1)
+	app := New()
+	app.GET("/events", func(_ *Ctx) Result {
+		return &EventStreamResult{
+			Handler: func(_ *Ctx, stream *EventStream) error {
+				stream.SendData("first")
+				<-stream.Done()
+				err := stream.SendData("second")
+				finished <- err
+				return err
+			},
+		}
+	})
+
+	mock := MockGet(app, "/events")
+	defer mock.Close()
+	res, err := mock.Do()
+	assert.Nil(err)
+	reader := bufio.NewReader(res.Body)
+	_, err = reader.ReadString('\n')
+	assert.Nil(err)
+	res.Body.Close()
+
+	select {
+	case err := <-finished:
+		assert.NotNil(err)
+	case <-time.After(5 * time.Second):
+		assert.FailNow("the handler should observe the client disconnect")
+	}
+}
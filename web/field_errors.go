@@ -0,0 +1,89 @@
+package web
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// Field error sources.
+const (
+	FieldSourceBody   = "body"
+	FieldSourceParam  = "param"
+	FieldSourceQuery  = "query"
+	FieldSourceForm   = "form"
+	FieldSourceHeader = "header"
+	// FieldSourcePath is the OpenAPI parameter location for route parameters.
+	FieldSourcePath = "path"
+)
+
+// FieldError is a binding or validation error for a single request field.
+type FieldError struct {
+	Field   string `json:"field" xml:"field,attr"`
+	Source  string `json:"source,omitempty" xml:"source,attr,omitempty"`
+	Rule    string `json:"rule,omitempty" xml:"rule,attr,omitempty"`
+	Message string `json:"message" xml:",chardata"`
+}
+
+// Error implements error.
+func (fe FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", fe.Field, fe.Message)
+}
+
+// FieldErrors is a collection of field errors.
+// It is returned by `Ctx.Bind` if there are problems binding or validating a request.
+type FieldErrors []FieldError
+
+// Error implements error.
+func (fe FieldErrors) Error() string {
+	messages := make([]string, len(fe))
+	for index, fieldErr := range fe {
+		messages[index] = fieldErr.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// FieldErrorsResponse is the response body returned by result providers for field errors.
+type FieldErrorsResponse struct {
+	XMLName xml.Name    `json:"-" xml:"errors"`
+	Message string      `json:"message" xml:"message"`
+	Fields  FieldErrors `json:"fields" xml:"field"`
+}
+
+// NewFieldErrorsResponse returns a new field errors response.
+func NewFieldErrorsResponse(fieldErrors FieldErrors) FieldErrorsResponse {
+	return FieldErrorsResponse{
+		Message: ErrFieldValidation.Error(),
+		Fields:  fieldErrors,
+	}
+}
+
+// AsFieldErrors returns the field errors for an error if it is a `FieldErrors` or a `FieldError`.
+func AsFieldErrors(err error) (FieldErrors, bool) {
+	switch typed := err.(type) {
+	case FieldErrors:
+		return typed, true
+	case *FieldErrors:
+		if typed == nil {
+			return nil, false
+		}
+		return *typed, true
+	case FieldError:
+		return FieldErrors{typed}, true
+	case *FieldError:
+		if typed == nil {
+			return nil, false
+		}
+		return FieldErrors{*typed}, true
+	}
+	return nil, false
+}
+
+// IsErrFieldValidation returns if an error is a field binding or validation error.
+func IsErrFieldValidation(err error) bool {
+	if err == nil {
+		return false
+	}
+	_, ok := AsFieldErrors(err)
+	return ok
+}
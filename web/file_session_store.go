@@ -0,0 +1,208 @@
+package web
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+var (
+	_ SessionStore = (*FileSessionStore)(nil)
+)
+
+// FileSessionStoreExtension is the extension of session files.
+const FileSessionStoreExtension = ".session.json"
+
+// NewFileSessionStore returns a new file session store that stores sessions in a given directory.
+// The directory is created when the first session is persisted.
+func NewFileSessionStore(path string) *FileSessionStore {
+	return &FileSessionStore{
+		Path: path,
+	}
+}
+
+// FileSessionStore stores sessions as json files in a directory.
+// It is meant to be used by single node tools that need sessions to survive restarts.
+type FileSessionStore struct {
+	sync.Mutex
+	Path string
+}
+
+// FetchSession implements SessionStore.
+func (fss *FileSessionStore) FetchSession(_ context.Context, sessionID string) (*Session, error) {
+	fss.Lock()
+	defer fss.Unlock()
+	return fss.read(fss.sessionPath(sessionID))
+}
+
+// PersistSession implements SessionStore.
+func (fss *FileSessionStore) PersistSession(_ context.Context, session *Session) error {
+	fss.Lock()
+	defer fss.Unlock()
+	return fss.write(session)
+}
+
+// TouchSession implements SessionStore.
+func (fss *FileSessionStore) TouchSession(_ context.Context, sessionID string, expiresUTC time.Time) error {
+	fss.Lock()
+	defer fss.Unlock()
+
+	session, err := fss.read(fss.sessionPath(sessionID))
+	if err != nil || session == nil {
+		return err
+	}
+	session.ExpiresUTC = expiresUTC
+	return fss.write(session)
+}
+
+// RemoveSession implements SessionStore.
+func (fss *FileSessionStore) RemoveSession(_ context.Context, sessionID string) error {
+	fss.Lock()
+	defer fss.Unlock()
+	return fss.remove(fss.sessionPath(sessionID))
+}
+
+// SessionsByUserID implements SessionStore.
+// It reads every session file, so it is only suitable for small numbers of sessions.
+func (fss *FileSessionStore) SessionsByUserID(_ context.Context, userID string) (output []*Session, err error) {
+	fss.Lock()
+	defer fss.Unlock()
+	err = fss.each(func(path string, session *Session) error {
+		if session.UserID == userID {
+			output = append(output, session)
+		}
+		return nil
+	})
+	return
+}
+
+// RemoveSessionsByUserID implements SessionStore.
+func (fss *FileSessionStore) RemoveSessionsByUserID(_ context.Context, userID string) error {
+	fss.Lock()
+	defer fss.Unlock()
+	return fss.each(func(path string, session *Session) error {
+		if session.UserID == userID {
+			return fss.remove(path)
+		}
+		return nil
+	})
+}
+
+// SweepSessions implements SessionStore.
+func (fss *FileSessionStore) SweepSessions(_ context.Context) error {
+	fss.Lock()
+	defer fss.Unlock()
+	return fss.each(func(path string, session *Session) error {
+		if session.IsExpired() {
+			return fss.remove(path)
+		}
+		return nil
+	})
+}
+
+// each calls a handler for every stored session.
+func (fss *FileSessionStore) each(handler func(string, *Session) error) error {
+	paths, err := fss.sessionPaths()
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		session, err := fss.read(path)
+		if err != nil {
+			return err
+		}
+		if session == nil {
+			continue
+		}
+		if err := handler(path, session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sessionPath returns the file path for a session id.
+// Session ids are hashed so they're safe to use as file names.
+func (fss *FileSessionStore) sessionPath(sessionID string) string {
+	hash := sha256.Sum256([]byte(sessionID))
+	return filepath.Join(fss.Path, hex.EncodeToString(hash[:])+FileSessionStoreExtension)
+}
+
+// sessionPaths returns the paths of every session file.
+func (fss *FileSessionStore) sessionPaths() ([]string, error) {
+	files, err := ioutil.ReadDir(fss.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, ex.New(err)
+	}
+	var output []string
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), FileSessionStoreExtension) {
+			output = append(output, filepath.Join(fss.Path, file.Name()))
+		}
+	}
+	return output, nil
+}
+
+func (fss *FileSessionStore) read(path string) (*Session, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, ex.New(err)
+	}
+	var session Session
+	if err := json.Unmarshal(contents, &session); err != nil {
+		return nil, ex.New(err, ex.OptMessagef("path: %s", path))
+	}
+	return &session, nil
+}
+
+// write writes a session to a temporary file and renames it so readers never see a partial session.
+func (fss *FileSessionStore) write(session *Session) error {
+	if err := os.MkdirAll(fss.Path, 0700); err != nil {
+		return ex.New(err)
+	}
+	contents, err := json.Marshal(session)
+	if err != nil {
+		return ex.New(err)
+	}
+	path := fss.sessionPath(session.SessionID)
+	tempFile, err := ioutil.TempFile(fss.Path, ".session")
+	if err != nil {
+		return ex.New(err)
+	}
+	if _, err := tempFile.Write(contents); err != nil {
+		tempFile.Close()
+		os.Remove(tempFile.Name())
+		return ex.New(err)
+	}
+	if err := tempFile.Close(); err != nil {
+		os.Remove(tempFile.Name())
+		return ex.New(err)
+	}
+	if err := os.Rename(tempFile.Name(), path); err != nil {
+		os.Remove(tempFile.Name())
+		return ex.New(err)
+	}
+	return nil
+}
+
+func (fss *FileSessionStore) remove(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return ex.New(err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package web
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestFileSessionStore(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "file_session_store")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	store := NewFileSessionStore(dir)
+
+	session, err := store.FetchSession(ctx, "missing")
+	assert.Nil(err)
+	assert.Nil(session)
+	assert.Nil(store.SweepSessions(ctx), "sweeping an empty store should not error")
+
+	active := NewSession("example-string", NewSessionID())
+	active.State["foo"] = "bar"
+	assert.Nil(store.PersistSession(ctx, active))
+	expired := NewSession("example-string", NewSessionID())
+	expired.ExpiresUTC = time.Now().UTC().Add(-time.Minute)
+	assert.Nil(store.PersistSession(ctx, expired))
+
+	// a new store reading the same directory should see the sessions.
+	store = NewFileSessionStore(dir)
+	session, err = store.FetchSession(ctx, active.SessionID)
+	assert.Nil(err)
+	assert.NotNil(session)
+	assert.Equal(active.UserID, session.UserID)
+	assert.Equal("bar", session.State["foo"])
+
+	expires := time.Now().UTC().Add(time.Hour).Round(time.Second)
+	assert.Nil(store.TouchSession(ctx, active.SessionID, expires))
+	session, err = store.FetchSession(ctx, active.SessionID)
+	assert.Nil(err)
+	assert.True(expires.Equal(session.ExpiresUTC))
+
+	sessions, err := store.SessionsByUserID(ctx, "example-string")
+	assert.Nil(err)
+	assert.Len(sessions, 2)
+
+	assert.Nil(store.SweepSessions(ctx))
+	session, err = store.FetchSession(ctx, expired.SessionID)
+	assert.Nil(err)
+	assert.Nil(session, "expired sessions should be swept")
+
+	assert.Nil(store.RemoveSession(ctx, active.SessionID))
+	assert.Nil(store.RemoveSession(ctx, active.SessionID))
+	assert.Nil(store.PersistSession(ctx, NewSession("example-string", NewSessionID())))
+	assert.Nil(store.RemoveSessionsByUserID(ctx, "example-string"))
+	files, err := ioutil.ReadDir(dir)
+	assert.Nil(err)
+	assert.Empty(files)
+}
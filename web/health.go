@@ -0,0 +1,387 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/blend/go-sdk/env"
+	"github.com/blend/go-sdk/ex"
+)
+
+// Health probe paths and statuses.
+const (
+	// DefaultHealthzPath is the default path of the aggregate health probe.
+	DefaultHealthzPath = "/healthz"
+	// DefaultReadyzPath is the default path of the readiness probe.
+	DefaultReadyzPath = "/readyz"
+	// DefaultLivezPath is the default path of the liveness probe.
+	DefaultLivezPath = "/livez"
+
+	// DefaultHealthCheckTimeout is the default timeout for a health check.
+	DefaultHealthCheckTimeout = 5 * time.Second
+	// DefaultHealthCacheTTL is the default time health check results are re-used for.
+	DefaultHealthCacheTTL = 2 * time.Second
+
+	// HealthStatusOK is the status of a passing check or probe.
+	HealthStatusOK = "ok"
+	// HealthStatusDegraded is the status of a probe where only non-critical checks are failing.
+	HealthStatusDegraded = "degraded"
+	// HealthStatusFailing is the status of a failing check or probe.
+	HealthStatusFailing = "failing"
+	// HealthStatusDraining is the status of the readiness probe while the app is shutting down.
+	HealthStatusDraining = "draining"
+)
+
+// Health errors.
+const (
+	// ErrHealthCheckTimeout is returned if a health check does not finish within its timeout.
+	ErrHealthCheckTimeout ex.Class = "health check timed out"
+	// ErrHealthCheckPanic is returned if a health check panics.
+	ErrHealthCheckPanic ex.Class = "health check panicked"
+)
+
+// HealthCheckAction is a health check; it returns an error if the component it checks is unhealthy.
+type HealthCheckAction func(context.Context) error
+
+// HealthCheckOption is an option for a health check.
+type HealthCheckOption func(*HealthCheck)
+
+// OptHealthCheckTimeout sets the timeout for a health check.
+func OptHealthCheckTimeout(timeout time.Duration) HealthCheckOption {
+	return func(hc *HealthCheck) { hc.Timeout = timeout }
+}
+
+// OptHealthCheckCritical sets if a health check failing fails the probes it is part of.
+// Checks are critical by default; failing non-critical checks only degrade the probes.
+func OptHealthCheckCritical(critical bool) HealthCheckOption {
+	return func(hc *HealthCheck) { hc.Critical = critical }
+}
+
+// OptHealthCheckLiveness sets if a health check is part of the liveness probe.
+// Liveness failures typically restart the process, so only checks a restart would fix should be included.
+func OptHealthCheckLiveness(liveness bool) HealthCheckOption {
+	return func(hc *HealthCheck) { hc.Liveness = liveness }
+}
+
+// HealthCheck is a named health check.
+type HealthCheck struct {
+	Name     string
+	Action   HealthCheckAction
+	Timeout  time.Duration
+	Critical bool
+	Liveness bool
+}
+
+// HealthOption is an option for health.
+type HealthOption func(*Health)
+
+// OptHealthTimeout sets the default timeout for health checks.
+func OptHealthTimeout(timeout time.Duration) HealthOption {
+	return func(h *Health) { h.Timeout = timeout }
+}
+
+// OptHealthCacheTTL sets how long health check results are re-used for.
+func OptHealthCacheTTL(ttl time.Duration) HealthOption {
+	return func(h *Health) { h.CacheTTL = ttl }
+}
+
+// OptHealthDrainDelay sets how long the app waits after failing readiness before it stops accepting requests.
+// The delay comes out of the app shutdown grace period.
+func OptHealthDrainDelay(delay time.Duration) HealthOption {
+	return func(h *Health) { h.DrainDelay = delay }
+}
+
+// OptHealthShowErrors sets if the probes include the errors of failing checks.
+func OptHealthShowErrors(showErrors bool) HealthOption {
+	return func(h *Health) { h.ShowErrors = showErrors }
+}
+
+// OptHealthCheck registers a health check.
+func OptHealthCheck(name string, action HealthCheckAction, options ...HealthCheckOption) HealthOption {
+	return func(h *Health) { h.Register(name, action, options...) }
+}
+
+// NewHealth returns a new health.
+// Check errors are shown by default outside of prodlike environments, as determined by `env.IsProdlike`.
+func NewHealth(options ...HealthOption) *Health {
+	h := &Health{
+		Timeout:    DefaultHealthCheckTimeout,
+		CacheTTL:   DefaultHealthCacheTTL,
+		ShowErrors: !env.IsProdlike(env.Env().ServiceEnv()),
+	}
+	for _, option := range options {
+		option(h)
+	}
+	return h
+}
+
+// Health aggregates named health checks into health, readiness and liveness probes.
+/*
+The probes are served as json by the `HealthzAction`, `ReadyzAction` and `LivezAction` actions,
+which return 200 if no critical checks are failing and 503 otherwise:
+
+	- `/healthz` runs every check.
+	- `/readyz` runs every check, and fails while the app is draining during shutdown.
+	- `/livez` runs only the checks registered with `OptHealthCheckLiveness(true)`.
+
+Check results are cached for `CacheTTL` so probes from many load balancers stay cheap. Checks run
+with their own timeout rather than the probe request's, so a probe that is cancelled doesn't fail them.
+The errors of failing checks are only included in the probes if `ShowErrors` is set, as they can leak
+details about internal systems.
+*/
+type Health struct {
+	sync.Mutex
+	Checks []HealthCheck
+	// Timeout is the default timeout for checks that don't set one.
+	Timeout time.Duration
+	// CacheTTL is how long check results are re-used for.
+	CacheTTL time.Duration
+	// DrainDelay is how long the app waits after failing readiness before it stops accepting requests.
+	// It comes out of the app shutdown grace period, and is cut short if the grace period elapses first.
+	DrainDelay time.Duration
+	// ShowErrors sets if the probes include the errors of failing checks.
+	ShowErrors bool
+
+	draining int32
+	results  map[string]HealthCheckResult
+}
+
+// HealthStatus is the result of a probe.
+type HealthStatus struct {
+	Status   string                       `json:"status"`
+	Draining bool                         `json:"draining,omitempty"`
+	Checks   map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+// IsFailing returns if the probe is failing.
+func (hs HealthStatus) IsFailing() bool {
+	return hs.Status == HealthStatusFailing || hs.Status == HealthStatusDraining
+}
+
+// HealthCheckResult is the result of a health check.
+type HealthCheckResult struct {
+	Status     string        `json:"status"`
+	Critical   bool          `json:"critical"`
+	Error      string        `json:"error,omitempty"`
+	Elapsed    time.Duration `json:"elapsed"`
+	CheckedUTC time.Time     `json:"checkedUTC"`
+}
+
+// Register registers a named health check.
+// Checks are critical and excluded from the liveness probe by default.
+func (h *Health) Register(name string, action HealthCheckAction, options ...HealthCheckOption) {
+	check := HealthCheck{
+		Name:     name,
+		Action:   action,
+		Critical: true,
+	}
+	for _, option := range options {
+		option(&check)
+	}
+
+	h.Lock()
+	defer h.Unlock()
+	h.Checks = append(h.Checks, check)
+}
+
+// SetDraining sets if the app is draining, which fails the readiness probe.
+func (h *Health) SetDraining(draining bool) {
+	if draining {
+		atomic.StoreInt32(&h.draining, 1)
+	} else {
+		atomic.StoreInt32(&h.draining, 0)
+	}
+}
+
+// IsDraining returns if the app is draining.
+func (h *Health) IsDraining() bool {
+	return atomic.LoadInt32(&h.draining) == 1
+}
+
+// Healthz runs every check.
+func (h *Health) Healthz(ctx context.Context) HealthStatus {
+	return h.check(ctx, h.checks(false))
+}
+
+// Readyz runs every check, and fails if the app is draining.
+func (h *Health) Readyz(ctx context.Context) HealthStatus {
+	if h.IsDraining() {
+		return HealthStatus{Status: HealthStatusDraining, Draining: true}
+	}
+	return h.check(ctx, h.checks(false))
+}
+
+// Livez runs the liveness checks.
+func (h *Health) Livez(ctx context.Context) HealthStatus {
+	return h.check(ctx, h.checks(true))
+}
+
+// HealthzAction serves the health probe.
+func (h *Health) HealthzAction(r *Ctx) Result {
+	return h.probeResult(h.Healthz(r.Context()))
+}
+
+// ReadyzAction serves the readiness probe.
+func (h *Health) ReadyzAction(r *Ctx) Result {
+	return h.probeResult(h.Readyz(r.Context()))
+}
+
+// LivezAction serves the liveness probe.
+func (h *Health) LivezAction(r *Ctx) Result {
+	return h.probeResult(h.Livez(r.Context()))
+}
+
+// TimeoutOrDefault returns the default check timeout or a default.
+func (h *Health) TimeoutOrDefault() time.Duration {
+	if h.Timeout > 0 {
+		return h.Timeout
+	}
+	return DefaultHealthCheckTimeout
+}
+
+// checks returns the registered checks, optionally only the liveness checks.
+func (h *Health) checks(liveness bool) (output []HealthCheck) {
+	h.Lock()
+	defer h.Unlock()
+	for _, check := range h.Checks {
+		if !liveness || check.Liveness {
+			output = append(output, check)
+		}
+	}
+	return
+}
+
+// check runs checks concurrently, re-using cached results, and aggregates the results.
+func (h *Health) check(ctx context.Context, checks []HealthCheck) HealthStatus {
+	status := HealthStatus{
+		Status: HealthStatusOK,
+		Checks: make(map[string]HealthCheckResult, len(checks)),
+	}
+
+	var resultsLock sync.Mutex
+	wg := sync.WaitGroup{}
+	wg.Add(len(checks))
+	for _, check := range checks {
+		go func(check HealthCheck) {
+			defer wg.Done()
+			result := h.result(ctx, check)
+			resultsLock.Lock()
+			status.Checks[check.Name] = result
+			resultsLock.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	for _, result := range status.Checks {
+		if result.Status == HealthStatusOK {
+			continue
+		}
+		if result.Critical {
+			status.Status = HealthStatusFailing
+		} else if status.Status == HealthStatusOK {
+			status.Status = HealthStatusDegraded
+		}
+	}
+	return status
+}
+
+// result returns the cached result for a check, running the check if the cached result has expired.
+func (h *Health) result(ctx context.Context, check HealthCheck) HealthCheckResult {
+	h.Lock()
+	cached, ok := h.results[check.Name]
+	h.Unlock()
+	if ok && time.Now().UTC().Sub(cached.CheckedUTC) < h.CacheTTL {
+		return cached
+	}
+
+	result := h.run(ctx, check)
+
+	h.Lock()
+	if h.results == nil {
+		h.results = make(map[string]HealthCheckResult)
+	}
+	h.results[check.Name] = result
+	h.Unlock()
+	return result
+}
+
+// run runs a check with its timeout.
+// The check context keeps the values of the probe context but not its cancellation, so results
+// aren't failed, and cached, because a probe request was cancelled.
+// Checks that ignore their context are abandoned once the timeout elapses.
+func (h *Health) run(ctx context.Context, check HealthCheck) HealthCheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = h.TimeoutOrDefault()
+	}
+	checkCtx, cancel := context.WithTimeout(healthCheckContext{ctx}, timeout)
+	defer cancel()
+
+	started := time.Now().UTC()
+	errors := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				errors <- ex.New(ErrHealthCheckPanic, ex.OptMessagef("%v", r))
+			}
+		}()
+		errors <- check.Action(checkCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-errors:
+	case <-checkCtx.Done():
+		err = ex.New(ErrHealthCheckTimeout, ex.OptMessagef("timeout: %v", timeout))
+	}
+
+	result := HealthCheckResult{
+		Status:     HealthStatusOK,
+		Critical:   check.Critical,
+		Elapsed:    time.Since(started),
+		CheckedUTC: started,
+	}
+	if err != nil {
+		result.Status = HealthStatusFailing
+		result.Error = healthCheckError(err)
+	}
+	return result
+}
+
+// healthCheckError returns the error string for a failing check, including the message for exceptions.
+func healthCheckError(err error) string {
+	if message := ex.ErrMessage(err); message != "" {
+		return ex.ErrClass(err) + "; " + message
+	}
+	return ex.ErrClass(err)
+}
+
+// healthCheckContext is a context with the values of a parent context that is never cancelled.
+type healthCheckContext struct {
+	context.Context
+}
+
+func (healthCheckContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (healthCheckContext) Done() <-chan struct{}       { return nil }
+func (healthCheckContext) Err() error                  { return nil }
+
+// probeResult returns the json result for a probe, without check errors unless they're shown.
+func (h *Health) probeResult(status HealthStatus) Result {
+	if !h.ShowErrors {
+		for name, result := range status.Checks {
+			result.Error = ""
+			status.Checks[name] = result
+		}
+	}
+	statusCode := http.StatusOK
+	if status.IsFailing() {
+		statusCode = http.StatusServiceUnavailable
+	}
+	return &JSONResult{
+		StatusCode: statusCode,
+		Response:   status,
+	}
+}
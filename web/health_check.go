@@ -0,0 +1,76 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/r2"
+)
+
+// Health check errors.
+const (
+	// ErrHealthCheckNotStarted is returned by started checks if a component is not started.
+	ErrHealthCheckNotStarted ex.Class = "health check component is not started"
+	// ErrHealthCheckStatus is returned by request checks if an endpoint returns a non-2xx status.
+	ErrHealthCheckStatus ex.Class = "health check request returned a non-2xx status"
+	// ErrHealthCheckCertExpiring is returned by cert expiry checks if a cert expires soon.
+	ErrHealthCheckCertExpiring ex.Class = "health check cert expires soon"
+)
+
+// HealthPinger is a component that can be pinged, e.g. a `db.Connection` or a `secrets.VaultClient`.
+type HealthPinger interface {
+	PingContext(context.Context) error
+}
+
+// HealthStarter is a component that has a started state, e.g. a `cron.JobManager`.
+type HealthStarter interface {
+	IsStarted() bool
+}
+
+// HealthCheckPing returns a health check that pings a component.
+func HealthCheckPing(pinger HealthPinger) HealthCheckAction {
+	return func(ctx context.Context) error {
+		return pinger.PingContext(ctx)
+	}
+}
+
+// HealthCheckStarted returns a health check that fails if a component is not started.
+func HealthCheckStarted(starter HealthStarter) HealthCheckAction {
+	return func(_ context.Context) error {
+		if !starter.IsStarted() {
+			return ex.New(ErrHealthCheckNotStarted)
+		}
+		return nil
+	}
+}
+
+// HealthCheckRequest returns a health check that fails if a request to an upstream endpoint
+// errors or returns a non-2xx status.
+func HealthCheckRequest(remoteURL string, options ...r2.Option) HealthCheckAction {
+	return func(ctx context.Context) error {
+		res, err := r2.New(remoteURL, append(options, r2.OptContext(ctx))...).DiscardWithResponse()
+		if err != nil {
+			return err
+		}
+		if res.StatusCode < http.StatusOK || res.StatusCode > 299 {
+			return ex.New(ErrHealthCheckStatus, ex.OptMessagef("url: %s, status: %d", remoteURL, res.StatusCode))
+		}
+		return nil
+	}
+}
+
+// HealthCheckCertExpiry returns a health check that fails if the app's current tls cert expires within a given duration.
+func HealthCheckCertExpiry(tlsFiles *TLSFiles, within time.Duration) HealthCheckAction {
+	return func(_ context.Context) error {
+		notAfter, err := tlsFiles.NotAfter()
+		if err != nil {
+			return err
+		}
+		if remaining := time.Until(notAfter); remaining < within {
+			return ex.New(ErrHealthCheckCertExpiring, ex.OptMessagef("cert: %s, not after: %v", tlsFiles.CertPath, notAfter.UTC()))
+		}
+		return nil
+	}
+}
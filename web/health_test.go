@@ -0,0 +1,229 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/async"
+	"github.com/blend/go-sdk/ex"
+)
+
+func TestHealthProbes(t *testing.T) {
+	assert := assert.New(t)
+
+	health := NewHealth(
+		OptHealthCheck("ok", func(_ context.Context) error { return nil }, OptHealthCheckLiveness(true)),
+		OptHealthCheck("optional", func(_ context.Context) error { return fmt.Errorf("optional failed") }, OptHealthCheckCritical(false)),
+		OptHealthShowErrors(true),
+	)
+	app := New(OptHealth(health))
+
+	var status HealthStatus
+	res, err := MockGet(app, DefaultHealthzPath).JSONWithResponse(&status)
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(HealthStatusDegraded, status.Status)
+	assert.Len(status.Checks, 2)
+	assert.Equal(HealthStatusFailing, status.Checks["optional"].Status)
+	assert.Equal("optional failed", status.Checks["optional"].Error)
+	assert.False(status.Checks["optional"].Critical)
+
+	status = HealthStatus{}
+	res, err = MockGet(app, DefaultLivezPath).JSONWithResponse(&status)
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal(HealthStatusOK, status.Status)
+	assert.Len(status.Checks, 1)
+
+	health.Register("critical", func(_ context.Context) error { return ex.New("critical failed") })
+
+	status = HealthStatus{}
+	res, err = MockGet(app, DefaultReadyzPath).JSONWithResponse(&status)
+	assert.Nil(err)
+	assert.Equal(http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(HealthStatusFailing, status.Status)
+
+	health.SetDraining(true)
+	status = HealthStatus{}
+	res, err = MockGet(app, DefaultReadyzPath).JSONWithResponse(&status)
+	assert.Nil(err)
+	assert.Equal(http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(HealthStatusDraining, status.Status)
+	assert.True(status.Draining)
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	assert := assert.New(t)
+
+	blocked := make(chan struct{})
+	defer close(blocked)
+	health := NewHealth(OptHealthCheck("slow", func(_ context.Context) error {
+		<-blocked
+		return nil
+	}, OptHealthCheckTimeout(time.Millisecond)))
+
+	status := health.Healthz(context.Background())
+	assert.Equal(HealthStatusFailing, status.Status)
+	assert.True(strings.HasPrefix(status.Checks["slow"].Error, string(ErrHealthCheckTimeout)))
+}
+
+func TestHealthCheckPanic(t *testing.T) {
+	assert := assert.New(t)
+
+	health := NewHealth(OptHealthCheck("panics", func(_ context.Context) error { panic("only a test") }))
+	status := health.Healthz(context.Background())
+	assert.Equal(HealthStatusFailing, status.Status)
+	assert.Equal(string(ErrHealthCheckPanic)+"; only a test", status.Checks["panics"].Error)
+}
+
+func TestHealthCacheTTL(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int32
+	health := NewHealth(OptHealthCheck("counted", func(_ context.Context) error {
+		atomic.AddInt32(&calls, 1)
+		return nil
+	}))
+	health.Healthz(context.Background())
+	health.Readyz(context.Background())
+	assert.Equal(1, atomic.LoadInt32(&calls))
+
+	health.CacheTTL = 0
+	health.Healthz(context.Background())
+	assert.Equal(2, atomic.LoadInt32(&calls))
+}
+
+func TestHealthDrainsDuringStop(t *testing.T) {
+	assert := assert.New(t)
+
+	health := NewHealth(OptHealthDrainDelay(500 * time.Millisecond))
+	app := New(OptBindAddr(DefaultMockBindAddr), OptHealth(health))
+	go app.Start()
+	<-app.NotifyStarted()
+	assert.False(health.IsDraining())
+
+	readyz := "http://" + app.Listener.Addr().String() + DefaultReadyzPath
+	res, err := http.Get(readyz)
+	assert.Nil(err)
+	res.Body.Close()
+	assert.Equal(http.StatusOK, res.StatusCode)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		app.Stop()
+	}()
+	for !health.IsDraining() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the server keeps serving during the drain delay, but fails readiness.
+	res, err = http.Get(readyz)
+	assert.Nil(err)
+	var status HealthStatus
+	assert.Nil(json.NewDecoder(res.Body).Decode(&status))
+	res.Body.Close()
+	assert.Equal(http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(HealthStatusDraining, status.Status)
+	<-stopped
+}
+
+func TestHealthProbesSkipDefaultMiddleware(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New(OptDefaultMiddleware(SessionRequired), OptHealth(NewHealth()))
+	for _, path := range []string{DefaultHealthzPath, DefaultReadyzPath, DefaultLivezPath} {
+		res, err := MockGet(app, path).DiscardWithResponse()
+		assert.Nil(err)
+		assert.Equal(http.StatusOK, res.StatusCode, path)
+	}
+}
+
+func TestHealthDrainDelayGracePeriod(t *testing.T) {
+	assert := assert.New(t)
+
+	health := NewHealth(OptHealthDrainDelay(time.Minute))
+	app := New(OptBindAddr(DefaultMockBindAddr), OptHealth(health), func(a *App) { a.Config.ShutdownGracePeriod = 10 * time.Millisecond })
+	go app.Start()
+	<-app.NotifyStarted()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		app.Stop()
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		assert.FailNow("the drain delay should be cut short by the shutdown grace period")
+	}
+}
+
+func TestHealthCheckStarted(t *testing.T) {
+	assert := assert.New(t)
+
+	latch := async.NewLatch()
+	check := HealthCheckStarted(latch)
+	assert.True(ex.Is(check(context.Background()), ErrHealthCheckNotStarted))
+	latch.Started()
+	assert.Nil(check(context.Background()))
+}
+
+func TestHealthCheckRequest(t *testing.T) {
+	assert := assert.New(t)
+
+	upstream := New(OptBindAddr(DefaultMockBindAddr))
+	upstream.GET("/ok", func(_ *Ctx) Result { return Text.OK() })
+	upstream.GET("/failing", func(_ *Ctx) Result { return Text.InternalError(fmt.Errorf("failing")) })
+	go upstream.Start()
+	<-upstream.NotifyStarted()
+	defer upstream.Stop()
+
+	remote := "http://" + upstream.Listener.Addr().String()
+	assert.Nil(HealthCheckRequest(remote + "/ok")(context.Background()))
+	assert.True(ex.Is(HealthCheckRequest(remote+"/failing")(context.Background()), ErrHealthCheckStatus))
+}
+
+func TestHealthCheckIgnoresProbeCancellation(t *testing.T) {
+	assert := assert.New(t)
+
+	type contextKey struct{}
+	health := NewHealth(OptHealthCheck("context", func(ctx context.Context) error {
+		if ctx.Value(contextKey{}) == nil {
+			return fmt.Errorf("missing probe context value")
+		}
+		return ctx.Err()
+	}))
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), contextKey{}, "value"))
+	cancel()
+	status := health.Healthz(ctx)
+	assert.Equal(HealthStatusOK, status.Status, "a cancelled probe should not fail its checks")
+	assert.Equal(HealthStatusOK, health.Healthz(context.Background()).Status)
+}
+
+func TestHealthShowErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	failing := OptHealthCheck("failing", func(_ context.Context) error { return fmt.Errorf("internal details") })
+	for _, showErrors := range []bool{false, true} {
+		app := New(OptHealth(NewHealth(failing, OptHealthShowErrors(showErrors))))
+		var status HealthStatus
+		res, err := MockGet(app, DefaultHealthzPath).JSONWithResponse(&status)
+		assert.Nil(err)
+		assert.Equal(http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(HealthStatusFailing, status.Checks["failing"].Status)
+		if showErrors {
+			assert.Equal("internal details", status.Checks["failing"].Error)
+		} else {
+			assert.Empty(status.Checks["failing"].Error)
+		}
+	}
+}
@@ -46,6 +46,12 @@ func (jrp JSONResultProvider) InternalError(err error) Result {
 
 // BadRequest returns a service response.
 func (jrp JSONResultProvider) BadRequest(err error) Result {
+	if fieldErrors, ok := AsFieldErrors(err); ok {
+		return &JSONResult{
+			StatusCode: http.StatusBadRequest,
+			Response:   NewFieldErrorsResponse(fieldErrors),
+		}
+	}
 	if err != nil {
 		return &JSONResult{
 			StatusCode: http.StatusBadRequest,
@@ -3,6 +3,11 @@ package web
 import (
 	"context"
 	"sync"
+	"time"
+)
+
+var (
+	_ SessionStore = (*LocalSessionCache)(nil)
 )
 
 // NewLocalSessionCache returns a new session cache.
@@ -37,6 +42,69 @@ func (lsc *LocalSessionCache) RemoveHandler(_ context.Context, sessionID string)
 	return nil
 }
 
+// FetchSession implements SessionStore.
+func (lsc *LocalSessionCache) FetchSession(_ context.Context, sessionID string) (*Session, error) {
+	return lsc.Get(sessionID), nil
+}
+
+// PersistSession implements SessionStore.
+func (lsc *LocalSessionCache) PersistSession(_ context.Context, session *Session) error {
+	lsc.Upsert(session)
+	return nil
+}
+
+// TouchSession implements SessionStore.
+func (lsc *LocalSessionCache) TouchSession(_ context.Context, sessionID string, expiresUTC time.Time) error {
+	lsc.SessionLock.Lock()
+	defer lsc.SessionLock.Unlock()
+	if session, ok := lsc.Sessions[sessionID]; ok {
+		session.ExpiresUTC = expiresUTC
+	}
+	return nil
+}
+
+// RemoveSession implements SessionStore.
+func (lsc *LocalSessionCache) RemoveSession(_ context.Context, sessionID string) error {
+	lsc.Remove(sessionID)
+	return nil
+}
+
+// SessionsByUserID implements SessionStore.
+func (lsc *LocalSessionCache) SessionsByUserID(_ context.Context, userID string) (output []*Session, err error) {
+	lsc.SessionLock.Lock()
+	defer lsc.SessionLock.Unlock()
+	for _, session := range lsc.Sessions {
+		if session.UserID == userID {
+			output = append(output, session)
+		}
+	}
+	return
+}
+
+// RemoveSessionsByUserID implements SessionStore.
+func (lsc *LocalSessionCache) RemoveSessionsByUserID(_ context.Context, userID string) error {
+	lsc.SessionLock.Lock()
+	defer lsc.SessionLock.Unlock()
+	for sessionID, session := range lsc.Sessions {
+		if session.UserID == userID {
+			delete(lsc.Sessions, sessionID)
+		}
+	}
+	return nil
+}
+
+// SweepSessions implements SessionStore.
+func (lsc *LocalSessionCache) SweepSessions(_ context.Context) error {
+	lsc.SessionLock.Lock()
+	defer lsc.SessionLock.Unlock()
+	for sessionID, session := range lsc.Sessions {
+		if session.IsExpired() {
+			delete(lsc.Sessions, sessionID)
+		}
+	}
+	return nil
+}
+
 // Upsert adds or updates a session to the cache.
 func (lsc *LocalSessionCache) Upsert(session *Session) {
 	lsc.SessionLock.Lock()
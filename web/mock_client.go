@@ -0,0 +1,362 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/r2"
+	"github.com/blend/go-sdk/webutil"
+)
+
+// DefaultMockClientURL is the url mock client requests are sent to if the app does not have a base url.
+const DefaultMockClientURL = "http://localhost"
+
+// ErrMockJSONPath is returned if a json path is not found in a mock response.
+const ErrMockJSONPath ex.Class = "mock response json path not found"
+
+// MockT is the subset of `testing.TB` used by the mock client to report failed expectations.
+type MockT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// NewMockClient returns a new mock client for an app.
+// The options are applied to every request the client sends.
+func NewMockClient(t MockT, app *App, options ...r2.Option) *MockClient {
+	jar, _ := cookiejar.New(nil)
+	mc := &MockClient{
+		T:        t,
+		App:      app,
+		Jar:      jar,
+		Defaults: options,
+	}
+	mc.captureEvents()
+	return mc
+}
+
+// MockClient sends requests to an app in process, without a listener.
+/*
+Cookies set by responses are kept in a cookie jar and sent with later requests, so sessions
+persist across calls, and the logger events triggered while serving each request are captured:
+
+	client := web.NewMockClient(t, app)
+	defer client.Close()
+	client.Login("example-user")
+	client.Get("/api/users/me").
+		ExpectStatus(http.StatusOK).
+		ExpectHeader(web.HeaderContentType, web.ContentTypeApplicationJSON).
+		ExpectJSONPath("user.id", "example-user").
+		ExpectEvent(logger.HTTPResponse)
+
+Events are captured from the app logger's formatter, so only events enabled on the logger are captured;
+if the app does not have a logger, the client sets one that enables every event and discards its output.
+
+A mock client is not safe for concurrent use.
+*/
+type MockClient struct {
+	T        MockT
+	App      *App
+	Jar      http.CookieJar
+	Defaults []r2.Option
+
+	startupTasks sync.Once
+	startupErr   error
+	capture      *mockEventCapture
+}
+
+// URL returns the url requests are sent to, which is the app base url if it is set.
+func (mc *MockClient) URL() *url.URL {
+	if mc.App.Config.BaseURL != "" {
+		if parsed, err := url.Parse(mc.App.Config.BaseURL); err == nil {
+			return parsed
+		}
+	}
+	return webutil.MustParseURL(DefaultMockClientURL)
+}
+
+// Login logs a user in through the app's auth manager, and adds the session cookie to the cookie jar.
+func (mc *MockClient) Login(userID string) (*Session, error) {
+	ctx := MockCtx(MethodGet, "/", OptCtxApp(mc.App))
+	u := mc.URL()
+	ctx.Request.Host = u.Host
+	ctx.Request.URL.Host = u.Host
+	session, err := mc.App.Auth.Login(userID, ctx)
+	if err != nil {
+		return nil, err
+	}
+	mc.setCookies(u, ReadSetCookies(ctx.Response.Header()))
+	return session, nil
+}
+
+// ClearCookies removes every cookie from the cookie jar, e.g. to log out.
+func (mc *MockClient) ClearCookies() {
+	mc.Jar, _ = cookiejar.New(nil)
+}
+
+// Get sends a get request.
+func (mc *MockClient) Get(path string, options ...r2.Option) *MockResponse {
+	return mc.Do(MethodGet, path, options...)
+}
+
+// Post sends a post request.
+func (mc *MockClient) Post(path string, options ...r2.Option) *MockResponse {
+	return mc.Do(MethodPost, path, options...)
+}
+
+// Put sends a put request.
+func (mc *MockClient) Put(path string, options ...r2.Option) *MockResponse {
+	return mc.Do(MethodPut, path, options...)
+}
+
+// Patch sends a patch request.
+func (mc *MockClient) Patch(path string, options ...r2.Option) *MockResponse {
+	return mc.Do(MethodPatch, path, options...)
+}
+
+// Delete sends a delete request.
+func (mc *MockClient) Delete(path string, options ...r2.Option) *MockResponse {
+	return mc.Do(MethodDelete, path, options...)
+}
+
+// Do sends a request with a given method.
+func (mc *MockClient) Do(method, path string, options ...r2.Option) *MockResponse {
+	res := &MockResponse{T: mc.T}
+	mc.startupTasks.Do(func() { mc.startupErr = mc.App.StartupTasks() })
+	if mc.startupErr != nil {
+		res.Err = mc.startupErr
+		return res
+	}
+
+	u := mc.URL()
+	u.Path = path
+	req := r2.New(u.String(), append(append([]r2.Option{r2.OptMethod(method)}, mc.Defaults...), options...)...)
+	if req.Err != nil {
+		res.Err = req.Err
+		return res
+	}
+	if req.Host == "" {
+		req.Host = req.URL.Host
+	}
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	if req.Body == nil {
+		req.Body = http.NoBody
+	}
+	for _, cookie := range mc.Jar.Cookies(req.URL) {
+		req.AddCookie(cookie)
+	}
+
+	recorder := httptest.NewRecorder()
+	mc.capture.Reset()
+	mc.App.ServeHTTP(recorder, &req.Request)
+
+	res.Response = recorder.Result()
+	res.Contents = recorder.Body.Bytes()
+	res.Events = mc.capture.Events()
+	mc.setCookies(req.URL, res.Response.Cookies())
+	return res
+}
+
+// setCookies adds cookies to the cookie jar.
+// Secure cookies are kept as insecure cookies, as requests are served in process without tls.
+func (mc *MockClient) setCookies(u *url.URL, cookies []*http.Cookie) {
+	for _, cookie := range cookies {
+		cookie.Secure = false
+	}
+	mc.Jar.SetCookies(u, cookies)
+}
+
+// Close stops capturing logger events.
+func (mc *MockClient) Close() error {
+	if typed, ok := mc.App.Log.(*logger.Logger); ok && typed.Formatter == mc.capture {
+		typed.Formatter = mc.capture.WriteFormatter
+	}
+	return nil
+}
+
+// captureEvents wraps the app logger's formatter to capture the events it writes.
+func (mc *MockClient) captureEvents() {
+	mc.capture = new(mockEventCapture)
+	if mc.App.Log == nil {
+		mc.App.Log = logger.MustNew(logger.OptAll(), logger.OptOutput(ioutil.Discard))
+	}
+	if typed, ok := mc.App.Log.(*logger.Logger); ok && typed != nil {
+		mc.capture.WriteFormatter = typed.Formatter
+		typed.Formatter = mc.capture
+	}
+}
+
+// MockResponse is a response to a mock client request.
+// The expectation methods report failures to the client's `MockT` and return the response so they can be chained.
+type MockResponse struct {
+	*http.Response
+	T MockT
+	// Err is set if the request could not be sent.
+	Err error
+	// Contents are the response body contents.
+	Contents []byte
+	// Events are the logger events triggered while serving the request.
+	Events []logger.Event
+}
+
+// JSON decodes the response contents as json.
+func (mr *MockResponse) JSON(dst interface{}) error {
+	if mr.Err != nil {
+		return mr.Err
+	}
+	return ex.New(json.Unmarshal(mr.Contents, dst))
+}
+
+// JSONPath returns the value at a path in the response contents decoded as json.
+// Path segments are separated by periods, and array elements are selected by index, e.g. `users.0.id`.
+func (mr *MockResponse) JSONPath(path string) (interface{}, error) {
+	var value interface{}
+	if err := mr.JSON(&value); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return value, nil
+	}
+	for _, segment := range strings.Split(path, ".") {
+		switch typed := value.(type) {
+		case map[string]interface{}:
+			var ok bool
+			if value, ok = typed[segment]; !ok {
+				return nil, ex.New(ErrMockJSONPath, ex.OptMessagef("path: %s, segment: %s", path, segment))
+			}
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(typed) {
+				return nil, ex.New(ErrMockJSONPath, ex.OptMessagef("path: %s, segment: %s", path, segment))
+			}
+			value = typed[index]
+		default:
+			return nil, ex.New(ErrMockJSONPath, ex.OptMessagef("path: %s, segment: %s", path, segment))
+		}
+	}
+	return value, nil
+}
+
+// ExpectStatus expects the response to have a status code.
+func (mr *MockResponse) ExpectStatus(statusCode int) *MockResponse {
+	mr.T.Helper()
+	if mr.expectSent() && mr.StatusCode != statusCode {
+		mr.T.Errorf("expected status code %d, actual %d; body: %s", statusCode, mr.StatusCode, mr.Contents)
+	}
+	return mr
+}
+
+// ExpectHeader expects the response to have a header value.
+func (mr *MockResponse) ExpectHeader(key, value string) *MockResponse {
+	mr.T.Helper()
+	if actual := mr.headerValue(key); mr.expectSent() && actual != value {
+		mr.T.Errorf("expected header %s to be %q, actual %q", key, value, actual)
+	}
+	return mr
+}
+
+// ExpectJSONPath expects the value at a path in the response json to equal a value.
+// The expected value is compared as json, so e.g. `1` equals the json number `1`.
+func (mr *MockResponse) ExpectJSONPath(path string, expected interface{}) *MockResponse {
+	mr.T.Helper()
+	if !mr.expectSent() {
+		return mr
+	}
+	actual, err := mr.JSONPath(path)
+	if err != nil {
+		mr.T.Errorf("expected json path %s; %v", path, err)
+		return mr
+	}
+	expectedJSON, err := json.Marshal(expected)
+	if err != nil {
+		mr.T.Errorf("expected json path %s; invalid expected value: %v", path, err)
+		return mr
+	}
+	var expectedValue interface{}
+	_ = json.Unmarshal(expectedJSON, &expectedValue)
+	if !reflect.DeepEqual(expectedValue, actual) {
+		mr.T.Errorf("expected json path %s to be %v, actual %v", path, expectedValue, actual)
+	}
+	return mr
+}
+
+// ExpectEvent expects a logger event with a flag to have been triggered while serving the request.
+func (mr *MockResponse) ExpectEvent(flag string) *MockResponse {
+	mr.T.Helper()
+	if mr.expectSent() && len(mr.EventsWithFlag(flag)) == 0 {
+		mr.T.Errorf("expected a %s event to be triggered", flag)
+	}
+	return mr
+}
+
+// EventsWithFlag returns the captured events with a flag.
+func (mr *MockResponse) EventsWithFlag(flag string) (output []logger.Event) {
+	for _, e := range mr.Events {
+		if e.GetFlag() == flag {
+			output = append(output, e)
+		}
+	}
+	return
+}
+
+func (mr *MockResponse) headerValue(key string) string {
+	if mr.Response == nil {
+		return ""
+	}
+	return mr.Header.Get(key)
+}
+
+// expectSent reports an error if the request could not be sent.
+func (mr *MockResponse) expectSent() bool {
+	mr.T.Helper()
+	if mr.Err != nil {
+		mr.T.Errorf("request failed: %v", mr.Err)
+		return false
+	}
+	return true
+}
+
+// mockEventCapture is a logger formatter that captures the events it writes.
+type mockEventCapture struct {
+	sync.Mutex
+	logger.WriteFormatter
+	events []logger.Event
+}
+
+// WriteFormat implements logger.WriteFormatter.
+func (mec *mockEventCapture) WriteFormat(ctx context.Context, output io.Writer, e logger.Event) error {
+	mec.Lock()
+	mec.events = append(mec.events, e)
+	mec.Unlock()
+	if mec.WriteFormatter == nil {
+		return nil
+	}
+	return mec.WriteFormatter.WriteFormat(ctx, output, e)
+}
+
+// Reset clears the captured events.
+func (mec *mockEventCapture) Reset() {
+	mec.Lock()
+	mec.events = nil
+	mec.Unlock()
+}
+
+// Events returns the captured events.
+func (mec *mockEventCapture) Events() []logger.Event {
+	mec.Lock()
+	defer mec.Unlock()
+	return append([]logger.Event(nil), mec.events...)
+}
@@ -0,0 +1,95 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/r2"
+)
+
+type mockT struct {
+	errors []string
+}
+
+func (mt *mockT) Helper() {}
+
+func (mt *mockT) Errorf(format string, args ...interface{}) {
+	mt.errors = append(mt.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMockClient(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New(OptAuth(NewLocalAuthManager()))
+	app.GET("/me", func(ctx *Ctx) Result {
+		return JSON.Result(map[string]interface{}{
+			"user":  map[string]interface{}{"id": ctx.Session.UserID},
+			"roles": []string{"admin", "user"},
+		})
+	}, SessionRequired)
+	app.POST("/visits", func(ctx *Ctx) Result {
+		ctx.WriteNewCookie(&http.Cookie{Name: "visited", Value: "true", Path: "/"})
+		return NoContent
+	})
+	app.GET("/visits", func(ctx *Ctx) Result {
+		if cookie := ctx.Cookie("visited"); cookie != nil {
+			return Text.Result(cookie.Value)
+		}
+		return Text.Result("false")
+	})
+
+	client := NewMockClient(t, app)
+	defer client.Close()
+
+	client.Get("/me").ExpectStatus(http.StatusForbidden)
+
+	session, err := client.Login("example-user")
+	assert.Nil(err)
+	assert.Equal("example-user", session.UserID)
+
+	client.Get("/me").
+		ExpectStatus(http.StatusOK).
+		ExpectHeader(HeaderContentType, ContentTypeApplicationJSON).
+		ExpectJSONPath("user.id", "example-user").
+		ExpectJSONPath("roles.1", "user").
+		ExpectEvent(logger.HTTPRequest).
+		ExpectEvent(logger.HTTPResponse)
+
+	client.Get("/visits").ExpectStatus(http.StatusOK)
+	assert.Equal("false", string(client.Get("/visits").Contents))
+	client.Post("/visits").ExpectStatus(http.StatusNoContent)
+	assert.Equal("true", string(client.Get("/visits").Contents))
+
+	client.ClearCookies()
+	assert.Equal("false", string(client.Get("/visits", r2.OptHeaderValue("X-Test", "true")).Contents))
+}
+
+func TestMockClientExpectationFailures(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New(OptLog(logger.None()))
+	app.GET("/", func(_ *Ctx) Result {
+		return JSON.Result(map[string]interface{}{"count": 1})
+	})
+
+	mt := new(mockT)
+	client := NewMockClient(mt, app)
+	defer client.Close()
+
+	res := client.Get("/").
+		ExpectStatus(http.StatusOK).
+		ExpectJSONPath("count", 1)
+	assert.Empty(mt.errors)
+	// the logger has every event disabled, so none are captured.
+	assert.Empty(res.Events)
+
+	res.ExpectStatus(http.StatusNotFound).
+		ExpectHeader(HeaderContentType, ContentTypeText).
+		ExpectJSONPath("count", 2).
+		ExpectJSONPath("missing", 1).
+		ExpectEvent(logger.HTTPResponse)
+	assert.Len(mt.errors, 5)
+}
@@ -0,0 +1,298 @@
+package web
+
+import (
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/webutil"
+)
+
+// Multipart defaults.
+const (
+	// DefaultMultipartMaxFileSize is the default maximum size of a multipart file part (64mb).
+	DefaultMultipartMaxFileSize int64 = 1 << 26
+	// DefaultMultipartMaxFieldSize is the default maximum size of a multipart form field part (1mb).
+	DefaultMultipartMaxFieldSize int64 = 1 << 20
+	// DefaultMultipartMaxParts is the default maximum number of parts in a multipart request.
+	DefaultMultipartMaxParts = 128
+	// DefaultMultipartMaxTotalSize is the default maximum size of a multipart request body (256mb).
+	DefaultMultipartMaxTotalSize int64 = 1 << 28
+)
+
+// Multipart errors.
+const (
+	// ErrMultipartPartTooLarge is returned if a multipart part exceeds its max size.
+	ErrMultipartPartTooLarge ex.Class = "multipart part exceeds the max size"
+	// ErrMultipartTooManyParts is returned if a multipart request exceeds the max part count.
+	ErrMultipartTooManyParts ex.Class = "multipart request exceeds the max part count"
+	// ErrMultipartTooLarge is returned if a multipart request body exceeds the max total size.
+	ErrMultipartTooLarge ex.Class = "multipart request exceeds the max total size"
+	// ErrMultipartContentType is returned if a multipart file's sniffed content type is not allowed.
+	ErrMultipartContentType ex.Class = "multipart file content type is not allowed"
+)
+
+// MultipartOption is an option for multipart readers.
+type MultipartOption func(*MultipartReader)
+
+// OptMultipartMaxFileSize sets the maximum size of a file part.
+func OptMultipartMaxFileSize(maxFileSize int64) MultipartOption {
+	return func(mr *MultipartReader) { mr.MaxFileSize = maxFileSize }
+}
+
+// OptMultipartMaxFieldSize sets the maximum size of a form field part.
+func OptMultipartMaxFieldSize(maxFieldSize int64) MultipartOption {
+	return func(mr *MultipartReader) { mr.MaxFieldSize = maxFieldSize }
+}
+
+// OptMultipartMaxParts sets the maximum number of parts.
+func OptMultipartMaxParts(maxParts int) MultipartOption {
+	return func(mr *MultipartReader) { mr.MaxParts = maxParts }
+}
+
+// OptMultipartMaxTotalSize sets the maximum size of the request body, across every part.
+func OptMultipartMaxTotalSize(maxTotalSize int64) MultipartOption {
+	return func(mr *MultipartReader) { mr.MaxTotalSize = maxTotalSize }
+}
+
+// OptMultipartContentTypes sets the content types allowed for file parts, e.g. `application/pdf` or `image/*`.
+// If unset, any content type is allowed.
+func OptMultipartContentTypes(contentTypes ...string) MultipartOption {
+	return func(mr *MultipartReader) { mr.ContentTypes = contentTypes }
+}
+
+// NewMultipartReader returns a new multipart reader for a request.
+// It returns `http.ErrNotMultipart` if the request is not a multipart request.
+// If there is a max total size, the request body is replaced with a reader that enforces it.
+func NewMultipartReader(r *http.Request, options ...MultipartOption) (*MultipartReader, error) {
+	mr := &MultipartReader{
+		MaxFileSize:  DefaultMultipartMaxFileSize,
+		MaxFieldSize: DefaultMultipartMaxFieldSize,
+		MaxParts:     DefaultMultipartMaxParts,
+		MaxTotalSize: DefaultMultipartMaxTotalSize,
+	}
+	for _, option := range options {
+		option(mr)
+	}
+	if mr.MaxTotalSize > 0 && r.Body != nil {
+		mr.body = &multipartBody{ReadCloser: r.Body, MaxSize: mr.MaxTotalSize}
+		r.Body = mr.body
+	}
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, ex.New(err)
+	}
+	mr.reader = reader
+	return mr, nil
+}
+
+// MultipartReader streams the parts of a multipart request without buffering them in memory,
+// enforcing size, part count and content type limits as the parts are read.
+type MultipartReader struct {
+	// MaxFileSize is the maximum size of a file part; it is unlimited if zero.
+	MaxFileSize int64
+	// MaxFieldSize is the maximum size of a form field part; it is unlimited if zero.
+	MaxFieldSize int64
+	// MaxParts is the maximum number of parts; it is unlimited if zero.
+	MaxParts int
+	// MaxTotalSize is the maximum size of the request body; it is unlimited if zero.
+	MaxTotalSize int64
+	// ContentTypes are the content types allowed for file parts; any content type is allowed if empty.
+	ContentTypes []string
+
+	reader *multipart.Reader
+	body   *multipartBody
+	parts  int
+}
+
+// NextPart returns the next part, or `io.EOF` once every part has been read.
+// Any unread contents of the previous part are discarded.
+func (mr *MultipartReader) NextPart() (*MultipartPart, error) {
+	part, err := mr.reader.NextPart()
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, ex.New(mr.body.errOr(err))
+	}
+	mr.parts++
+	if mr.MaxParts > 0 && mr.parts > mr.MaxParts {
+		part.Close()
+		return nil, ex.New(ErrMultipartTooManyParts, ex.OptMessagef("max parts: %d", mr.MaxParts))
+	}
+
+	if part.FileName() == "" {
+		return &MultipartPart{Part: part, MaxSize: mr.MaxFieldSize, reader: part, body: mr.body}, nil
+	}
+
+	contentType, reader, err := webutil.DetectContentTypeReader(part)
+	if err != nil {
+		part.Close()
+		return nil, mr.body.errOr(err)
+	}
+	if !mr.isAllowedContentType(contentType) {
+		part.Close()
+		return nil, ex.New(ErrMultipartContentType, ex.OptMessagef("part: %s, content type: %s", part.FormName(), contentType))
+	}
+	return &MultipartPart{Part: part, ContentType: contentType, MaxSize: mr.MaxFileSize, reader: reader, body: mr.body}, nil
+}
+
+// isAllowedContentType returns if a sniffed content type is allowed, matching media types only
+// so `text/plain` allows `text/plain; charset=utf-8`, and `image/*` allows any image.
+func (mr *MultipartReader) isAllowedContentType(contentType string) bool {
+	if len(mr.ContentTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range mr.ContentTypes {
+		if strings.HasSuffix(allowed, "/*") {
+			if strings.HasPrefix(mediaType, strings.TrimSuffix(allowed, "*")) {
+				return true
+			}
+			continue
+		}
+		if allowedMediaType, _, err := mime.ParseMediaType(allowed); err == nil && allowedMediaType == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// MultipartPart is a part of a multipart request.
+// Reading a part returns `ErrMultipartPartTooLarge` once its contents exceed its max size.
+type MultipartPart struct {
+	*multipart.Part
+	// ContentType is the sniffed content type of a file part; it is empty for form field parts.
+	ContentType string
+	// MaxSize is the maximum size of the part; it is unlimited if zero.
+	MaxSize int64
+
+	reader io.Reader
+	body   *multipartBody
+	read   int64
+	err    error
+}
+
+// IsFile returns if the part is a file.
+func (mp *MultipartPart) IsFile() bool {
+	return mp.FileName() != ""
+}
+
+// Read implements io.Reader.
+func (mp *MultipartPart) Read(p []byte) (n int, err error) {
+	if mp.err != nil {
+		return 0, mp.err
+	}
+	// read at most one byte past the max size to tell if the part exceeds it.
+	if mp.MaxSize > 0 && int64(len(p)) > mp.MaxSize-mp.read+1 {
+		p = p[:mp.MaxSize-mp.read+1]
+	}
+	n, err = mp.reader.Read(p)
+	if err != nil && err != io.EOF {
+		err = mp.body.errOr(err)
+	}
+	mp.read += int64(n)
+	if mp.MaxSize > 0 && mp.read > mp.MaxSize {
+		n -= int(mp.read - mp.MaxSize)
+		mp.read = mp.MaxSize
+		mp.err = ex.New(ErrMultipartPartTooLarge, ex.OptMessagef("part: %s, max size: %d", mp.FormName(), mp.MaxSize))
+		return n, mp.err
+	}
+	return
+}
+
+// Value reads a form field part as a string.
+func (mp *MultipartPart) Value() (string, error) {
+	contents, err := ioutil.ReadAll(mp)
+	if err != nil {
+		return "", ex.New(err)
+	}
+	return string(contents), nil
+}
+
+// CopyTo copies the part contents to a writer.
+func (mp *MultipartPart) CopyTo(dst io.Writer) (int64, error) {
+	written, err := io.Copy(dst, mp)
+	if err != nil {
+		return written, ex.New(err)
+	}
+	return written, nil
+}
+
+// CopyToFile copies the part contents to a new file at a given path.
+// The file is removed if the copy fails, e.g. because the part is too large.
+func (mp *MultipartPart) CopyToFile(path string) (int64, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, ex.New(err)
+	}
+	written, err := mp.CopyTo(f)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = ex.New(closeErr)
+	}
+	if err != nil {
+		os.Remove(path)
+		return written, err
+	}
+	return written, nil
+}
+
+// multipartBody is a multipart request body that returns `ErrMultipartTooLarge` once it exceeds its max size.
+type multipartBody struct {
+	io.ReadCloser
+	MaxSize int64
+
+	read int64
+	err  error
+}
+
+// Read implements io.Reader.
+func (mb *multipartBody) Read(p []byte) (n int, err error) {
+	if mb.err != nil {
+		return 0, mb.err
+	}
+	// read at most one byte past the max size to tell if the body exceeds it.
+	if int64(len(p)) > mb.MaxSize-mb.read+1 {
+		p = p[:mb.MaxSize-mb.read+1]
+	}
+	n, err = mb.ReadCloser.Read(p)
+	mb.read += int64(n)
+	if mb.read > mb.MaxSize {
+		n -= int(mb.read - mb.MaxSize)
+		mb.read = mb.MaxSize
+		mb.err = ex.New(ErrMultipartTooLarge, ex.OptMessagef("max total size: %d", mb.MaxSize))
+		return n, mb.err
+	}
+	return
+}
+
+// errOr returns the error the body exceeded its max size with, if it did, as the multipart reader
+// doesn't always return read errors as is.
+func (mb *multipartBody) errOr(err error) error {
+	if mb != nil && mb.err != nil {
+		return mb.err
+	}
+	return err
+}
+
+// MultipartErrorResult returns the result for a multipart error from a result provider.
+// Size and part count limit errors return 413, content type errors return 415,
+// and other errors, e.g. malformed requests, return 400.
+func MultipartErrorResult(provider ResultProvider, err error) Result {
+	switch {
+	case ex.Is(err, ErrMultipartPartTooLarge), ex.Is(err, ErrMultipartTooManyParts), ex.Is(err, ErrMultipartTooLarge):
+		return provider.Status(http.StatusRequestEntityTooLarge, err.Error())
+	case ex.Is(err, ErrMultipartContentType):
+		return provider.Status(http.StatusUnsupportedMediaType, err.Error())
+	default:
+		return provider.BadRequest(err)
+	}
+}
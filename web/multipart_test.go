@@ -0,0 +1,140 @@
+package web
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/r2"
+)
+
+func mockMultipartBody(t *testing.T, fields map[string]string, files map[string][]byte) (*bytes.Buffer, string) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, contents := range files {
+		part, err := writer.CreateFormFile(name, name+".dat")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = part.Write(contents); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return body, writer.FormDataContentType()
+}
+
+func TestCtxEachMultipartPart(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "web-multipart")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 64)...)
+
+	app := New()
+	app.POST("/upload", func(ctx *Ctx) Result {
+		var names []string
+		err := ctx.EachMultipartPart(func(part *MultipartPart) error {
+			if !part.IsFile() {
+				value, err := part.Value()
+				names = append(names, part.FormName()+"="+value)
+				return err
+			}
+			names = append(names, part.FormName()+":"+part.ContentType)
+			_, err := part.CopyToFile(filepath.Join(tempDir, part.FormName()))
+			return err
+		},
+			OptMultipartMaxFileSize(128),
+			OptMultipartMaxParts(2),
+			OptMultipartContentTypes("image/*"),
+		)
+		if err != nil {
+			return MultipartErrorResult(Text, err)
+		}
+		return Text.Result(strings.Join(names, ","))
+	})
+
+	upload := func(fields map[string]string, files map[string][]byte) (string, int) {
+		body, contentType := mockMultipartBody(t, fields, files)
+		contents, res, err := MockPost(app, "/upload", ioutil.NopCloser(body), r2.OptHeaderValue(HeaderContentType, contentType)).BytesWithResponse()
+		assert.Nil(err)
+		return string(contents), res.StatusCode
+	}
+
+	contents, statusCode := upload(map[string]string{"name": "test"}, map[string][]byte{"image": png})
+	assert.Equal(http.StatusOK, statusCode, contents)
+	assert.Equal("name=test,image:image/png", contents)
+	written, err := ioutil.ReadFile(filepath.Join(tempDir, "image"))
+	assert.Nil(err)
+	assert.Equal(png, written)
+
+	_, statusCode = upload(nil, map[string][]byte{"large": append(png, bytes.Repeat([]byte{0}, 128)...)})
+	assert.Equal(http.StatusRequestEntityTooLarge, statusCode)
+	_, err = os.Stat(filepath.Join(tempDir, "large"))
+	assert.True(os.IsNotExist(err))
+
+	_, statusCode = upload(map[string]string{"one": "1", "two": "2", "three": "3"}, nil)
+	assert.Equal(http.StatusRequestEntityTooLarge, statusCode)
+
+	_, statusCode = upload(nil, map[string][]byte{"text": []byte("this is only a test")})
+	assert.Equal(http.StatusUnsupportedMediaType, statusCode)
+
+	res, err := MockPost(app, "/upload", ioutil.NopCloser(strings.NewReader("not multipart"))).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusBadRequest, res.StatusCode)
+}
+
+func TestCtxEachMultipartPartMaxTotalSize(t *testing.T) {
+	assert := assert.New(t)
+
+	var read int
+	app := New()
+	app.POST("/upload", func(ctx *Ctx) Result {
+		err := ctx.EachMultipartPart(func(part *MultipartPart) error {
+			_, err := part.CopyTo(ioutil.Discard)
+			read++
+			return err
+		},
+			OptMultipartMaxFileSize(1024),
+			OptMultipartMaxTotalSize(1024),
+		)
+		if err != nil {
+			return MultipartErrorResult(Text, err)
+		}
+		return NoContent
+	})
+
+	upload := func(files map[string][]byte) int {
+		body, contentType := mockMultipartBody(t, nil, files)
+		res, err := MockPost(app, "/upload", ioutil.NopCloser(body), r2.OptHeaderValue(HeaderContentType, contentType)).DiscardWithResponse()
+		assert.Nil(err)
+		return res.StatusCode
+	}
+
+	assert.Equal(http.StatusNoContent, upload(map[string][]byte{"one": bytes.Repeat([]byte{'a'}, 256)}))
+
+	// each part is within the max file size, but together they exceed the max total size.
+	read = 0
+	statusCode := upload(map[string][]byte{
+		"one":   bytes.Repeat([]byte{'a'}, 512),
+		"two":   bytes.Repeat([]byte{'b'}, 512),
+		"three": bytes.Repeat([]byte{'c'}, 512),
+	})
+	assert.Equal(http.StatusRequestEntityTooLarge, statusCode)
+	assert.True(read < 3, "the request should be rejected before every part is read")
+}
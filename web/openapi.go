@@ -0,0 +1,540 @@
+package web
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/blend/go-sdk/webutil"
+)
+
+const (
+	// OpenAPIVersion is the version of the OpenAPI specification generated documents conform to.
+	OpenAPIVersion = "3.0.3"
+	// ContentTypeApplicationFormEncoded is the content type for url encoded form bodies.
+	ContentTypeApplicationFormEncoded = webutil.ContentTypeApplicationFormEncoded
+	// ContentTypeApplicationJSONUnqualified is the json content type without a charset, as used in api documents.
+	ContentTypeApplicationJSONUnqualified = "application/json"
+)
+
+// RouteDoc describes a route for generated api documentation.
+type RouteDoc struct {
+	// OperationID is an optional unique identifier for the route.
+	OperationID string
+	// Summary is a short summary of what the route does.
+	Summary string
+	// Description is a longer, optionally markdown formatted, description of the route.
+	Description string
+	// Tags are used to group routes in the document.
+	Tags []string
+	// Deprecated marks the route as deprecated.
+	Deprecated bool
+	// Hidden omits the route from generated documents.
+	Hidden bool
+	// Request is an instance of the type the route binds with `Ctx.Bind`.
+	// Fields tagged with `param`, `query` or `header` are documented as parameters,
+	// fields tagged with `form` as a form body, and any other fields as a json body.
+	Request interface{}
+	// Responses is a map of status code to an instance of the response type.
+	// A nil value documents a response with no body.
+	Responses map[int]interface{}
+}
+
+// Describe sets the documentation for a route.
+// The route does not need to be registered before it is described.
+func (a *App) Describe(method, path string, doc RouteDoc) {
+	if a.Docs == nil {
+		a.Docs = make(map[string]RouteDoc)
+	}
+	a.Docs[Route{Method: method, Path: path}.StringWithMethod()] = doc
+}
+
+// Describe sets the documentation for a route relative to the group.
+func (rg *RouteGroup) Describe(method, path string, doc RouteDoc) {
+	rg.App.Describe(method, rg.Path(path), doc)
+}
+
+// ServeOpenAPI serves a generated OpenAPI document for the app's routes at `{path}.json`,
+// and an api explorer page at `{path}`.
+// The explorer page loads the swagger-ui assets embedded in the package from `{path}/assets/`;
+// it is only served when built with go 1.16 or later, which can embed them.
+//
+// The document is generated from the routes registered at the time of the request,
+// along with any documentation provided with `Describe`.
+func (a *App) ServeOpenAPI(path string, info OpenAPIInfo, middleware ...Middleware) {
+	specPath := strings.TrimSuffix(path, "/") + ".json"
+	a.GET(specPath, func(_ *Ctx) Result {
+		return &JSONResult{StatusCode: http.StatusOK, Response: a.OpenAPI(info)}
+	}, middleware...)
+	a.Describe(MethodGet, specPath, RouteDoc{Hidden: true})
+
+	assets := openAPIExplorerAssets()
+	if assets == nil {
+		return
+	}
+	assetsPath := strings.TrimSuffix(path, "/") + "/assets"
+	sfs := NewStaticFileServer(assets)
+	sfs.Middleware = middleware
+	assetsRoute := a.formatStaticMountRoute(assetsPath)
+	a.Statics[assetsRoute] = sfs
+	a.Handle(MethodGet, assetsRoute, a.RenderAction(a.Middleware(sfs.Action, middleware...)))
+	a.Describe(MethodGet, assetsRoute, RouteDoc{Hidden: true})
+
+	a.GET(path, func(_ *Ctx) Result {
+		return &RawResult{
+			StatusCode:  http.StatusOK,
+			ContentType: ContentTypeHTML,
+			Response:    []byte(fmt.Sprintf(OpenAPIExplorerTemplate, html.EscapeString(info.Title), assetsPath, assetsPath, specPath)),
+		}
+	}, middleware...)
+	a.Describe(MethodGet, path, RouteDoc{Hidden: true})
+}
+
+// OpenAPI generates an OpenAPI document for the app's registered routes.
+func (a *App) OpenAPI(info OpenAPIInfo) *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Paths:   map[string]OpenAPIPathItem{},
+	}
+	if a.Config.BaseURL != "" {
+		doc.Servers = []OpenAPIServer{{URL: a.Config.BaseURL}}
+	}
+
+	// routes are visited in a fixed order so component names are assigned deterministically.
+	methods := make([]string, 0, len(a.Routes))
+	for method := range a.Routes {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	schemas := newOpenAPISchemaBuilder()
+	for _, method := range methods {
+		a.Routes[method].Walk(func(route *Route) {
+			routeDoc := a.Docs[Route{Method: method, Path: route.Path}.StringWithMethod()]
+			if routeDoc.Hidden {
+				return
+			}
+			path := OpenAPIPath(route.Path)
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = OpenAPIPathItem{}
+			}
+			doc.Paths[path][strings.ToLower(method)] = schemas.operation(method, route.Path, routeDoc)
+		})
+	}
+	if len(schemas.components) > 0 {
+		doc.Components = &OpenAPIComponents{Schemas: schemas.components}
+	}
+	return doc
+}
+
+// OpenAPIPath converts a route path into an OpenAPI path template,
+// i.e. `/users/:id` becomes `/users/{id}`.
+func OpenAPIPath(routePath string) string {
+	segments := strings.Split(routePath, "/")
+	for index, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			segments[index] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// OpenAPIDocument is the root object of an OpenAPI document.
+type OpenAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Servers    []OpenAPIServer            `json:"servers,omitempty"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components *OpenAPIComponents         `json:"components,omitempty"`
+}
+
+// OpenAPIInfo is metadata about the api.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// OpenAPIServer is a server the api is served from.
+type OpenAPIServer struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPIPathItem is a map of lowercase http method to operation.
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+// OpenAPIOperation describes a single api operation on a path.
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId,omitempty"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter is a path, query or header parameter.
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *OpenAPISchema `json:"schema,omitempty"`
+}
+
+// OpenAPIRequestBody is a request body.
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is a response for a given status code.
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType is the schema for a given content type.
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema,omitempty"`
+}
+
+// OpenAPIComponents holds reusable objects referenced from the rest of the document.
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+}
+
+// OpenAPISchema is a (subset of a) json schema.
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Enum                 []string                  `json:"enum,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty"`
+	MinItems             *int                      `json:"minItems,omitempty"`
+	MaxItems             *int                      `json:"maxItems,omitempty"`
+}
+
+// --------------------------------------------------------------------------------
+// schema generation
+// --------------------------------------------------------------------------------
+
+func newOpenAPISchemaBuilder() *openAPISchemaBuilder {
+	return &openAPISchemaBuilder{
+		names:      map[reflect.Type]string{},
+		components: map[string]*OpenAPISchema{},
+	}
+}
+
+// openAPISchemaBuilder generates schemas from go types, registering named struct types as components.
+type openAPISchemaBuilder struct {
+	names      map[reflect.Type]string
+	components map[string]*OpenAPISchema
+}
+
+func (sb *openAPISchemaBuilder) operation(method, routePath string, doc RouteDoc) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		OperationID: doc.OperationID,
+		Summary:     doc.Summary,
+		Description: doc.Description,
+		Tags:        doc.Tags,
+		Deprecated:  doc.Deprecated,
+		Responses:   map[string]OpenAPIResponse{},
+	}
+
+	documented := map[string]bool{}
+	if doc.Request != nil {
+		sb.request(op, method, reflect.TypeOf(doc.Request), documented)
+	}
+	for _, segment := range strings.Split(routePath, "/") {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') && !documented[segment[1:]] {
+			op.Parameters = append(op.Parameters, OpenAPIParameter{
+				Name:     segment[1:],
+				In:       FieldSourcePath,
+				Required: true,
+				Schema:   &OpenAPISchema{Type: "string"},
+			})
+		}
+	}
+
+	if len(doc.Responses) == 0 {
+		op.Responses[strconv.Itoa(http.StatusOK)] = OpenAPIResponse{Description: http.StatusText(http.StatusOK)}
+	}
+	statusCodes := make([]int, 0, len(doc.Responses))
+	for statusCode := range doc.Responses {
+		statusCodes = append(statusCodes, statusCode)
+	}
+	sort.Ints(statusCodes)
+	for _, statusCode := range statusCodes {
+		response := doc.Responses[statusCode]
+		res := OpenAPIResponse{Description: http.StatusText(statusCode)}
+		if response != nil {
+			res.Content = map[string]OpenAPIMediaType{
+				ContentTypeApplicationJSONUnqualified: {Schema: sb.schema(reflect.TypeOf(response))},
+			}
+		}
+		op.Responses[strconv.Itoa(statusCode)] = res
+	}
+	return op
+}
+
+// request documents the parameters and body of a bound request type.
+func (sb *openAPISchemaBuilder) request(op *OpenAPIOperation, method string, t reflect.Type, documented map[string]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	form := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	body := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	sb.requestFields(op, t, documented, form, body)
+
+	switch {
+	case len(form.Properties) > 0:
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: len(form.Required) > 0,
+			Content:  map[string]OpenAPIMediaType{ContentTypeApplicationFormEncoded: {Schema: form}},
+		}
+	case len(body.Properties) > 0 && method != MethodGet && method != "HEAD":
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: len(body.Required) > 0,
+			Content:  map[string]OpenAPIMediaType{ContentTypeApplicationJSONUnqualified: {Schema: body}},
+		}
+	}
+}
+
+func (sb *openAPISchemaBuilder) requestFields(op *OpenAPIOperation, t reflect.Type, documented map[string]bool, form, body *OpenAPISchema) {
+	for x := 0; x < t.NumField(); x++ {
+		field := t.Field(x)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		source, name := validateFieldName(field)
+		if source == "" && field.Anonymous && isNestedStruct(field.Type) {
+			sb.requestFields(op, field.Type, documented, form, body)
+			continue
+		}
+
+		schema, required := sb.fieldSchema(field)
+		switch source {
+		case FieldSourceParam, FieldSourceQuery, FieldSourceHeader:
+			in := source
+			if source == FieldSourceParam {
+				in = FieldSourcePath
+				required = true
+				documented[name] = true
+			}
+			op.Parameters = append(op.Parameters, OpenAPIParameter{Name: name, In: in, Required: required, Schema: schema})
+		case FieldSourceForm:
+			form.Properties[name] = schema
+			if required {
+				form.Required = append(form.Required, name)
+			}
+		default:
+			if strings.Split(field.Tag.Get("json"), ",")[0] == "-" {
+				continue
+			}
+			body.Properties[name] = schema
+			if required {
+				body.Required = append(body.Required, name)
+			}
+		}
+	}
+}
+
+// fieldSchema returns the schema for a struct field, including any validation rules.
+func (sb *openAPISchemaBuilder) fieldSchema(field reflect.StructField) (schema *OpenAPISchema, required bool) {
+	schema = sb.schema(field.Type)
+	rules := parseValidateRules(field.Tag.Get(ValidateTag))
+	if len(rules) == 0 {
+		return
+	}
+	if schema.Ref != "" { // rules cannot be applied to references
+		for _, rule := range rules {
+			if rule[0] == ValidateRuleRequired {
+				required = true
+			}
+		}
+		return
+	}
+
+	for _, rule := range rules {
+		switch rule[0] {
+		case ValidateRuleRequired:
+			required = true
+		case ValidateRuleMin, ValidateRuleMax:
+			limit, err := strconv.ParseFloat(rule[1], 64)
+			if err != nil {
+				continue
+			}
+			isMin := rule[0] == ValidateRuleMin
+			switch schema.Type {
+			case "string":
+				setOpenAPILimit(&schema.MinLength, &schema.MaxLength, isMin, int(limit))
+			case "array":
+				setOpenAPILimit(&schema.MinItems, &schema.MaxItems, isMin, int(limit))
+			case "integer", "number":
+				if isMin {
+					schema.Minimum = &limit
+				} else {
+					schema.Maximum = &limit
+				}
+			}
+		case ValidateRuleRegex:
+			schema.Pattern = rule[1]
+		case ValidateRuleEnum:
+			schema.Enum = strings.Split(rule[1], "|")
+		}
+	}
+	return
+}
+
+func setOpenAPILimit(min, max **int, isMin bool, value int) {
+	if isMin {
+		*min = &value
+	} else {
+		*max = &value
+	}
+}
+
+// schema returns the schema for a go type.
+func (sb *openAPISchemaBuilder) schema(t reflect.Type) *OpenAPISchema {
+	var nullable bool
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	switch t {
+	case typeDuration:
+		return &OpenAPISchema{Type: "string", Nullable: nullable}
+	case typeTime:
+		return &OpenAPISchema{Type: "string", Format: "date-time", Nullable: nullable}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean", Nullable: nullable}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32", Nullable: nullable}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64", Nullable: nullable}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float", Nullable: nullable}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double", Nullable: nullable}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string", Nullable: nullable}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte", Nullable: nullable}
+		}
+		return &OpenAPISchema{Type: "array", Items: sb.schema(t.Elem()), Nullable: nullable}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: sb.schema(t.Elem()), Nullable: nullable}
+	case reflect.Struct:
+		if reflect.PtrTo(t).Implements(typeTextUnmarshaler) {
+			return &OpenAPISchema{Type: "string", Nullable: nullable}
+		}
+		if t.Name() == "" {
+			return sb.object(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + sb.component(t)}
+	}
+	return &OpenAPISchema{}
+}
+
+// component registers a named struct type as a component schema and returns its name.
+// Types that share a name with a type registered before them are qualified with their package path,
+// and then numbered if that is also taken.
+func (sb *openAPISchemaBuilder) component(t reflect.Type) string {
+	if name, ok := sb.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := sb.components[name]; taken {
+		name = strings.Replace(t.PkgPath(), "/", ".", -1) + "." + t.Name()
+	}
+	for base, index := name, 2; ; index++ {
+		if _, taken := sb.components[name]; !taken {
+			break
+		}
+		name = base + strconv.Itoa(index)
+	}
+	sb.names[t] = name
+	sb.components[name] = &OpenAPISchema{} // placeholder for recursive types
+	*sb.components[name] = *sb.object(t)
+	return name
+}
+
+// object returns an object schema for a struct type using the json field names.
+func (sb *openAPISchemaBuilder) object(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+	sb.objectFields(t, schema)
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func (sb *openAPISchemaBuilder) objectFields(t reflect.Type, schema *OpenAPISchema) {
+	for x := 0; x < t.NumField(); x++ {
+		field := t.Field(x)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if tag[0] == "-" {
+			continue
+		}
+		if tag[0] == "" && field.Anonymous && isNestedStruct(field.Type) {
+			sb.objectFields(field.Type, schema)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		name := tag[0]
+		if name == "" {
+			name = field.Name
+		}
+		fieldSchema, required := sb.fieldSchema(field)
+		schema.Properties[name] = fieldSchema
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// OpenAPIExplorerTemplate is the html page used to explore generated api documents.
+// It takes the page title, the swagger-ui assets path twice, and the document url as format arguments.
+// The assets are embedded in the package and served by the app, so the page doesn't load any third party scripts.
+const OpenAPIExplorerTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<link rel="stylesheet" href="%s/swagger-ui.css">
+</head>
+<body>
+<div id="explorer"></div>
+<script src="%s/swagger-ui-bundle.js"></script>
+<script>window.onload = function() { SwaggerUIBundle({ url: %q, dom_id: "#explorer" }); };</script>
+</body>
+</html>`
@@ -0,0 +1,25 @@
+//go:build go1.16
+// +build go1.16
+
+package web
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// openAPIExplorerFiles are the swagger-ui (v4.15.5, Apache 2.0, see `openapi_explorer/LICENSE`) assets
+// the api explorer page loads, embedded so the page doesn't depend on a third party cdn.
+//
+//go:embed openapi_explorer/swagger-ui-bundle.js openapi_explorer/swagger-ui.css
+var openAPIExplorerFiles embed.FS
+
+// openAPIExplorerAssets returns the api explorer page assets.
+func openAPIExplorerAssets() http.FileSystem {
+	assets, err := fs.Sub(openAPIExplorerFiles, "openapi_explorer")
+	if err != nil {
+		return nil
+	}
+	return http.FS(assets)
+}
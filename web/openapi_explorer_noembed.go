@@ -0,0 +1,11 @@
+//go:build !go1.16
+// +build !go1.16
+
+package web
+
+import "net/http"
+
+// openAPIExplorerAssets returns the api explorer page assets, which can't be embedded before go 1.16.
+func openAPIExplorerAssets() http.FileSystem {
+	return nil
+}
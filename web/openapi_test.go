@@ -0,0 +1,150 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+type openAPITestUser struct {
+	ID       string            `json:"id"`
+	Email    string            `json:"email" validate:"required"`
+	Created  time.Time         `json:"created"`
+	Manager  *openAPITestUser  `json:"manager,omitempty"`
+	Labels   map[string]string `json:"labels"`
+	password string
+}
+
+type openAPITestCreateUser struct {
+	OrgID  int      `param:"orgID"`
+	DryRun bool     `query:"dryRun"`
+	Tags   []string `query:"tag" validate:"max=3"`
+	Email  string   `json:"email" validate:"required,regex=^.+@.+$"`
+	Role   string   `json:"role" validate:"enum=admin|member"`
+}
+
+func TestOpenAPIPath(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("/", OpenAPIPath("/"))
+	assert.Equal("/users/{id}", OpenAPIPath("/users/:id"))
+	assert.Equal("/static/{filepath}", OpenAPIPath("/static/*filepath"))
+}
+
+func TestAppOpenAPI(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New()
+	api := app.Group("/api/orgs/:orgID")
+	api.POST("/users", controllerNoOp)
+	api.Describe("POST", "/users", RouteDoc{
+		Summary:   "Create a user",
+		Tags:      []string{"users"},
+		Request:   openAPITestCreateUser{},
+		Responses: map[int]interface{}{http.StatusOK: openAPITestUser{}, http.StatusBadRequest: FieldErrorsResponse{}},
+	})
+	api.GET("/users/:id", controllerNoOp)
+	app.ServeOpenAPI("/docs", OpenAPIInfo{Title: "Test API", Version: "1.0"})
+
+	doc := app.OpenAPI(OpenAPIInfo{Title: "Test API", Version: "1.0"})
+	assert.Equal(OpenAPIVersion, doc.OpenAPI)
+	assert.Len(doc.Paths, 2, "the documentation routes should be hidden")
+
+	create := doc.Paths["/api/orgs/{orgID}/users"]["post"]
+	assert.NotNil(create)
+	assert.Equal("Create a user", create.Summary)
+	assert.Len(create.Parameters, 3)
+	assert.Equal("orgID", create.Parameters[0].Name)
+	assert.Equal(FieldSourcePath, create.Parameters[0].In)
+	assert.Equal("integer", create.Parameters[0].Schema.Type)
+	assert.Equal("tag", create.Parameters[2].Name)
+	assert.Equal(3, *create.Parameters[2].Schema.MaxItems)
+
+	assert.NotNil(create.RequestBody)
+	body := create.RequestBody.Content[ContentTypeApplicationJSONUnqualified].Schema
+	assert.Equal([]string{"email"}, body.Required)
+	assert.Equal("^.+@.+$", body.Properties["email"].Pattern)
+	assert.Equal([]string{"admin", "member"}, body.Properties["role"].Enum)
+
+	assert.Equal("#/components/schemas/openAPITestUser", create.Responses["200"].Content[ContentTypeApplicationJSONUnqualified].Schema.Ref)
+	user := doc.Components.Schemas["openAPITestUser"]
+	assert.NotNil(user)
+	assert.Equal("date-time", user.Properties["created"].Format)
+	assert.Equal("#/components/schemas/openAPITestUser", user.Properties["manager"].Ref)
+	assert.Equal("string", user.Properties["labels"].AdditionalProperties.Type)
+	assert.Nil(user.Properties["password"])
+
+	get := doc.Paths["/api/orgs/{orgID}/users/{id}"]["get"]
+	assert.NotNil(get)
+	assert.Len(get.Parameters, 2)
+	assert.Equal("OK", get.Responses["200"].Description)
+}
+
+func TestAppServeOpenAPI(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New()
+	app.GET("/foo", controllerNoOp)
+	app.ServeOpenAPI("/docs", OpenAPIInfo{Title: "Test API", Version: "1.0"})
+
+	var doc OpenAPIDocument
+	res, err := MockGet(app, "/docs.json").JSONWithResponse(&doc)
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.Equal("Test API", doc.Info.Title)
+	assert.NotNil(doc.Paths["/foo"]["get"])
+
+	contents, err := MockGet(app, "/docs").Bytes()
+	assert.Nil(err)
+	assert.True(strings.Contains(string(contents), `"/docs.json"`))
+	assert.True(strings.Contains(string(contents), `src="/docs/assets/swagger-ui-bundle.js"`))
+	assert.False(strings.Contains(string(contents), "https://"), "the explorer should not load third party assets")
+
+	for _, asset := range []string{"/docs/assets/swagger-ui-bundle.js", "/docs/assets/swagger-ui.css"} {
+		res, err = MockGet(app, asset).DiscardWithResponse()
+		assert.Nil(err)
+		assert.Equal(http.StatusOK, res.StatusCode, asset)
+	}
+
+	_, err = json.Marshal(app.OpenAPI(OpenAPIInfo{}))
+	assert.Nil(err)
+}
+
+func openAPITestItemV1() interface{} {
+	type openAPITestItem struct {
+		V1 string `json:"v1"`
+	}
+	return openAPITestItem{}
+}
+
+func openAPITestItemV2() interface{} {
+	type openAPITestItem struct {
+		V2 string `json:"v2"`
+	}
+	return openAPITestItem{}
+}
+
+func TestAppOpenAPIComponentNames(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New()
+	app.GET("/v1", controllerNoOp)
+	app.Describe("GET", "/v1", RouteDoc{Responses: map[int]interface{}{http.StatusOK: openAPITestItemV1()}})
+	app.POST("/v2", controllerNoOp)
+	app.Describe("POST", "/v2", RouteDoc{Responses: map[int]interface{}{http.StatusOK: openAPITestItemV2(), http.StatusAccepted: openAPITestItemV1()}})
+
+	for index := 0; index < 10; index++ {
+		doc := app.OpenAPI(OpenAPIInfo{})
+		assert.Len(doc.Components.Schemas, 2)
+		ref := doc.Paths["/v1"]["get"].Responses["200"].Content[ContentTypeApplicationJSONUnqualified].Schema.Ref
+		assert.Equal("#/components/schemas/openAPITestItem", ref)
+		assert.NotNil(doc.Components.Schemas["openAPITestItem"].Properties["v1"])
+		ref = doc.Paths["/v2"]["post"].Responses["200"].Content[ContentTypeApplicationJSONUnqualified].Schema.Ref
+		assert.NotEqual("#/components/schemas/openAPITestItem", ref)
+		assert.NotNil(doc.Components.Schemas[strings.TrimPrefix(ref, "#/components/schemas/")].Properties["v2"])
+	}
+}
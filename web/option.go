@@ -3,6 +3,7 @@ package web
 import (
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/blend/go-sdk/env"
@@ -16,8 +17,11 @@ type Option func(*App)
 func OptConfig(cfg Config) Option {
 	return func(a *App) {
 		a.Config = cfg
-		a.Auth = NewAuthManager(cfg)
+		a.Auth, a.authErr = NewAuthManagerWithError(cfg)
 		a.Views = NewViewCache(OptViewCacheConfig(&cfg.Views))
+		if !cfg.CORS.IsZero() {
+			OptCORS(cfg.CORS)(a)
+		}
 	}
 }
 
@@ -27,8 +31,18 @@ func OptConfigFromEnv() Option {
 		var cfg Config
 		env.Env().ReadInto(&cfg)
 		a.Config = cfg
-		a.Auth = NewAuthManager(cfg)
+		a.Auth, a.authErr = NewAuthManagerWithError(cfg)
 		a.Views = NewViewCache(OptViewCacheConfig(&cfg.Views))
+		if !cfg.CORS.IsZero() {
+			OptCORS(cfg.CORS)(a)
+		}
+	}
+}
+
+// OptCompression sets the response compression config.
+func OptCompression(cfg CompressionConfig) Option {
+	return func(a *App) {
+		a.Config.Compression = cfg
 	}
 }
 
@@ -39,6 +53,38 @@ func OptBindAddr(bindAddr string) Option {
 	}
 }
 
+// OptUnixSocket sets the app to listen on a unix domain socket at a given path instead of the bind address.
+func OptUnixSocket(path string) Option {
+	return func(a *App) {
+		a.Config.UnixSocket = path
+	}
+}
+
+// OptListener sets the listener the app serves on, e.g. a proxy protocol listener, as the `NetListener`.
+// The app's `Listener` is only set when it serves on a `*net.TCPListener`.
+// The listener is closed when the app stops.
+func OptListener(listener net.Listener) Option {
+	return func(a *App) {
+		a.NetListener = listener
+	}
+}
+
+// OptRequestIDs sets if the app reads or generates a request id for every request.
+// See the `RequestID` middleware.
+func OptRequestIDs(requestIDs bool) Option {
+	return func(a *App) {
+		a.Config.RequestIDs = requestIDs
+	}
+}
+
+// OptH2C sets if the app serves http/2 over cleartext (h2c) as well as http/1.1.
+// It is ignored for tls listeners, which negotiate http/2 with alpn.
+func OptH2C(h2c bool) Option {
+	return func(a *App) {
+		a.Config.H2C = h2c
+	}
+}
+
 // OptPort sets the config bind address
 func OptPort(port int32) Option {
 	return func(a *App) {
@@ -77,6 +123,13 @@ func OptTLSConfig(cfg *tls.Config) Option {
 	return func(a *App) { a.TLSConfig = cfg }
 }
 
+// OptTLSFromFiles sets the app to serve tls with a cert and key loaded from files, and optionally
+// to require client certs signed by the cas in the given client ca files.
+// The files are reloaded when they change, so rotated certs are served without a restart.
+func OptTLSFromFiles(certPath, keyPath string, clientCAPaths ...string) Option {
+	return func(a *App) { a.TLSFiles = NewTLSFiles(certPath, keyPath, clientCAPaths...) }
+}
+
 // OptDefaultHeader sets a default header.
 func OptDefaultHeader(key, value string) Option {
 	return func(a *App) {
@@ -97,6 +150,13 @@ func OptDefaultMiddleware(middleware ...Middleware) Option {
 	return func(a *App) { a.DefaultMiddleware = middleware }
 }
 
+// OptDefaultProvider sets the default result provider for the app.
+func OptDefaultProvider(provider ResultProvider) Option {
+	return func(a *App) {
+		a.DefaultProvider = provider
+	}
+}
+
 // OptUse adds to the default middleware.
 func OptUse(m Middleware) Option {
 	return func(a *App) { a.DefaultMiddleware = append(a.DefaultMiddleware, m) }
@@ -111,3 +171,20 @@ func OptMethodNotAllowedHandler(action Action) Option {
 func OptNotFoundHandler(action Action) Option {
 	return func(a *App) { a.NotFoundHandler = a.RenderAction(action) }
 }
+
+// OptWebSocketUpgrader sets the upgrader used by websocket routes.
+func OptWebSocketUpgrader(upgrader WebSocketUpgrader) Option {
+	return func(a *App) { a.WebSocketUpgrader = upgrader }
+}
+
+// OptHealth sets the app health and serves its probes at `/healthz`, `/readyz` and `/livez`.
+// The readiness probe fails while the app is stopping so load balancers can drain it first.
+// The probes don't use the app default middleware, so they can't require a session.
+func OptHealth(health *Health) Option {
+	return func(a *App) {
+		a.Health = health
+		a.Handle("GET", DefaultHealthzPath, a.RenderAction(health.HealthzAction))
+		a.Handle("GET", DefaultReadyzPath, a.RenderAction(health.ReadyzAction))
+		a.Handle("GET", DefaultLivezPath, a.RenderAction(health.LivezAction))
+	}
+}
@@ -0,0 +1,244 @@
+package web
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/blend/go-sdk/env"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+)
+
+// ProblemTypeBlank is the default problem type, which indicates the problem has no semantics beyond its status code.
+const ProblemTypeBlank = "about:blank"
+
+var (
+	// assert it implements result provider.
+	_ ResultProvider = (*ProblemResultProvider)(nil)
+)
+
+// ProblemType is the type, title and status code an error class maps to.
+type ProblemType struct {
+	// Type is a uri that identifies the problem type, e.g. `https://example.com/problems/out-of-credit`.
+	Type string
+	// Title is a short summary of the problem type that should not change between occurrences.
+	Title string
+	// StatusCode is the http status code for the problem type.
+	StatusCode int
+}
+
+// ProblemResultProviderOption is an option for problem result providers.
+type ProblemResultProviderOption func(*ProblemResultProvider)
+
+// OptProblemType registers the problem type for an error class.
+func OptProblemType(class ex.Class, problemType ProblemType) ProblemResultProviderOption {
+	return func(prp *ProblemResultProvider) { prp.Register(class, problemType) }
+}
+
+// OptProblemShowDetails sets if problems include the messages and stacks of unregistered errors.
+func OptProblemShowDetails(showDetails bool) ProblemResultProviderOption {
+	return func(prp *ProblemResultProvider) { prp.ShowDetails = showDetails }
+}
+
+// NewProblemResultProvider returns a new problem result provider.
+// Details are shown by default outside of prodlike environments, as determined by `env.IsProdlike`;
+// if `SERVICE_ENV` is unset the environment is treated as prodlike.
+func NewProblemResultProvider(options ...ProblemResultProviderOption) *ProblemResultProvider {
+	prp := &ProblemResultProvider{
+		ShowDetails: !env.IsProdlike(env.Env().ServiceEnv()),
+	}
+	prp.Register(ErrParameterMissing, ProblemType{StatusCode: http.StatusBadRequest})
+	prp.Register(ErrFieldValidation, ProblemType{StatusCode: http.StatusBadRequest})
+	prp.Register(ErrMultipartPartTooLarge, ProblemType{StatusCode: http.StatusRequestEntityTooLarge})
+	prp.Register(ErrMultipartTooManyParts, ProblemType{StatusCode: http.StatusRequestEntityTooLarge})
+	prp.Register(ErrMultipartTooLarge, ProblemType{StatusCode: http.StatusRequestEntityTooLarge})
+	prp.Register(ErrMultipartContentType, ProblemType{StatusCode: http.StatusUnsupportedMediaType})
+	for _, option := range options {
+		option(prp)
+	}
+	return prp
+}
+
+// ProblemResultProvider returns RFC 7807 `application/problem+json` results.
+/*
+Errors are mapped to problems by their `ex.Class` through a registry of problem types:
+
+	problems := web.NewProblemResultProvider(
+		web.OptProblemType(ErrOutOfCredit, web.ProblemType{
+			Type:       "https://example.com/problems/out-of-credit",
+			Title:      "You do not have enough credit",
+			StatusCode: http.StatusForbidden,
+		}),
+	)
+	app := web.New(web.OptDefaultProvider(problems))
+
+Registered errors include their exception message, or their class, as the problem detail.
+Unregistered errors only include their class, message and stack if `ShowDetails` is set, which it is
+by default outside of prodlike environments, so internal errors aren't leaked to clients in production.
+
+Field errors are included as `fields`, and every problem includes the request path as its `instance`
+and the request id as `requestID` so clients can correlate problems with server logs.
+*/
+type ProblemResultProvider struct {
+	sync.RWMutex
+	// Types are the problem types for error classes.
+	Types map[ex.Class]ProblemType
+	// ShowDetails sets if problems include the messages and stacks of unregistered errors.
+	ShowDetails bool
+}
+
+// Register registers the problem type for an error class.
+// If the type is unset, it defaults to `about:blank`, and if the title is unset it defaults to the status text.
+func (prp *ProblemResultProvider) Register(class ex.Class, problemType ProblemType) {
+	prp.Lock()
+	defer prp.Unlock()
+	if prp.Types == nil {
+		prp.Types = make(map[ex.Class]ProblemType)
+	}
+	prp.Types[class] = problemType
+}
+
+// NotFound returns a not found problem.
+func (prp *ProblemResultProvider) NotFound() Result {
+	return &ProblemResult{Problem: NewProblem(http.StatusNotFound)}
+}
+
+// NotAuthorized returns a not authorized problem.
+func (prp *ProblemResultProvider) NotAuthorized() Result {
+	return &ProblemResult{Problem: NewProblem(http.StatusForbidden)}
+}
+
+// InternalError returns the problem for an error, defaulting to an internal server error.
+// The error is logged.
+func (prp *ProblemResultProvider) InternalError(err error) Result {
+	return ResultWithLoggedError(prp.Problem(err, http.StatusInternalServerError), err)
+}
+
+// BadRequest returns the problem for an error, defaulting to a bad request.
+func (prp *ProblemResultProvider) BadRequest(err error) Result {
+	return prp.Problem(err, http.StatusBadRequest)
+}
+
+// Status returns a problem for a status code.
+// If the response is an error, it is mapped to a problem with the status code as the default,
+// otherwise the response is used as the problem detail.
+func (prp *ProblemResultProvider) Status(statusCode int, response ...interface{}) Result {
+	if len(response) == 0 || response[0] == nil {
+		return &ProblemResult{Problem: NewProblem(statusCode)}
+	}
+	if typed, ok := response[0].(error); ok {
+		return prp.Problem(typed, statusCode)
+	}
+	problem := NewProblem(statusCode)
+	problem.Detail = fmt.Sprintf("%v", response[0])
+	return &ProblemResult{Problem: problem}
+}
+
+// Problem returns the problem result for an error.
+// The status code is used for errors whose class is not registered.
+func (prp *ProblemResultProvider) Problem(err error, statusCode int) *ProblemResult {
+	if err == nil {
+		return &ProblemResult{Problem: NewProblem(statusCode)}
+	}
+	if fieldErrors, ok := AsFieldErrors(err); ok {
+		problem := prp.problem(ErrFieldValidation, http.StatusBadRequest)
+		problem.Fields = fieldErrors
+		return &ProblemResult{Problem: problem}
+	}
+
+	class := ex.Class(ex.ErrClass(err))
+	prp.RLock()
+	_, registered := prp.Types[class]
+	prp.RUnlock()
+
+	problem := prp.problem(class, statusCode)
+	if registered {
+		if problem.Detail = ex.ErrMessage(err); problem.Detail == "" {
+			problem.Detail = string(class)
+		}
+		return &ProblemResult{Problem: problem}
+	}
+	if prp.ShowDetails {
+		problem.Detail = string(class)
+		if message := ex.ErrMessage(err); message != "" {
+			problem.Detail = problem.Detail + "; " + message
+		}
+		if typed := ex.As(err); typed != nil && typed.Stack != nil {
+			problem.Stack = typed.Stack.Strings()
+		}
+	}
+	return &ProblemResult{Problem: problem}
+}
+
+// problem returns a new problem for an error class from the registry, or from a default status code.
+func (prp *ProblemResultProvider) problem(class ex.Class, statusCode int) Problem {
+	prp.RLock()
+	problemType, ok := prp.Types[class]
+	prp.RUnlock()
+	if !ok {
+		return NewProblem(statusCode)
+	}
+
+	if problemType.StatusCode == 0 {
+		problemType.StatusCode = statusCode
+	}
+	problem := NewProblem(problemType.StatusCode)
+	if problemType.Type != "" {
+		problem.Type = problemType.Type
+	}
+	if problemType.Title != "" {
+		problem.Title = problemType.Title
+	} else if problemType.Type != "" {
+		problem.Title = string(class)
+	}
+	return problem
+}
+
+// NewProblem returns a new `about:blank` problem for a status code.
+func NewProblem(statusCode int) Problem {
+	return Problem{
+		Type:   ProblemTypeBlank,
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+	}
+}
+
+// Problem is an RFC 7807 problem details response.
+type Problem struct {
+	Type      string      `json:"type"`
+	Title     string      `json:"title"`
+	Status    int         `json:"status"`
+	Detail    string      `json:"detail,omitempty"`
+	Instance  string      `json:"instance,omitempty"`
+	RequestID string      `json:"requestID,omitempty"`
+	Fields    FieldErrors `json:"fields,omitempty"`
+	Stack     []string    `json:"stack,omitempty"`
+}
+
+// ProblemResult is an `application/problem+json` result.
+type ProblemResult struct {
+	Problem Problem
+}
+
+// Render renders the result.
+// It sets the problem instance to the request path and the request id to the request's id if they are unset.
+func (pr *ProblemResult) Render(ctx *Ctx) error {
+	problem := pr.Problem
+	if problem.Instance == "" && ctx.Request != nil && ctx.Request.URL != nil {
+		problem.Instance = ctx.Request.URL.Path
+	}
+	if problem.RequestID == "" {
+		if ctx.Request != nil {
+			problem.RequestID = logger.GetRequestID(ctx.Context())
+		}
+		if problem.RequestID == "" {
+			problem.RequestID = ctx.ID
+		}
+	}
+
+	ctx.Response.Header().Set(HeaderContentType, ContentTypeProblemJSON)
+	ctx.Response.WriteHeader(problem.Status)
+	return ex.New(json.NewEncoder(ctx.Response).Encode(problem))
+}
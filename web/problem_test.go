@@ -0,0 +1,95 @@
+package web
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/env"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/r2"
+)
+
+func TestProblemResultProvider(t *testing.T) {
+	assert := assert.New(t)
+
+	const errOutOfCredit ex.Class = "out of credit"
+	problems := NewProblemResultProvider(
+		OptProblemShowDetails(false),
+		OptProblemType(errOutOfCredit, ProblemType{
+			Type:       "https://example.com/problems/out-of-credit",
+			Title:      "You do not have enough credit",
+			StatusCode: http.StatusForbidden,
+		}),
+	)
+
+	app := New(OptDefaultProvider(problems), OptRequestIDs(true))
+	app.GET("/credit", func(ctx *Ctx) Result {
+		return ctx.DefaultProvider.InternalError(ex.New(errOutOfCredit, ex.OptMessage("balance: 30")))
+	})
+	app.GET("/internal", func(ctx *Ctx) Result {
+		return ctx.DefaultProvider.InternalError(ex.New("database password is hunter2"))
+	})
+	app.GET("/upload", func(ctx *Ctx) Result {
+		return ctx.DefaultProvider.InternalError(ex.New(ErrMultipartTooLarge))
+	})
+	app.GET("/fields", func(ctx *Ctx) Result {
+		return ctx.DefaultProvider.BadRequest(FieldErrors{{Field: "name", Source: FieldSourceQuery, Message: "is required"}})
+	})
+
+	var problem Problem
+	res, err := MockGet(app, "/credit", r2.OptHeaderValue(HeaderXRequestID, "test-request-id")).JSONWithResponse(&problem)
+	assert.Nil(err)
+	assert.Equal(http.StatusForbidden, res.StatusCode)
+	assert.Equal(ContentTypeProblemJSON, res.Header.Get(HeaderContentType))
+	assert.Equal("https://example.com/problems/out-of-credit", problem.Type)
+	assert.Equal("You do not have enough credit", problem.Title)
+	assert.Equal(http.StatusForbidden, problem.Status)
+	assert.Equal("balance: 30", problem.Detail)
+	assert.Equal("/credit", problem.Instance)
+	assert.Equal("test-request-id", problem.RequestID)
+
+	problem = Problem{}
+	res, err = MockGet(app, "/internal").JSONWithResponse(&problem)
+	assert.Nil(err)
+	assert.Equal(http.StatusInternalServerError, res.StatusCode)
+	assert.Equal(ProblemTypeBlank, problem.Type)
+	assert.Equal(http.StatusText(http.StatusInternalServerError), problem.Title)
+	assert.Empty(problem.Detail)
+	assert.Empty(problem.Stack)
+	assert.NotEmpty(problem.RequestID)
+
+	problem = Problem{}
+	res, err = MockGet(app, "/upload").JSONWithResponse(&problem)
+	assert.Nil(err)
+	assert.Equal(http.StatusRequestEntityTooLarge, res.StatusCode)
+	assert.Equal(string(ErrMultipartTooLarge), problem.Detail)
+
+	problem = Problem{}
+	res, err = MockGet(app, "/fields").JSONWithResponse(&problem)
+	assert.Nil(err)
+	assert.Equal(http.StatusBadRequest, res.StatusCode)
+	assert.Len(problem.Fields, 1)
+	assert.Equal("name", problem.Fields[0].Field)
+
+	problems.ShowDetails = true
+	problem = Problem{}
+	_, err = MockGet(app, "/internal").JSONWithResponse(&problem)
+	assert.Nil(err)
+	assert.Equal("database password is hunter2", problem.Detail)
+	assert.NotEmpty(problem.Stack)
+}
+
+func TestProblemResultProviderShowDetailsDefault(t *testing.T) {
+	assert := assert.New(t)
+	defer env.Restore()
+
+	env.Env().Set(env.VarServiceEnv, env.ServiceEnvDev)
+	assert.True(NewProblemResultProvider().ShowDetails)
+
+	env.Env().Set(env.VarServiceEnv, env.ServiceEnvProd)
+	assert.False(NewProblemResultProvider().ShowDetails)
+
+	env.Env().Delete(env.VarServiceEnv)
+	assert.False(NewProblemResultProvider().ShowDetails)
+}
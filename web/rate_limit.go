@@ -0,0 +1,168 @@
+package web
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/webutil"
+)
+
+// Rate limit headers.
+const (
+	// HeaderXRateLimitLimit is the header for the total number of requests allowed in a period.
+	HeaderXRateLimitLimit = "X-RateLimit-Limit"
+	// HeaderXRateLimitRemaining is the header for the number of requests remaining in the period.
+	HeaderXRateLimitRemaining = "X-RateLimit-Remaining"
+	// HeaderXRateLimitReset is the header for the unix time the limit is fully replenished.
+	HeaderXRateLimitReset = "X-RateLimit-Reset"
+	// HeaderRetryAfter is the header for the number of seconds to wait before retrying.
+	HeaderRetryAfter = "Retry-After"
+)
+
+// ErrInvalidTrustedProxy is returned if a trusted proxy is not an ip address or cidr range.
+const ErrInvalidTrustedProxy ex.Class = "invalid trusted proxy"
+
+// RateLimitKeyFunc returns the key to rate limit a request by.
+// If it returns an empty key the request is not rate limited.
+type RateLimitKeyFunc func(*Ctx) string
+
+// RateLimitByRemoteAddr keys requests by the ip address of the connection peer.
+// Forwarding headers are ignored because clients can set them to anything;
+// use `RateLimitByForwardedFor` if the app is behind a proxy.
+func RateLimitByRemoteAddr(ctx *Ctx) string {
+	return remoteAddrIP(ctx.Request.RemoteAddr)
+}
+
+// RateLimitByForwardedFor returns a key func that keys requests by the client ip address
+// reported in the `X-Forwarded-For` header by trusted proxies.
+/*
+The trusted proxies are ip addresses or cidr ranges. The header is only used if the connection
+peer is a trusted proxy, and is read from the right, skipping trusted proxies, so the key is the
+address of the first untrusted hop; addresses a client prepends to the header are never used.
+
+	key, err := web.RateLimitByForwardedFor("10.0.0.0/8")
+*/
+func RateLimitByForwardedFor(trustedProxies ...string) (RateLimitKeyFunc, error) {
+	var trusted []*net.IPNet
+	for _, proxy := range trustedProxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, ex.New(ErrInvalidTrustedProxy, ex.OptMessagef("trusted proxy: %s", proxy))
+			}
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(len(ip)*8, len(ip)*8)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, ex.New(ErrInvalidTrustedProxy, ex.OptMessagef("trusted proxy: %s", proxy), ex.OptInner(err))
+		}
+		trusted = append(trusted, network)
+	}
+	isTrusted := func(addr string) bool {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return false
+		}
+		for _, network := range trusted {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(ctx *Ctx) string {
+		addr := remoteAddrIP(ctx.Request.RemoteAddr)
+		if !isTrusted(addr) {
+			return addr
+		}
+		hops := strings.Split(strings.Join(ctx.Request.Header[webutil.HeaderXForwardedFor], ","), ",")
+		for index := len(hops) - 1; index >= 0; index-- {
+			hop := strings.TrimSpace(hops[index])
+			if hop == "" {
+				continue
+			}
+			addr = hop
+			if !isTrusted(hop) {
+				break
+			}
+		}
+		return addr
+	}, nil
+}
+
+// RateLimitBySessionUserID keys requests by the session user id.
+// Requests without a session are not rate limited; use it after `SessionAware` or `SessionRequired`.
+func RateLimitBySessionUserID(ctx *Ctx) string {
+	if ctx.Session == nil {
+		return ""
+	}
+	return ctx.Session.UserID
+}
+
+// RateLimitByHeader returns a key func that keys requests by the value of a header.
+func RateLimitByHeader(header string) RateLimitKeyFunc {
+	return func(ctx *Ctx) string {
+		return ctx.Request.Header.Get(header)
+	}
+}
+
+// RateLimit returns a middleware that limits requests by key using a given store.
+/*
+The key func defaults to `RateLimitByRemoteAddr`. Every response carries the
+`X-RateLimit-*` headers; requests over the limit are answered with a `429 Too Many Requests`
+from the ctx's default result provider and a `Retry-After` header.
+
+If the store returns an error it is logged and the request is allowed.
+
+	store := web.NewTokenBucketStore(10, time.Second, 20)
+	app.POST("/api/login", login, web.RateLimit(store, web.RateLimitByRemoteAddr))
+*/
+func RateLimit(store RateLimitStore, key RateLimitKeyFunc) Middleware {
+	if key == nil {
+		key = RateLimitByRemoteAddr
+	}
+	return func(action Action) Action {
+		return func(ctx *Ctx) Result {
+			rateLimitKey := key(ctx)
+			if rateLimitKey == "" {
+				return action(ctx)
+			}
+
+			now := time.Now().UTC()
+			result, err := store.Take(rateLimitKey, now)
+			if err != nil {
+				logger.MaybeError(ctx.Log, err)
+				return action(ctx)
+			}
+
+			header := ctx.Response.Header()
+			header.Set(HeaderXRateLimitLimit, strconv.Itoa(result.Limit))
+			header.Set(HeaderXRateLimitRemaining, strconv.Itoa(result.Remaining))
+			header.Set(HeaderXRateLimitReset, strconv.FormatInt(result.Reset.Unix(), 10))
+			if !result.Allowed {
+				header.Set(HeaderRetryAfter, strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+				return ctx.DefaultProvider.Status(http.StatusTooManyRequests)
+			}
+			return action(ctx)
+		}
+	}
+}
+
+// remoteAddrIP returns the ip address of a request remote address.
+func remoteAddrIP(remoteAddr string) string {
+	if ip, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		return ip
+	}
+	return remoteAddr
+}
@@ -0,0 +1,194 @@
+package web
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+var (
+	_ RateLimitStore = (*TokenBucketStore)(nil)
+	_ RateLimitStore = (*SlidingWindowStore)(nil)
+)
+
+// RateLimitStore counts requests for rate limit keys.
+/*
+Stores own the limiting algorithm so that a shared store (e.g. one backed by redis)
+can apply it atomically. Implementations must be safe to use from multiple goroutines.
+*/
+type RateLimitStore interface {
+	Take(key string, now time.Time) (RateLimitResult, error)
+}
+
+// RateLimitResult is the outcome of taking a request from a rate limit store.
+type RateLimitResult struct {
+	// Allowed indicates if the request is within the limit.
+	Allowed bool
+	// Limit is the total number of requests allowed in a period.
+	Limit int
+	// Remaining is the number of requests left in the current period.
+	Remaining int
+	// Reset is when the limit will be fully replenished.
+	Reset time.Time
+	// RetryAfter is how long to wait before the next request will be allowed, if the request was not allowed.
+	RetryAfter time.Duration
+}
+
+// NewTokenBucketStore returns a new in-memory token bucket store.
+// Buckets hold up to `burst` tokens and are refilled at `limit` tokens per `period`.
+func NewTokenBucketStore(limit int, period time.Duration, burst int) *TokenBucketStore {
+	if burst < 1 {
+		burst = limit
+	}
+	return &TokenBucketStore{
+		Limit:   limit,
+		Period:  period,
+		Burst:   burst,
+		buckets: map[string]*tokenBucket{},
+	}
+}
+
+// TokenBucketStore is an in-memory token bucket rate limit store.
+type TokenBucketStore struct {
+	sync.Mutex
+	Limit  int
+	Period time.Duration
+	Burst  int
+
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+// Take implements RateLimitStore.
+func (tbs *TokenBucketStore) Take(key string, now time.Time) (RateLimitResult, error) {
+	tbs.Lock()
+	defer tbs.Unlock()
+
+	tbs.sweep(now)
+
+	rate := float64(tbs.Limit) / float64(tbs.Period) // tokens per nanosecond
+	bucket, ok := tbs.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{Tokens: float64(tbs.Burst), Updated: now}
+		tbs.buckets[key] = bucket
+	}
+	if elapsed := now.Sub(bucket.Updated); elapsed > 0 {
+		bucket.Tokens = math.Min(float64(tbs.Burst), bucket.Tokens+float64(elapsed)*rate)
+		bucket.Updated = now
+	}
+
+	result := RateLimitResult{Limit: tbs.Burst}
+	if bucket.Tokens >= 1 {
+		bucket.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - bucket.Tokens) / rate))
+	}
+	result.Remaining = int(bucket.Tokens)
+	result.Reset = now.Add(time.Duration(math.Ceil((float64(tbs.Burst) - bucket.Tokens) / rate)))
+	return result, nil
+}
+
+// sweep removes full buckets, as they are equivalent to new buckets.
+func (tbs *TokenBucketStore) sweep(now time.Time) {
+	if now.Sub(tbs.lastSweep) < tbs.Period {
+		return
+	}
+	tbs.lastSweep = now
+	fillTime := time.Duration(float64(tbs.Burst) / float64(tbs.Limit) * float64(tbs.Period))
+	for key, bucket := range tbs.buckets {
+		if now.Sub(bucket.Updated) >= fillTime {
+			delete(tbs.buckets, key)
+		}
+	}
+}
+
+// NewSlidingWindowStore returns a new in-memory sliding window store
+// that allows `limit` requests in any `window` length period.
+func NewSlidingWindowStore(limit int, window time.Duration) *SlidingWindowStore {
+	return &SlidingWindowStore{
+		Limit:   limit,
+		Window:  window,
+		windows: map[string]*slidingWindow{},
+	}
+}
+
+// SlidingWindowStore is an in-memory sliding window rate limit store.
+/*
+It approximates a sliding window by weighting the count of the previous fixed window
+by how much of it overlaps the sliding window, which keeps only two counters per key.
+*/
+type SlidingWindowStore struct {
+	sync.Mutex
+	Limit  int
+	Window time.Duration
+
+	windows   map[string]*slidingWindow
+	lastSweep time.Time
+}
+
+type slidingWindow struct {
+	Start    time.Time
+	Current  int
+	Previous int
+}
+
+// Take implements RateLimitStore.
+func (sws *SlidingWindowStore) Take(key string, now time.Time) (RateLimitResult, error) {
+	sws.Lock()
+	defer sws.Unlock()
+
+	sws.sweep(now)
+
+	windowStart := now.Truncate(sws.Window)
+	window, ok := sws.windows[key]
+	if !ok {
+		window = &slidingWindow{Start: windowStart}
+		sws.windows[key] = window
+	}
+	if !window.Start.Equal(windowStart) {
+		if windowStart.Sub(window.Start) == sws.Window {
+			window.Previous = window.Current
+		} else {
+			window.Previous = 0
+		}
+		window.Current = 0
+		window.Start = windowStart
+	}
+
+	overlap := 1 - float64(now.Sub(windowStart))/float64(sws.Window)
+	count := float64(window.Previous)*overlap + float64(window.Current)
+
+	result := RateLimitResult{Limit: sws.Limit, Reset: windowStart.Add(sws.Window)}
+	if count+1 <= float64(sws.Limit) {
+		window.Current++
+		count++
+		result.Allowed = true
+	} else if window.Previous > 0 && float64(window.Current) < float64(sws.Limit) {
+		// wait until enough of the previous window has slid out of view.
+		needed := (count + 1 - float64(sws.Limit)) / float64(window.Previous)
+		result.RetryAfter = time.Duration(math.Ceil(needed * float64(sws.Window)))
+	} else {
+		result.RetryAfter = windowStart.Add(sws.Window).Sub(now)
+	}
+	result.Remaining = int(math.Max(0, math.Floor(float64(sws.Limit)-count)))
+	return result, nil
+}
+
+// sweep removes windows that have no requests in the sliding window.
+func (sws *SlidingWindowStore) sweep(now time.Time) {
+	if now.Sub(sws.lastSweep) < sws.Window {
+		return
+	}
+	sws.lastSweep = now
+	for key, window := range sws.windows {
+		if now.Sub(window.Start) >= 2*sws.Window {
+			delete(sws.windows, key)
+		}
+	}
+}
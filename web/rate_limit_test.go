@@ -0,0 +1,206 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/r2"
+	"github.com/blend/go-sdk/webutil"
+)
+
+func TestTokenBucketStore(t *testing.T) {
+	assert := assert.New(t)
+
+	store := NewTokenBucketStore(1, time.Second, 2)
+	now := time.Date(2019, 01, 01, 12, 0, 0, 0, time.UTC)
+
+	result, err := store.Take("foo", now)
+	assert.Nil(err)
+	assert.True(result.Allowed)
+	assert.Equal(2, result.Limit)
+	assert.Equal(1, result.Remaining)
+
+	result, err = store.Take("foo", now)
+	assert.Nil(err)
+	assert.True(result.Allowed)
+	assert.Equal(0, result.Remaining)
+
+	result, err = store.Take("foo", now)
+	assert.Nil(err)
+	assert.False(result.Allowed)
+	assert.Equal(time.Second, result.RetryAfter)
+	assert.Equal(now.Add(2*time.Second), result.Reset)
+
+	// other keys have their own bucket.
+	result, err = store.Take("bar", now)
+	assert.Nil(err)
+	assert.True(result.Allowed)
+
+	result, err = store.Take("foo", now.Add(time.Second))
+	assert.Nil(err)
+	assert.True(result.Allowed)
+
+	// full buckets are swept.
+	store.Take("baz", now.Add(time.Hour))
+	assert.Len(store.buckets, 1)
+}
+
+func TestSlidingWindowStore(t *testing.T) {
+	assert := assert.New(t)
+
+	store := NewSlidingWindowStore(2, time.Minute)
+	now := time.Date(2019, 01, 01, 12, 0, 0, 0, time.UTC)
+
+	result, err := store.Take("foo", now)
+	assert.Nil(err)
+	assert.True(result.Allowed)
+	assert.Equal(1, result.Remaining)
+	assert.Equal(now.Add(time.Minute), result.Reset)
+
+	result, err = store.Take("foo", now.Add(time.Second))
+	assert.Nil(err)
+	assert.True(result.Allowed)
+	assert.Equal(0, result.Remaining)
+
+	result, err = store.Take("foo", now.Add(2*time.Second))
+	assert.Nil(err)
+	assert.False(result.Allowed)
+	assert.Equal(58*time.Second, result.RetryAfter)
+
+	// halfway through the next window, half of the previous window's requests still count.
+	result, err = store.Take("foo", now.Add(90*time.Second))
+	assert.Nil(err)
+	assert.True(result.Allowed)
+	assert.Equal(0, result.Remaining)
+
+	result, err = store.Take("foo", now.Add(91*time.Second))
+	assert.Nil(err)
+	assert.False(result.Allowed)
+	assert.True(result.RetryAfter > 0)
+	assert.True(result.RetryAfter <= 30*time.Second)
+
+	// a gap of more than a window resets the count.
+	result, err = store.Take("foo", now.Add(5*time.Minute))
+	assert.Nil(err)
+	assert.True(result.Allowed)
+	assert.Equal(1, result.Remaining)
+}
+
+func TestSlidingWindowStoreConcurrent(t *testing.T) {
+	assert := assert.New(t)
+
+	store := NewSlidingWindowStore(50, time.Hour)
+	now := time.Now()
+
+	var allowed int
+	var lock sync.Mutex
+	wg := sync.WaitGroup{}
+	for x := 0; x < 100; x++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, _ := store.Take("foo", now)
+			if result.Allowed {
+				lock.Lock()
+				allowed++
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(50, allowed)
+}
+
+func TestRateLimit(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New()
+	app.GET("/", func(_ *Ctx) Result {
+		return JSON.OK()
+	}, RateLimit(NewTokenBucketStore(1, time.Hour, 2), RateLimitByHeader("X-API-Key")), JSONProviderAsDefault)
+
+	for x := 0; x < 2; x++ {
+		res, err := MockGet(app, "/", r2.OptHeaderValue("X-API-Key", "foo")).DiscardWithResponse()
+		assert.Nil(err)
+		assert.Equal(http.StatusOK, res.StatusCode)
+		assert.Equal("2", res.Header.Get(HeaderXRateLimitLimit))
+		assert.Equal(fmt.Sprint(1-x), res.Header.Get(HeaderXRateLimitRemaining))
+		assert.NotEmpty(res.Header.Get(HeaderXRateLimitReset))
+	}
+
+	res, err := MockGet(app, "/", r2.OptHeaderValue("X-API-Key", "foo")).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusTooManyRequests, res.StatusCode)
+	assert.Equal("3600", res.Header.Get(HeaderRetryAfter))
+
+	// a different key is limited separately.
+	res, err = MockGet(app, "/", r2.OptHeaderValue("X-API-Key", "bar")).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+
+	// no key is not limited.
+	for x := 0; x < 3; x++ {
+		res, err = MockGet(app, "/").DiscardWithResponse()
+		assert.Nil(err)
+		assert.Equal(http.StatusOK, res.StatusCode)
+		assert.Empty(res.Header.Get(HeaderXRateLimitLimit))
+	}
+}
+
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(_ string, _ time.Time) (RateLimitResult, error) {
+	return RateLimitResult{}, fmt.Errorf("store unavailable")
+}
+
+func TestRateLimitStoreError(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New()
+	app.GET("/", func(_ *Ctx) Result {
+		return JSON.OK()
+	}, RateLimit(failingRateLimitStore{}, nil))
+
+	res, err := MockGet(app, "/").DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+}
+
+func TestRateLimitByRemoteAddr(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := MockCtx("GET", "/", OptCtxHeaderValue(webutil.HeaderXForwardedFor, "1.2.3.4"))
+	ctx.Request.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal("10.0.0.1", RateLimitByRemoteAddr(ctx), "forwarding headers should be ignored")
+}
+
+func TestRateLimitByForwardedFor(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := RateLimitByForwardedFor("not an ip")
+	assert.True(ex.Is(err, ErrInvalidTrustedProxy))
+
+	key, err := RateLimitByForwardedFor("10.0.0.0/8", "192.168.1.1")
+	assert.Nil(err)
+
+	keyFor := func(remoteAddr string, forwardedFor ...string) string {
+		ctx := MockCtx("GET", "/")
+		ctx.Request.RemoteAddr = remoteAddr
+		for _, value := range forwardedFor {
+			ctx.Request.Header.Add(webutil.HeaderXForwardedFor, value)
+		}
+		return key(ctx)
+	}
+
+	assert.Equal("8.8.8.8", keyFor("8.8.8.8:1234", "1.2.3.4"), "untrusted peers can't set the key")
+	assert.Equal("1.2.3.4", keyFor("10.0.0.1:1234", "1.2.3.4"))
+	assert.Equal("1.2.3.4", keyFor("10.0.0.1:1234", "5.6.7.8, 1.2.3.4, 192.168.1.1"), "client supplied hops should be skipped")
+	assert.Equal("1.2.3.4", keyFor("10.0.0.1:1234", "5.6.7.8", "1.2.3.4"))
+	assert.Equal("10.0.0.2", keyFor("10.0.0.1:1234", "10.0.0.2"))
+	assert.Equal("10.0.0.1", keyFor("10.0.0.1:1234"))
+}
@@ -1,11 +1,16 @@
 package web
 
 import (
+	"bufio"
+	"net"
 	"net/http"
+
+	"github.com/blend/go-sdk/ex"
 )
 
 var (
 	_ ResponseWriter = (*RawResponseWriter)(nil)
+	_ http.Hijacker  = (*RawResponseWriter)(nil)
 )
 
 // NewRawResponseWriter creates a new uncompressed response writer.
@@ -66,3 +71,18 @@ func (rw *RawResponseWriter) Flush() {
 func (rw *RawResponseWriter) Close() error {
 	return nil
 }
+
+// Hijack implements http.Hijacker.
+// Because hijacking is used to switch protocols, the status code is recorded as `101 Switching Protocols`.
+func (rw *RawResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	typed, ok := rw.innerResponse.(http.Hijacker)
+	if !ok {
+		return nil, nil, ex.New(ErrHijackUnsupported)
+	}
+	conn, brw, err := typed.Hijack()
+	if err != nil {
+		return nil, nil, ex.New(err)
+	}
+	rw.statusCode = http.StatusSwitchingProtocols
+	return conn, brw, nil
+}
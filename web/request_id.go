@@ -0,0 +1,48 @@
+package web
+
+import "github.com/blend/go-sdk/logger"
+
+// MaxRequestIDLength is the maximum length of a request id read from a request header.
+// Longer request ids are replaced with a generated one.
+const MaxRequestIDLength = 128
+
+// RequestID is a middleware that reads the request id from the `X-Request-ID` header, or generates one,
+// and sets it as the ctx id, the `X-Request-ID` response header and the request id on the request context.
+/*
+The request id is stored in the logger sub-context fields of the request context, so events triggered with
+`ctx.Context()` include it, and it can be forwarded on outbound requests with `r2.OptRequestID(ctx.Context())`.
+
+Route middleware runs after the app triggers the request event; use `OptRequestIDs(true)` to apply the
+request id to every request before the request event is triggered.
+*/
+func RequestID(action Action) Action {
+	return func(ctx *Ctx) Result {
+		applyRequestID(ctx)
+		return action(ctx)
+	}
+}
+
+// applyRequestID sets the request id for a request from the request header, falling back to the generated ctx id.
+func applyRequestID(ctx *Ctx) {
+	if requestID := ctx.Request.Header.Get(HeaderXRequestID); isValidRequestID(requestID) {
+		ctx.ID = requestID
+	}
+	if ctx.Response != nil {
+		ctx.Response.Header().Set(HeaderXRequestID, ctx.ID)
+	}
+	ctx.WithContext(logger.WithRequestID(ctx.Context(), ctx.ID))
+}
+
+// isValidRequestID returns if a request id read from a header can be used as is.
+// Ids must be non-empty, at most `MaxRequestIDLength` long, and contain only visible ascii characters.
+func isValidRequestID(requestID string) bool {
+	if len(requestID) == 0 || len(requestID) > MaxRequestIDLength {
+		return false
+	}
+	for index := 0; index < len(requestID); index++ {
+		if requestID[index] < '!' || requestID[index] > '~' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/logger"
+	"github.com/blend/go-sdk/r2"
+)
+
+func TestRequestID(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New()
+	app.GET("/", func(ctx *Ctx) Result {
+		return Text.Result(logger.GetRequestID(ctx.Context()))
+	}, RequestID)
+
+	res, err := MockGet(app, "/", r2.OptHeaderValue(HeaderXRequestID, "test-request-id")).Bytes()
+	assert.Nil(err)
+	assert.Equal("test-request-id", string(res))
+
+	res, err = MockGet(app, "/").Bytes()
+	assert.Nil(err)
+	assert.NotEmpty(string(res))
+	assert.NotEqual("test-request-id", string(res))
+
+	// invalid request ids are replaced.
+	res, err = MockGet(app, "/", r2.OptHeaderValue(HeaderXRequestID, strings.Repeat("a", MaxRequestIDLength+1))).Bytes()
+	assert.Nil(err)
+	assert.Len(string(res), len(NewRequestID()))
+}
+
+func TestAppRequestIDs(t *testing.T) {
+	assert := assert.New(t)
+
+	buffer := new(bytes.Buffer)
+	log := logger.MustNew(logger.OptAll(), logger.OptJSON(), logger.OptOutput(buffer))
+	defer log.Close()
+
+	app := New(OptLog(log), OptRequestIDs(true))
+	app.GET("/", func(ctx *Ctx) Result {
+		return Text.Result(ctx.ID)
+	})
+
+	contents, meta, err := MockGet(app, "/", r2.OptHeaderValue(HeaderXRequestID, "test-request-id")).BytesWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, meta.StatusCode)
+	assert.Equal("test-request-id", string(contents))
+	assert.Equal("test-request-id", meta.Header.Get(HeaderXRequestID))
+	assert.Nil(log.Drain())
+
+	flags := map[string]bool{}
+	scanner := bufio.NewScanner(buffer)
+	for scanner.Scan() {
+		var event map[string]interface{}
+		assert.Nil(json.Unmarshal(scanner.Bytes(), &event))
+		flag, _ := event[logger.FieldFlag].(string)
+		if flag == logger.HTTPRequest || flag == logger.HTTPResponse {
+			flags[flag] = true
+			assert.Equal("test-request-id", event[logger.FieldRequestID])
+		}
+	}
+	assert.True(flags[logger.HTTPRequest])
+	assert.True(flags[logger.HTTPResponse])
+}
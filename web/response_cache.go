@@ -0,0 +1,98 @@
+package web
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// NewResponseCache returns a new in-memory response cache that holds at most `maxEntries` responses.
+func NewResponseCache(maxEntries int) *ResponseCache {
+	return &ResponseCache{
+		MaxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+// ResponseCache is a bounded, in-memory cache of rendered responses.
+// Entries expire after their TTL, and the least recently used entries are evicted when the cache is full.
+type ResponseCache struct {
+	sync.Mutex
+	MaxEntries int
+
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+// CachedResponse is a rendered response.
+type CachedResponse struct {
+	Key          string
+	StatusCode   int
+	Header       http.Header
+	Body         []byte
+	ETag         string
+	LastModified time.Time
+	Expires      time.Time
+}
+
+// IsExpired returns if the response has expired as of a given time.
+func (cr *CachedResponse) IsExpired(now time.Time) bool {
+	return !cr.Expires.IsZero() && !now.Before(cr.Expires)
+}
+
+// Get returns a cached response for a key if it's present and not expired.
+func (rc *ResponseCache) Get(key string, now time.Time) (*CachedResponse, bool) {
+	rc.Lock()
+	defer rc.Unlock()
+
+	element, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	response := element.Value.(*CachedResponse)
+	if response.IsExpired(now) {
+		rc.removeElement(element)
+		return nil, false
+	}
+	rc.lru.MoveToFront(element)
+	return response, true
+}
+
+// Set adds or replaces a cached response, evicting the least recently used responses if the cache is full.
+func (rc *ResponseCache) Set(response *CachedResponse) {
+	rc.Lock()
+	defer rc.Unlock()
+
+	if element, ok := rc.entries[response.Key]; ok {
+		element.Value = response
+		rc.lru.MoveToFront(element)
+		return
+	}
+	rc.entries[response.Key] = rc.lru.PushFront(response)
+	for rc.MaxEntries > 0 && rc.lru.Len() > rc.MaxEntries {
+		rc.removeElement(rc.lru.Back())
+	}
+}
+
+// Remove removes a cached response.
+func (rc *ResponseCache) Remove(key string) {
+	rc.Lock()
+	defer rc.Unlock()
+	if element, ok := rc.entries[key]; ok {
+		rc.removeElement(element)
+	}
+}
+
+// Len returns the number of cached responses.
+func (rc *ResponseCache) Len() int {
+	rc.Lock()
+	defer rc.Unlock()
+	return rc.lru.Len()
+}
+
+func (rc *ResponseCache) removeElement(element *list.Element) {
+	rc.lru.Remove(element)
+	delete(rc.entries, element.Value.(*CachedResponse).Key)
+}
@@ -0,0 +1,330 @@
+package web
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/fileutil"
+	"github.com/blend/go-sdk/stats"
+)
+
+// Response cache headers and metric names.
+const (
+	// HeaderETag is the "ETag" header.
+	HeaderETag = "ETag"
+	// HeaderLastModified is the "Last-Modified" header.
+	HeaderLastModified = "Last-Modified"
+	// HeaderIfNoneMatch is the "If-None-Match" header.
+	HeaderIfNoneMatch = "If-None-Match"
+	// HeaderIfModifiedSince is the "If-Modified-Since" header.
+	HeaderIfModifiedSince = "If-Modified-Since"
+
+	// MetricNameResponseCacheHit is the metric incremented when a response is served from the cache.
+	MetricNameResponseCacheHit = "http.response_cache.hit"
+	// MetricNameResponseCacheMiss is the metric incremented when a response is not in the cache.
+	MetricNameResponseCacheMiss = "http.response_cache.miss"
+)
+
+// ResponseCacheOption is an option for the response cache middleware.
+type ResponseCacheOption func(*ResponseCacheOptions)
+
+// ResponseCacheOptions are the options for the response cache middleware.
+type ResponseCacheOptions struct {
+	// Cache stores rendered responses; if unset responses are only given etags.
+	Cache *ResponseCache
+	// TTL is how long responses are cached for; if unset they're cached until evicted.
+	TTL time.Duration
+	// Vary are the request headers responses vary by.
+	Vary []string
+	// Stats receives cache hit and miss counts.
+	Stats stats.Collector
+	// Public marks responses as the same for every client, so requests with a session
+	// or credentials are served from and stored in the cache too.
+	Public bool
+}
+
+// OptResponseCache sets the cache rendered responses are stored in.
+func OptResponseCache(cache *ResponseCache) ResponseCacheOption {
+	return func(opts *ResponseCacheOptions) { opts.Cache = cache }
+}
+
+// OptResponseCacheTTL sets how long responses are cached for.
+func OptResponseCacheTTL(ttl time.Duration) ResponseCacheOption {
+	return func(opts *ResponseCacheOptions) { opts.TTL = ttl }
+}
+
+// OptResponseCacheVary sets the request headers responses vary by.
+func OptResponseCacheVary(headers ...string) ResponseCacheOption {
+	return func(opts *ResponseCacheOptions) { opts.Vary = append(opts.Vary, headers...) }
+}
+
+// OptResponseCachePublic sets if responses are the same for every client,
+// in which case requests with a session or credentials are cached as well.
+func OptResponseCachePublic(public bool) ResponseCacheOption {
+	return func(opts *ResponseCacheOptions) { opts.Public = public }
+}
+
+// OptResponseCacheStats sets the stats collector for cache hits and misses.
+func OptResponseCacheStats(collector stats.Collector) ResponseCacheOption {
+	return func(opts *ResponseCacheOptions) { opts.Stats = collector }
+}
+
+// ResponseCacheMiddleware returns a middleware that buffers rendered results to serve conditional requests.
+/*
+GET and HEAD results are rendered into a buffer; successful responses are given an `ETag`
+(unless the result sets one), and requests with a matching `If-None-Match`, or an
+`If-Modified-Since` no earlier than the `Last-Modified` header, are answered with `304 Not Modified`.
+
+If a cache is provided, successful responses are stored in it keyed by the request method,
+uri and the values of the `Vary` headers. Responses that set cookies or have a `Cache-Control`
+of `no-store` or `private` are not stored.
+
+Because the key doesn't identify the client, requests with a session, or with `Authorization`
+or `Cookie` headers, are neither served from nor stored in the cache unless the responses are
+marked public with `OptResponseCachePublic`. Sessions are only seen if the session middleware
+runs before this middleware.
+
+	cache := web.NewResponseCache(1024)
+	app.GET("/api/products", listProducts, web.ResponseCacheMiddleware(
+		web.OptResponseCache(cache),
+		web.OptResponseCacheTTL(time.Minute),
+		web.OptResponseCacheVary("Accept-Language"),
+	))
+
+Because responses are buffered it should not be used on streaming routes.
+*/
+func ResponseCacheMiddleware(options ...ResponseCacheOption) Middleware {
+	var opts ResponseCacheOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	return func(action Action) Action {
+		return func(ctx *Ctx) Result {
+			if ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead {
+				return action(ctx)
+			}
+
+			now := time.Now().UTC()
+			key := opts.key(ctx)
+			useCache := opts.Cache != nil && (opts.Public || !hasCredentials(ctx))
+			if useCache {
+				if cached, ok := opts.Cache.Get(key, now); ok {
+					opts.increment(ctx, MetricNameResponseCacheHit)
+					return &cachedResponseResult{Response: cached, Vary: opts.Vary}
+				}
+				opts.increment(ctx, MetricNameResponseCacheMiss)
+			}
+
+			response, err := renderToCachedResponse(ctx, action)
+			if err != nil {
+				return ctx.DefaultProvider.InternalError(err)
+			}
+			response.Key = key
+			if response.StatusCode == http.StatusOK {
+				if response.ETag == "" {
+					etag, err := fileutil.ETag(response.Body)
+					if err != nil {
+						return ctx.DefaultProvider.InternalError(err)
+					}
+					response.ETag = `"` + etag + `"`
+				}
+				if useCache && isStorableResponse(response) {
+					if response.LastModified.IsZero() {
+						response.LastModified = now
+					}
+					if opts.TTL > 0 {
+						response.Expires = now.Add(opts.TTL)
+					}
+					opts.Cache.Set(response)
+				}
+			}
+			return &cachedResponseResult{Response: response, Vary: opts.Vary}
+		}
+	}
+}
+
+func (opts ResponseCacheOptions) key(ctx *Ctx) string {
+	key := ctx.Request.Method + " " + ctx.Request.URL.RequestURI()
+	for _, header := range opts.Vary {
+		key += "\n" + http.CanonicalHeaderKey(header) + ": " + strings.Join(ctx.Request.Header[http.CanonicalHeaderKey(header)], ", ")
+	}
+	return key
+}
+
+// hasCredentials returns if a request has a session or carries credentials,
+// in which case the response may be specific to the client.
+func hasCredentials(ctx *Ctx) bool {
+	return ctx.Session != nil ||
+		ctx.Request.Header.Get(HeaderAuthorization) != "" ||
+		ctx.Request.Header.Get(HeaderCookie) != ""
+}
+
+func (opts ResponseCacheOptions) increment(ctx *Ctx, metricName string) {
+	if opts.Stats == nil {
+		return
+	}
+	route := stats.RouteNotFound
+	if ctx.Route != nil {
+		route = ctx.Route.Path
+	}
+	opts.Stats.Increment(metricName, stats.Tag(stats.TagRoute, route), stats.Tag(stats.TagMethod, ctx.Request.Method))
+}
+
+// renderToCachedResponse runs an action and renders its result into a buffer.
+func renderToCachedResponse(ctx *Ctx, action Action) (*CachedResponse, error) {
+	before := copyHeader(ctx.Response.Header())
+	buffer := &bufferedResponseWriter{header: copyHeader(before)}
+
+	response := ctx.Response
+	ctx.Response = buffer
+	defer func() { ctx.Response = response }()
+
+	var err error
+	if result := action(ctx); result != nil {
+		if typed, ok := result.(ResultPreRender); ok {
+			err = ex.Nest(err, typed.PreRender(ctx))
+		}
+		err = ex.Nest(err, result.Render(ctx))
+		if typed, ok := result.(ResultPostRender); ok {
+			err = ex.Nest(err, typed.PostRender(ctx))
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// only keep the headers set while rendering, as the others will already be on the response.
+	header := http.Header{}
+	for key, values := range buffer.header {
+		if previous, ok := before[key]; !ok || strings.Join(previous, "\n") != strings.Join(values, "\n") {
+			header[key] = append([]string(nil), values...)
+		}
+	}
+	cached := &CachedResponse{
+		StatusCode: buffer.StatusCode(),
+		Header:     header,
+		Body:       buffer.body.Bytes(),
+		ETag:       header.Get(HeaderETag),
+	}
+	if lastModified := header.Get(HeaderLastModified); lastModified != "" {
+		cached.LastModified, _ = http.ParseTime(lastModified)
+	}
+	header.Del(HeaderETag)
+	header.Del(HeaderLastModified)
+	return cached, nil
+}
+
+func isStorableResponse(response *CachedResponse) bool {
+	if len(response.Header[HeaderSetCookie]) > 0 {
+		return false
+	}
+	cacheControl := strings.ToLower(response.Header.Get(HeaderCacheControl))
+	return !strings.Contains(cacheControl, "no-store") && !strings.Contains(cacheControl, "private")
+}
+
+// cachedResponseResult renders a cached response, answering conditional requests.
+type cachedResponseResult struct {
+	Response *CachedResponse
+	Vary     []string
+}
+
+// Render implements Result.
+func (crr *cachedResponseResult) Render(ctx *Ctx) error {
+	header := ctx.Response.Header()
+	// copy the values so headers added while rendering don't write into the cached response.
+	for key, values := range crr.Response.Header {
+		header[key] = append([]string(nil), values...)
+	}
+	for _, vary := range crr.Vary {
+		header.Add(HeaderVary, http.CanonicalHeaderKey(vary))
+	}
+	if crr.Response.StatusCode == http.StatusOK {
+		if crr.Response.ETag != "" {
+			header.Set(HeaderETag, crr.Response.ETag)
+		}
+		if !crr.Response.LastModified.IsZero() {
+			header.Set(HeaderLastModified, crr.Response.LastModified.UTC().Format(http.TimeFormat))
+		}
+		if isNotModified(ctx.Request, crr.Response) {
+			header.Del(HeaderContentType)
+			header.Del(HeaderContentLength)
+			ctx.Response.WriteHeader(http.StatusNotModified)
+			return nil
+		}
+	}
+	statusCode := crr.Response.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	ctx.Response.WriteHeader(statusCode)
+	_, err := ctx.Response.Write(crr.Response.Body)
+	return err
+}
+
+// isNotModified returns if a request's conditional headers match a response.
+func isNotModified(req *http.Request, response *CachedResponse) bool {
+	if ifNoneMatch := req.Header.Get(HeaderIfNoneMatch); ifNoneMatch != "" {
+		if response.ETag == "" {
+			return false
+		}
+		for _, etag := range strings.Split(ifNoneMatch, ",") {
+			etag = strings.TrimSpace(etag)
+			if etag == "*" || strings.TrimPrefix(etag, "W/") == strings.TrimPrefix(response.ETag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ifModifiedSince := req.Header.Get(HeaderIfModifiedSince); ifModifiedSince != "" && !response.LastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		if err != nil {
+			return false
+		}
+		return !response.LastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// bufferedResponseWriter is a response writer that buffers the response.
+type bufferedResponseWriter struct {
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (brw *bufferedResponseWriter) Header() http.Header { return brw.header }
+
+func (brw *bufferedResponseWriter) Write(contents []byte) (int, error) {
+	if brw.statusCode == 0 {
+		brw.statusCode = http.StatusOK
+	}
+	return brw.body.Write(contents)
+}
+
+func (brw *bufferedResponseWriter) WriteHeader(statusCode int) {
+	if brw.statusCode == 0 {
+		brw.statusCode = statusCode
+	}
+}
+
+func (brw *bufferedResponseWriter) StatusCode() int {
+	if brw.statusCode == 0 {
+		return http.StatusOK
+	}
+	return brw.statusCode
+}
+
+func (brw *bufferedResponseWriter) ContentLength() int { return brw.body.Len() }
+func (brw *bufferedResponseWriter) Flush()             {}
+func (brw *bufferedResponseWriter) Close() error       { return nil }
+
+func copyHeader(header http.Header) http.Header {
+	output := make(http.Header, len(header))
+	for key, values := range header {
+		output[key] = append([]string(nil), values...)
+	}
+	return output
+}
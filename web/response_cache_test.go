@@ -0,0 +1,237 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/r2"
+	"github.com/blend/go-sdk/stats"
+)
+
+func TestResponseCache(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Date(2019, 01, 01, 12, 0, 0, 0, time.UTC)
+	cache := NewResponseCache(2)
+	cache.Set(&CachedResponse{Key: "foo", Expires: now.Add(time.Minute)})
+	cache.Set(&CachedResponse{Key: "bar"})
+
+	_, ok := cache.Get("foo", now)
+	assert.True(ok)
+	_, ok = cache.Get("foo", now.Add(time.Minute))
+	assert.False(ok, "expired entries should be removed")
+	assert.Equal(1, cache.Len())
+
+	cache.Set(&CachedResponse{Key: "baz"})
+	_, ok = cache.Get("bar", now) // bar is now the most recently used
+	assert.True(ok)
+	cache.Set(&CachedResponse{Key: "buzz"})
+	assert.Equal(2, cache.Len())
+	_, ok = cache.Get("baz", now)
+	assert.False(ok, "the least recently used entry should be evicted")
+}
+
+func TestResponseCacheMiddlewareETag(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int
+	app := New()
+	app.GET("/", func(_ *Ctx) Result {
+		calls++
+		return JSON.Result(map[string]string{"foo": "bar"})
+	}, ResponseCacheMiddleware())
+
+	res, err := MockGet(app, "/").DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+	etag := res.Header.Get(HeaderETag)
+	assert.NotEmpty(etag)
+	assert.Equal(ContentTypeApplicationJSON, res.Header.Get(HeaderContentType))
+
+	contents, res, err := MockGet(app, "/", r2.OptHeaderValue(HeaderIfNoneMatch, etag)).BytesWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusNotModified, res.StatusCode)
+	assert.Empty(contents)
+
+	res, err = MockGet(app, "/", r2.OptHeaderValue(HeaderIfNoneMatch, `"nope", W/`+etag)).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusNotModified, res.StatusCode)
+
+	res, err = MockGet(app, "/", r2.OptHeaderValue(HeaderIfNoneMatch, `"nope"`)).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusOK, res.StatusCode)
+
+	// without a cache the action runs every time.
+	assert.Equal(4, calls)
+}
+
+func TestResponseCacheMiddlewareStore(t *testing.T) {
+	assert := assert.New(t)
+
+	collector := stats.NewMockCollector()
+	var metrics []stats.MockMetric
+	done := make(chan struct{})
+	go func() {
+		for metric := range collector.Events {
+			metrics = append(metrics, metric)
+		}
+		close(done)
+	}()
+
+	var calls int
+	cache := NewResponseCache(16)
+	app := New()
+	app.GET("/things/:id", func(ctx *Ctx) Result {
+		calls++
+		ctx.Response.Header().Set("X-Call", fmt.Sprint(calls))
+		id, _ := ctx.RouteParam("id")
+		return Text.Result(id + " " + ctx.Request.Header.Get("Accept-Language"))
+	}, ResponseCacheMiddleware(
+		OptResponseCache(cache),
+		OptResponseCacheTTL(time.Minute),
+		OptResponseCacheVary("Accept-Language"),
+		OptResponseCacheStats(collector),
+	))
+	app.GET("/private", func(ctx *Ctx) Result {
+		calls++
+		ctx.Response.Header().Set(HeaderCacheControl, "private")
+		return Text.Result("private")
+	}, ResponseCacheMiddleware(OptResponseCache(cache)))
+
+	english := r2.OptHeaderValue("Accept-Language", "en")
+	contents, res, err := MockGet(app, "/things/foo", english).BytesWithResponse()
+	assert.Nil(err)
+	assert.Equal("foo en", string(contents))
+	assert.Equal("1", res.Header.Get("X-Call"))
+	assert.Equal("Accept-Language", res.Header.Get(HeaderVary))
+	lastModified := res.Header.Get(HeaderLastModified)
+	assert.NotEmpty(lastModified)
+
+	contents, res, err = MockGet(app, "/things/foo", english).BytesWithResponse()
+	assert.Nil(err)
+	assert.Equal("foo en", string(contents))
+	assert.Equal("1", res.Header.Get("X-Call"), "the cached headers should be served")
+	assert.Equal(ContentTypeText, res.Header.Get(HeaderContentType))
+
+	res, err = MockGet(app, "/things/foo", english, r2.OptHeaderValue(HeaderIfModifiedSince, lastModified)).DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusNotModified, res.StatusCode)
+
+	contents, err = MockGet(app, "/things/foo", r2.OptHeaderValue("Accept-Language", "fr")).Bytes()
+	assert.Nil(err)
+	assert.Equal("foo fr", string(contents))
+
+	contents, err = MockGet(app, "/things/bar", english).Bytes()
+	assert.Nil(err)
+	assert.Equal("bar en", string(contents))
+	assert.Equal(3, calls)
+
+	for x := 0; x < 2; x++ {
+		contents, err = MockGet(app, "/private").Bytes()
+		assert.Nil(err)
+		assert.Equal("private", string(contents))
+	}
+	assert.Equal(5, calls)
+	assert.Equal(3, cache.Len())
+
+	close(collector.Events)
+	<-done
+	var hits, misses int
+	for _, metric := range metrics {
+		switch metric.Name {
+		case MetricNameResponseCacheHit:
+			hits++
+		case MetricNameResponseCacheMiss:
+			misses++
+		}
+	}
+	assert.Equal(2, hits)
+	assert.Equal(3, misses)
+	assert.Any(metrics[0].Tags, func(v interface{}) bool { return v.(string) == stats.Tag(stats.TagRoute, "/things/:id") })
+}
+
+func TestResponseCacheMiddlewareErrorsNotCached(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls int
+	cache := NewResponseCache(16)
+	app := New()
+	app.GET("/", func(_ *Ctx) Result {
+		calls++
+		return Text.NotFound()
+	}, ResponseCacheMiddleware(OptResponseCache(cache)))
+
+	for x := 0; x < 2; x++ {
+		res, err := MockGet(app, "/").DiscardWithResponse()
+		assert.Nil(err)
+		assert.Equal(http.StatusNotFound, res.StatusCode)
+		assert.Empty(res.Header.Get(HeaderETag))
+	}
+	assert.Equal(2, calls)
+	assert.Zero(cache.Len())
+}
+
+func TestResponseCacheMiddlewareSessions(t *testing.T) {
+	assert := assert.New(t)
+
+	testSession := func(action Action) Action {
+		return func(ctx *Ctx) Result {
+			if userID := ctx.Request.Header.Get("X-Test-User"); userID != "" {
+				ctx.Session = &Session{SessionID: "session-" + userID, UserID: userID}
+			}
+			return action(ctx)
+		}
+	}
+	whoami := func(ctx *Ctx) Result {
+		if ctx.Session == nil {
+			return Text.Result("anonymous")
+		}
+		return Text.Result(ctx.Session.UserID)
+	}
+
+	cache := NewResponseCache(16)
+	app := New()
+	// the session middleware is listed last so it runs first.
+	app.GET("/whoami", whoami, ResponseCacheMiddleware(OptResponseCache(cache)), testSession)
+	app.GET("/public", whoami, ResponseCacheMiddleware(OptResponseCache(cache), OptResponseCachePublic(true)), testSession)
+
+	get := func(path string, options ...r2.Option) string {
+		contents, err := MockGet(app, path, options...).Bytes()
+		assert.Nil(err)
+		return string(contents)
+	}
+
+	assert.Equal("alice", get("/whoami", r2.OptHeaderValue("X-Test-User", "alice")))
+	assert.Equal("bob", get("/whoami", r2.OptHeaderValue("X-Test-User", "bob")))
+	assert.Equal("anonymous", get("/whoami"))
+	assert.Equal("anonymous", get("/whoami", r2.OptHeaderValue(HeaderAuthorization, "Bearer alice")))
+	assert.Equal(1, cache.Len(), "only the anonymous response should be stored")
+
+	// public responses are shared regardless of the session.
+	assert.Equal("alice", get("/public", r2.OptHeaderValue("X-Test-User", "alice")))
+	assert.Equal("alice", get("/public", r2.OptHeaderValue("X-Test-User", "bob")))
+	assert.Equal(2, cache.Len())
+}
+
+func TestCachedResponseResultCopiesHeaders(t *testing.T) {
+	assert := assert.New(t)
+
+	vary := make([]string, 1, 4)
+	vary[0] = "Accept"
+	response := &CachedResponse{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{HeaderVary: vary},
+	}
+	result := &cachedResponseResult{Response: response, Vary: []string{"accept-language"}}
+
+	first, second := MockCtx("GET", "/"), MockCtx("GET", "/")
+	assert.Nil(result.Render(first))
+	assert.Nil(result.Render(second))
+	first.Response.Header()[HeaderVary][1] = "Cookie"
+
+	assert.Equal([]string{"Accept", "Accept-Language"}, second.Response.Header()[HeaderVary])
+	assert.Equal([]string{"Accept"}, response.Header[HeaderVary])
+}
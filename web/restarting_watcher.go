@@ -0,0 +1,114 @@
+package web
+
+import (
+	"time"
+
+	"github.com/blend/go-sdk/fileutil"
+)
+
+// newRestartingWatcher returns a new restarting watcher for a path.
+func newRestartingWatcher(path string, pollInterval time.Duration, action fileutil.WatchAction) *restartingWatcher {
+	return &restartingWatcher{
+		Path:         path,
+		PollInterval: pollInterval,
+		Action:       action,
+		started:      make(chan struct{}),
+		stopping:     make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+}
+
+// restartingWatcher watches a file with a file watcher that is restarted if it exits on an error.
+/*
+File watchers exit without stopping if the file can't be read, e.g. if it is removed, or replaced by
+renaming a new file over it, as editors and secret mounts do. When that happens the error is passed to
+`OnError`, and the file is watched again once it can be read, calling `OnRestart` as the file may have
+changed while it wasn't watched.
+*/
+type restartingWatcher struct {
+	Path         string
+	PollInterval time.Duration
+	Action       fileutil.WatchAction
+	OnError      func(error)
+	OnRestart    func()
+
+	startErr error
+	started  chan struct{}
+	stopping chan struct{}
+	done     chan struct{}
+}
+
+// start starts watching the file, and returns once the first file watcher has read the file's
+// modification time, so changes made after it returns aren't missed.
+// It returns the error the first file watcher exited with if it couldn't; the file keeps being
+// watched until the watcher is stopped either way.
+func (rw *restartingWatcher) start() error {
+	go rw.watch()
+	<-rw.started
+	return rw.startErr
+}
+
+// stop stops the watcher and waits for it to exit.
+func (rw *restartingWatcher) stop() {
+	close(rw.stopping)
+	<-rw.done
+}
+
+func (rw *restartingWatcher) watch() {
+	defer close(rw.done)
+	for restarted := false; ; restarted = true {
+		watcher := fileutil.NewWatcher(rw.Path, rw.Action)
+		watcher.PollInterval = rw.PollInterval
+		watcher.Errors = make(chan error, 1)
+		watcher.Starting()
+		exited := make(chan struct{})
+		go func() {
+			defer close(exited)
+			watcher.Watch()
+		}()
+
+		var err error
+		select {
+		case <-watcher.NotifyStarted():
+			if restarted && rw.OnRestart != nil {
+				rw.OnRestart()
+			}
+		case <-exited:
+			err = watcherError(watcher)
+		case <-rw.stopping:
+		}
+		if !restarted {
+			rw.startErr = err
+			close(rw.started)
+		}
+		if err == nil {
+			select {
+			case <-exited:
+				err = watcherError(watcher)
+			case <-rw.stopping:
+				watcher.Stopping()
+				<-exited
+				return
+			}
+		}
+
+		if err != nil && rw.OnError != nil {
+			rw.OnError(err)
+		}
+		select {
+		case <-time.After(watcher.PollIntervalOrDefault()):
+		case <-rw.stopping:
+			return
+		}
+	}
+}
+
+// watcherError returns the error an exited file watcher reported, if any.
+func watcherError(watcher *fileutil.Watcher) error {
+	select {
+	case err := <-watcher.Errors:
+		return err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,127 @@
+package web
+
+import "strings"
+
+// RouteGroup is a set of routes that share a common path prefix and middleware.
+/*
+Groups are created from an app, and can be nested:
+
+	api := app.Group("/api", web.SessionRequired)
+	v1 := api.Group("/v1", web.JSONProviderAsDefault)
+	v1.GET("/users/:id", getUser) // registers "/api/v1/users/:id"
+
+Middleware is applied in layers; the app default middleware runs first,
+then each group's middleware from the outermost group inwards, and finally
+any middleware specified on the route itself.
+
+Middleware added to a group with `Use` only applies to routes registered after the call.
+*/
+type RouteGroup struct {
+	App        *App
+	Parent     *RouteGroup
+	Prefix     string
+	Middleware []Middleware
+}
+
+// Group returns a new route group with a given path prefix and middleware.
+func (a *App) Group(prefix string, middleware ...Middleware) *RouteGroup {
+	return &RouteGroup{
+		App:        a,
+		Prefix:     cleanGroupPrefix(prefix),
+		Middleware: middleware,
+	}
+}
+
+// Group returns a new route group nested within the current group.
+// The new group's prefix is appended to the parent's prefix, and the
+// parent's middleware will run before the new group's middleware.
+func (rg *RouteGroup) Group(prefix string, middleware ...Middleware) *RouteGroup {
+	return &RouteGroup{
+		App:        rg.App,
+		Parent:     rg,
+		Prefix:     cleanGroupPrefix(prefix),
+		Middleware: middleware,
+	}
+}
+
+// Use adds middleware to the group.
+func (rg *RouteGroup) Use(middleware ...Middleware) {
+	rg.Middleware = append(rg.Middleware, middleware...)
+}
+
+// FullPrefix returns the prefix for the group including any parent group prefixes.
+func (rg *RouteGroup) FullPrefix() string {
+	if rg.Parent != nil {
+		return rg.Parent.FullPrefix() + rg.Prefix
+	}
+	return rg.Prefix
+}
+
+// Path returns the full path for a given route path relative to the group.
+func (rg *RouteGroup) Path(path string) string {
+	return rg.FullPrefix() + path
+}
+
+// GET registers a GET request handler.
+func (rg *RouteGroup) GET(path string, action Action, middleware ...Middleware) {
+	rg.Handle(MethodGet, path, action, middleware...)
+}
+
+// OPTIONS registers a OPTIONS request handler.
+func (rg *RouteGroup) OPTIONS(path string, action Action, middleware ...Middleware) {
+	rg.Handle(MethodOptions, path, action, middleware...)
+}
+
+// HEAD registers a HEAD request handler.
+func (rg *RouteGroup) HEAD(path string, action Action, middleware ...Middleware) {
+	rg.Handle("HEAD", path, action, middleware...)
+}
+
+// PUT registers a PUT request handler.
+func (rg *RouteGroup) PUT(path string, action Action, middleware ...Middleware) {
+	rg.Handle(MethodPut, path, action, middleware...)
+}
+
+// PATCH registers a PATCH request handler.
+func (rg *RouteGroup) PATCH(path string, action Action, middleware ...Middleware) {
+	rg.Handle("PATCH", path, action, middleware...)
+}
+
+// POST registers a POST request handler.
+func (rg *RouteGroup) POST(path string, action Action, middleware ...Middleware) {
+	rg.Handle(MethodPost, path, action, middleware...)
+}
+
+// DELETE registers a DELETE request handler.
+func (rg *RouteGroup) DELETE(path string, action Action, middleware ...Middleware) {
+	rg.Handle(MethodDelete, path, action, middleware...)
+}
+
+// Handle registers an action for a given method and path relative to the group.
+// The action is wrapped with the route middleware, the group middleware (outermost group first)
+// and then the app default middleware.
+func (rg *RouteGroup) Handle(method, path string, action Action, middleware ...Middleware) {
+	if len(path) == 0 || path[0] != '/' {
+		panic("path must begin with '/' in path '" + path + "'")
+	}
+	rg.App.Handle(method, rg.Path(path), rg.App.RenderAction(rg.App.Middleware(action, rg.middleware(middleware...)...)))
+}
+
+// middleware returns the middleware chain for a route in the group.
+// Middleware later in the list wraps (and runs before) middleware earlier in the list.
+func (rg *RouteGroup) middleware(route ...Middleware) []Middleware {
+	output := append([]Middleware{}, route...)
+	for group := rg; group != nil; group = group.Parent {
+		output = append(output, group.Middleware...)
+	}
+	return output
+}
+
+// cleanGroupPrefix ensures a prefix begins with, and does not end with, a slash.
+func cleanGroupPrefix(prefix string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if len(prefix) > 0 && prefix[0] != '/' {
+		prefix = "/" + prefix
+	}
+	return prefix
+}
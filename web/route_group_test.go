@@ -0,0 +1,70 @@
+package web
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestRouteGroup(t *testing.T) {
+	assert := assert.New(t)
+
+	var calls []string
+	tag := func(name string) Middleware {
+		return func(action Action) Action {
+			return func(ctx *Ctx) Result {
+				calls = append(calls, name)
+				return action(ctx)
+			}
+		}
+	}
+
+	app := New(OptUse(tag("default")))
+	api := app.Group("/api", tag("api"))
+	v1 := api.Group("v1/", tag("v1"))
+	v1.GET("/users/:id", func(ctx *Ctx) Result {
+		calls = append(calls, "action")
+		return NoContent
+	}, tag("route"))
+
+	route, params, _ := app.Lookup("GET", "/api/v1/users/foo")
+	assert.NotNil(route)
+	assert.Equal("/api/v1/users/:id", route.Path)
+	assert.Equal("foo", params.Get("id"))
+
+	res, err := MockGet(app, "/api/v1/users/foo").DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusNoContent, res.StatusCode)
+	assert.Equal([]string{"default", "api", "v1", "route", "action"}, calls)
+}
+
+func TestRouteGroupFullPrefix(t *testing.T) {
+	assert := assert.New(t)
+
+	app := New()
+	assert.Equal("/api", app.Group("api").FullPrefix())
+	assert.Equal("/api/v1", app.Group("/api/").Group("/v1").FullPrefix())
+	assert.Equal("/api/v1/", app.Group("/api").Group("/v1").Path("/"))
+	assert.Equal("", app.Group("/").FullPrefix())
+}
+
+func TestRouteGroupUse(t *testing.T) {
+	assert := assert.New(t)
+
+	var used bool
+	app := New()
+	admin := app.Group("/admin")
+	admin.Use(func(action Action) Action {
+		return func(ctx *Ctx) Result {
+			used = true
+			return action(ctx)
+		}
+	})
+	admin.POST("/reindex", func(_ *Ctx) Result { return NoContent })
+
+	res, err := MockMethod(app, "POST", "/admin/reindex").DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusNoContent, res.StatusCode)
+	assert.True(used)
+}
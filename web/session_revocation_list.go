@@ -0,0 +1,101 @@
+package web
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var (
+	_ SessionRevocationList = (*LocalSessionRevocationList)(nil)
+)
+
+// SessionRevocationList is a list of revoked sessions that is consulted when sessions are verified.
+/*
+It is required to revoke sessions that are not tracked by a server side store, i.e. jwt sessions,
+where sessions are revoked by their token id (`jti`). Revocations are kept until the sessions they
+revoke would have expired.
+*/
+type SessionRevocationList interface {
+	// RevokeSession revokes a session by id until a given expiry.
+	RevokeSession(ctx context.Context, sessionID string, expiresUTC time.Time) error
+	// RevokeUserSessions revokes every session for a user created at or before `revokedUTC` until a given expiry.
+	// Implementations compare times to the second, as jwt issue times are in seconds, so sessions created
+	// in the same second as the revocation are revoked too.
+	RevokeUserSessions(ctx context.Context, userID string, revokedUTC, expiresUTC time.Time) error
+	// IsSessionRevoked returns if a session has been revoked.
+	IsSessionRevoked(ctx context.Context, session *Session) (bool, error)
+	// SweepRevocations removes expired revocations.
+	SweepRevocations(ctx context.Context) error
+}
+
+// NewLocalSessionRevocationList returns a new in memory session revocation list.
+func NewLocalSessionRevocationList() *LocalSessionRevocationList {
+	return &LocalSessionRevocationList{
+		Sessions: map[string]time.Time{},
+		Users:    map[string]LocalUserRevocation{},
+	}
+}
+
+// LocalSessionRevocationList is an in memory session revocation list.
+// It is only suitable for single node apps, as revocations are not shared between processes.
+type LocalSessionRevocationList struct {
+	sync.Mutex
+	// Sessions are the revoked session ids and when their revocations expire.
+	Sessions map[string]time.Time
+	// Users are the user revocations by user id.
+	Users map[string]LocalUserRevocation
+}
+
+// LocalUserRevocation is a revocation of every session for a user created before a given time.
+type LocalUserRevocation struct {
+	RevokedUTC time.Time
+	ExpiresUTC time.Time
+}
+
+// RevokeSession implements SessionRevocationList.
+func (lsrl *LocalSessionRevocationList) RevokeSession(_ context.Context, sessionID string, expiresUTC time.Time) error {
+	lsrl.Lock()
+	defer lsrl.Unlock()
+	lsrl.Sessions[sessionID] = expiresUTC
+	return nil
+}
+
+// RevokeUserSessions implements SessionRevocationList.
+func (lsrl *LocalSessionRevocationList) RevokeUserSessions(_ context.Context, userID string, revokedUTC, expiresUTC time.Time) error {
+	lsrl.Lock()
+	defer lsrl.Unlock()
+	lsrl.Users[userID] = LocalUserRevocation{RevokedUTC: revokedUTC.Truncate(time.Second), ExpiresUTC: expiresUTC}
+	return nil
+}
+
+// IsSessionRevoked implements SessionRevocationList.
+func (lsrl *LocalSessionRevocationList) IsSessionRevoked(_ context.Context, session *Session) (bool, error) {
+	lsrl.Lock()
+	defer lsrl.Unlock()
+	if _, ok := lsrl.Sessions[session.SessionID]; ok {
+		return true, nil
+	}
+	if revocation, ok := lsrl.Users[session.UserID]; ok {
+		return !session.CreatedUTC.Truncate(time.Second).After(revocation.RevokedUTC), nil
+	}
+	return false, nil
+}
+
+// SweepRevocations implements SessionRevocationList.
+func (lsrl *LocalSessionRevocationList) SweepRevocations(_ context.Context) error {
+	lsrl.Lock()
+	defer lsrl.Unlock()
+	now := time.Now().UTC()
+	for sessionID, expiresUTC := range lsrl.Sessions {
+		if !expiresUTC.IsZero() && expiresUTC.Before(now) {
+			delete(lsrl.Sessions, sessionID)
+		}
+	}
+	for userID, revocation := range lsrl.Users {
+		if !revocation.ExpiresUTC.IsZero() && revocation.ExpiresUTC.Before(now) {
+			delete(lsrl.Users, userID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package web
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+func TestLocalSessionRevocationList(t *testing.T) {
+	assert := assert.New(t)
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	revocations := NewLocalSessionRevocationList()
+
+	session := NewSession("example-string", NewSessionID())
+	revoked, err := revocations.IsSessionRevoked(ctx, session)
+	assert.Nil(err)
+	assert.False(revoked)
+
+	assert.Nil(revocations.RevokeSession(ctx, session.SessionID, now.Add(-time.Minute)))
+	revoked, err = revocations.IsSessionRevoked(ctx, session)
+	assert.Nil(err)
+	assert.True(revoked)
+
+	before := &Session{UserID: "other-user", SessionID: NewSessionID(), CreatedUTC: now.Add(-time.Hour)}
+	after := &Session{UserID: "other-user", SessionID: NewSessionID(), CreatedUTC: now.Add(time.Second)}
+	assert.Nil(revocations.RevokeUserSessions(ctx, "other-user", now, now.Add(time.Hour)))
+	revoked, err = revocations.IsSessionRevoked(ctx, before)
+	assert.Nil(err)
+	assert.True(revoked)
+	revoked, err = revocations.IsSessionRevoked(ctx, after)
+	assert.Nil(err)
+	assert.False(revoked, "sessions created after the revocation should not be revoked")
+
+	// revocations are compared to the second, so sessions created in the same second are revoked.
+	for _, createdUTC := range []time.Time{now, now.Truncate(time.Second), now.Truncate(time.Second).Add(time.Second - time.Nanosecond)} {
+		sameSecond := &Session{UserID: "other-user", SessionID: NewSessionID(), CreatedUTC: createdUTC}
+		revoked, err = revocations.IsSessionRevoked(ctx, sameSecond)
+		assert.Nil(err)
+		assert.True(revoked)
+	}
+
+	assert.Nil(revocations.SweepRevocations(ctx))
+	assert.Empty(revocations.Sessions, "expired session revocations should be swept")
+	assert.Len(revocations.Users, 1)
+}
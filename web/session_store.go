@@ -0,0 +1,75 @@
+package web
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// Session store providers.
+const (
+	// SessionStoreProviderLocal stores sessions in memory.
+	SessionStoreProviderLocal = "local"
+	// SessionStoreProviderFile stores sessions as files in a directory.
+	SessionStoreProviderFile = "file"
+	// SessionStoreProviderPostgres stores sessions in a postgres table.
+	// It must be registered with a connection by the `web/dbsession` package.
+	SessionStoreProviderPostgres = "postgres"
+)
+
+// ErrSessionStoreProviderUnknown is returned if a session store provider is not registered.
+const ErrSessionStoreProviderUnknown ex.Class = "web; session store provider unknown"
+
+// SessionStore is a persistent store of sessions keyed by session id.
+type SessionStore interface {
+	// FetchSession returns a session by id, or nil if it does not exist.
+	FetchSession(ctx context.Context, sessionID string) (*Session, error)
+	// PersistSession adds or updates a session.
+	PersistSession(ctx context.Context, session *Session) error
+	// TouchSession updates the expiry of a session.
+	TouchSession(ctx context.Context, sessionID string, expiresUTC time.Time) error
+	// RemoveSession removes a session by id.
+	RemoveSession(ctx context.Context, sessionID string) error
+	// SessionsByUserID returns every session for a user.
+	SessionsByUserID(ctx context.Context, userID string) ([]*Session, error)
+	// RemoveSessionsByUserID removes every session for a user.
+	RemoveSessionsByUserID(ctx context.Context, userID string) error
+	// SweepSessions removes expired sessions.
+	SweepSessions(ctx context.Context) error
+}
+
+// SessionStoreProvider returns a session store for a config.
+type SessionStoreProvider func(SessionStoreConfig) (SessionStore, error)
+
+var (
+	sessionStoreProvidersLock sync.RWMutex
+	sessionStoreProviders     = map[string]SessionStoreProvider{
+		SessionStoreProviderLocal: func(_ SessionStoreConfig) (SessionStore, error) {
+			return NewLocalSessionCache(), nil
+		},
+		SessionStoreProviderFile: func(cfg SessionStoreConfig) (SessionStore, error) {
+			return NewFileSessionStore(cfg.PathOrDefault()), nil
+		},
+	}
+)
+
+// RegisterSessionStoreProvider registers a session store provider by name.
+// The `local` and `file` providers are registered by default.
+func RegisterSessionStoreProvider(provider string, factory SessionStoreProvider) {
+	sessionStoreProvidersLock.Lock()
+	defer sessionStoreProvidersLock.Unlock()
+	sessionStoreProviders[provider] = factory
+}
+
+// NewSessionStore returns a session store from a config using the registered providers.
+func NewSessionStore(cfg SessionStoreConfig) (SessionStore, error) {
+	sessionStoreProvidersLock.RLock()
+	factory, ok := sessionStoreProviders[cfg.Provider]
+	sessionStoreProvidersLock.RUnlock()
+	if !ok {
+		return nil, ex.New(ErrSessionStoreProviderUnknown, ex.OptMessagef("provider: %s", cfg.Provider))
+	}
+	return factory(cfg)
+}
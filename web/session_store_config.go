@@ -0,0 +1,58 @@
+package web
+
+import (
+	"time"
+
+	"github.com/blend/go-sdk/configutil"
+	"github.com/blend/go-sdk/env"
+)
+
+var (
+	_ configutil.ConfigResolver = (*SessionStoreConfig)(nil)
+)
+
+// SessionStoreConfig configures where a remote mode auth manager stores sessions.
+type SessionStoreConfig struct {
+	// Provider is the name of a registered session store provider, e.g. `local`, `file` or `postgres`.
+	Provider string `json:"provider,omitempty" yaml:"provider,omitempty" env:"SESSION_STORE_PROVIDER"`
+	// Path is the directory the `file` provider stores sessions in.
+	Path string `json:"path,omitempty" yaml:"path,omitempty" env:"SESSION_STORE_PATH"`
+	// Table is the table the `postgres` provider stores sessions in.
+	Table string `json:"table,omitempty" yaml:"table,omitempty" env:"SESSION_STORE_TABLE"`
+	// SweepInterval is how often expired sessions are removed from the store.
+	SweepInterval time.Duration `json:"sweepInterval,omitempty" yaml:"sweepInterval,omitempty" env:"SESSION_STORE_SWEEP_INTERVAL"`
+}
+
+// Resolve adds extra resolution steps when we setup the config.
+func (ssc *SessionStoreConfig) Resolve() error {
+	return env.Env().ReadInto(ssc)
+}
+
+// IsZero returns if the config is unset.
+func (ssc SessionStoreConfig) IsZero() bool {
+	return ssc.Provider == ""
+}
+
+// PathOrDefault returns the path or a default.
+func (ssc SessionStoreConfig) PathOrDefault() string {
+	if ssc.Path != "" {
+		return ssc.Path
+	}
+	return DefaultSessionStorePath
+}
+
+// TableOrDefault returns the table or a default.
+func (ssc SessionStoreConfig) TableOrDefault() string {
+	if ssc.Table != "" {
+		return ssc.Table
+	}
+	return DefaultSessionStoreTable
+}
+
+// SweepIntervalOrDefault returns the sweep interval or a default.
+func (ssc SessionStoreConfig) SweepIntervalOrDefault() time.Duration {
+	if ssc.SweepInterval > 0 {
+		return ssc.SweepInterval
+	}
+	return DefaultSessionStoreSweepInterval
+}
@@ -3,12 +3,23 @@ package web
 import (
 	"bytes"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sync"
 )
 
+// PrecompressedFileExtensions are the file extensions of precompressed siblings of static files by content encoding.
+var PrecompressedFileExtensions = map[string]string{
+	ContentEncodingBrotli: ".br",
+	ContentEncodingGZIP:   ".gz",
+}
+
+// precompressedFileEncodings are the content encodings of precompressed files in order of preference.
+var precompressedFileEncodings = []string{ContentEncodingBrotli, ContentEncodingGZIP}
+
 // NewStaticFileServer returns a new static file cache.
 func NewStaticFileServer(searchPaths ...http.FileSystem) *StaticFileServer {
 	return &StaticFileServer{
@@ -70,7 +81,25 @@ func (sc *StaticFileServer) Action(r *Ctx) Result {
 }
 
 // ServeFile writes the file to the response without running middleware.
+// If the request accepts it, a precompressed `.br` or `.gz` sibling of the file is served instead.
 func (sc *StaticFileServer) ServeFile(r *Ctx, filePath string) Result {
+	for _, encoding := range sc.acceptedPrecompressedEncodings(r.Request) {
+		f, err := sc.resolveRewrittenFile(sc.rewrite(filePath) + PrecompressedFileExtensions[encoding])
+		if err != nil {
+			return r.DefaultProvider.InternalError(err)
+		}
+		if f != nil {
+			defer f.Close()
+			finfo, err := f.Stat()
+			if err != nil {
+				return r.DefaultProvider.InternalError(err)
+			}
+			setPrecompressedHeaders(r, filePath, encoding)
+			http.ServeContent(r.Response, r.Request, filePath, finfo.ModTime(), f)
+			return nil
+		}
+	}
+
 	f, err := sc.ResolveFile(filePath)
 	if f == nil || (err != nil && os.IsNotExist(err)) {
 		return r.DefaultProvider.NotFound()
@@ -89,23 +118,60 @@ func (sc *StaticFileServer) ServeFile(r *Ctx, filePath string) Result {
 }
 
 // ServeCachedFile writes the file to the response.
+// If the request accepts it, a precompressed `.br` or `.gz` sibling of the file is served instead.
 func (sc *StaticFileServer) ServeCachedFile(r *Ctx, filepath string) Result {
+	for _, encoding := range sc.acceptedPrecompressedEncodings(r.Request) {
+		extension := PrecompressedFileExtensions[encoding]
+		file, err := sc.resolveCachedFile(filepath+extension, func() (http.File, error) {
+			return sc.resolveRewrittenFile(sc.rewrite(filepath) + extension)
+		})
+		if err != nil {
+			return r.DefaultProvider.InternalError(err)
+		}
+		if file != nil {
+			setPrecompressedHeaders(r, filepath, encoding)
+			http.ServeContent(r.Response, r.Request, filepath, file.ModTime, file.Contents)
+			return nil
+		}
+	}
+
 	file, err := sc.ResolveCachedFile(filepath)
 	if err != nil {
 		return r.DefaultProvider.InternalError(err)
 	}
+	if file == nil {
+		return r.DefaultProvider.NotFound()
+	}
 	http.ServeContent(r.Response, r.Request, filepath, file.ModTime, file.Contents)
 	return nil
 }
 
 // ResolveFile resolves a file from rewrite rules and search paths.
 func (sc *StaticFileServer) ResolveFile(filePath string) (f http.File, err error) {
+	return sc.resolveRewrittenFile(sc.rewrite(filePath))
+}
+
+// ResolveCachedFile returns a cached file at a given path.
+// It returns the cached instance of a file if it exists, and adds it to the cache if there is a miss.
+// If the file does not exist it returns nil.
+func (sc *StaticFileServer) ResolveCachedFile(filepath string) (*CachedStaticFile, error) {
+	return sc.resolveCachedFile(filepath, func() (http.File, error) {
+		return sc.ResolveFile(filepath)
+	})
+}
+
+// rewrite applies the rewrite rules to a file path.
+func (sc *StaticFileServer) rewrite(filePath string) string {
 	for _, rule := range sc.RewriteRules {
 		if matched, newFilePath := rule.Apply(filePath); matched {
 			filePath = newFilePath
 		}
 	}
+	return filePath
+}
 
+// resolveRewrittenFile opens a file from the search paths.
+func (sc *StaticFileServer) resolveRewrittenFile(filePath string) (f http.File, err error) {
 	// for each searchpath, sniff if the file exists ...
 	var openErr error
 	for _, searchPath := range sc.SearchPaths {
@@ -121,20 +187,27 @@ func (sc *StaticFileServer) ResolveFile(filePath string) (f http.File, err error
 	return
 }
 
-// ResolveCachedFile returns a cached file at a given path.
-// It returns the cached instance of a file if it exists, and adds it to the cache if there is a miss.
-func (sc *StaticFileServer) ResolveCachedFile(filepath string) (*CachedStaticFile, error) {
+// resolveCachedFile returns a cached file for a key, resolving it on a miss.
+// Files that don't exist aren't cached, so they're served once they're created.
+func (sc *StaticFileServer) resolveCachedFile(key string, resolve func() (http.File, error)) (*CachedStaticFile, error) {
 	sc.Lock()
 	defer sc.Unlock()
 
-	if file, ok := sc.Cache[filepath]; ok {
+	if file, ok := sc.Cache[key]; ok {
 		return file, nil
 	}
+	if sc.Cache == nil {
+		sc.Cache = make(map[string]*CachedStaticFile)
+	}
 
-	diskFile, err := sc.ResolveFile(filepath)
+	diskFile, err := resolve()
 	if err != nil {
 		return nil, err
 	}
+	if diskFile == nil {
+		return nil, nil
+	}
+	defer diskFile.Close()
 
 	finfo, err := diskFile.Stat()
 	if err != nil {
@@ -147,16 +220,51 @@ func (sc *StaticFileServer) ResolveCachedFile(filepath string) (*CachedStaticFil
 	}
 
 	file := &CachedStaticFile{
-		Path:     filepath,
+		Path:     key,
 		Contents: bytes.NewReader(contents),
 		ModTime:  finfo.ModTime(),
 		Size:     len(contents),
 	}
+	sc.Cache[key] = file
+	return file, nil
+}
 
-	if sc.Cache == nil {
-		sc.Cache = make(map[string]*CachedStaticFile)
+// acceptedPrecompressedEncodings returns the precompressed file encodings a request accepts in order of preference.
+func (sc *StaticFileServer) acceptedPrecompressedEncodings(req *http.Request) (output []string) {
+	acceptEncoding := req.Header.Get(HeaderAcceptEncoding)
+	if acceptEncoding == "" {
+		return
 	}
+	remaining := append([]string(nil), precompressedFileEncodings...)
+	for len(remaining) > 0 {
+		encoding := NegotiateContentEncoding(acceptEncoding, remaining...)
+		if encoding == ContentEncodingIdentity {
+			return
+		}
+		output = append(output, encoding)
+		for index := range remaining {
+			if remaining[index] == encoding {
+				remaining = append(remaining[:index], remaining[index+1:]...)
+				break
+			}
+		}
+	}
+	return
+}
 
-	sc.Cache[filepath] = file
-	return file, nil
+// setPrecompressedHeaders sets the headers for serving a precompressed sibling of a file.
+func setPrecompressedHeaders(r *Ctx, filePath, encoding string) {
+	header := r.Response.Header()
+	header.Set(HeaderContentEncoding, encoding)
+	if !headerContainsToken(header, HeaderVary, HeaderAcceptEncoding) {
+		header.Add(HeaderVary, HeaderAcceptEncoding)
+	}
+	// the content type can't be sniffed from the compressed contents.
+	if header.Get(HeaderContentType) == "" {
+		contentType := mime.TypeByExtension(filepath.Ext(filePath))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		header.Set(HeaderContentType, contentType)
+	}
 }
@@ -3,7 +3,11 @@ package web
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/blend/go-sdk/assert"
@@ -63,3 +67,83 @@ func TestStaticFileserverRewriteRule(t *testing.T) {
 	assert.Nil(result)
 	assert.NotEmpty(buffer.Bytes(), "we should still have reached the file")
 }
+
+func TestStaticFileserverPrecompressed(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "static_file_server")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "app.js"), []byte("plain"), 0644))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "app.js.gz"), []byte("gzipped"), 0644))
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "app.js.br"), []byte("brotli"), 0644))
+
+	for _, cacheDisabled := range []bool{true, false} {
+		cfs := NewStaticFileServer(http.Dir(dir))
+		cfs.CacheDisabled = cacheDisabled
+
+		serve := func(acceptEncoding string) (*webutil.MockResponseWriter, string) {
+			buffer := bytes.NewBuffer(nil)
+			res := webutil.NewMockResponse(buffer)
+			req := webutil.NewMockRequest("GET", "/app.js")
+			req.Header.Set(HeaderAcceptEncoding, acceptEncoding)
+			result := cfs.Action(NewCtx(res, req, OptCtxRouteParams(RouteParameters{
+				RouteTokenFilepath: "app.js",
+			})))
+			assert.Nil(result)
+			return res, buffer.String()
+		}
+
+		res, contents := serve("gzip, br")
+		assert.Equal("brotli", contents)
+		assert.Equal(ContentEncodingBrotli, res.Header().Get(HeaderContentEncoding))
+		assert.Equal(HeaderAcceptEncoding, res.Header().Get(HeaderVary))
+		assert.True(strings.HasSuffix(res.Header().Get(HeaderContentType), "javascript; charset=utf-8"))
+
+		res, contents = serve("gzip, br;q=0.5")
+		assert.Equal("gzipped", contents)
+		assert.Equal(ContentEncodingGZIP, res.Header().Get(HeaderContentEncoding))
+
+		res, contents = serve("deflate")
+		assert.Equal("plain", contents)
+		assert.Empty(res.Header().Get(HeaderContentEncoding))
+	}
+}
+
+func TestStaticFileserverNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	cfs := NewStaticFileServer(http.Dir("testdata"))
+	result := cfs.Action(NewCtx(webutil.NewMockResponse(new(bytes.Buffer)), webutil.NewMockRequest("GET", "/missing.html"), OptCtxRouteParams(RouteParameters{
+		RouteTokenFilepath: "missing.html",
+	}), OptCtxDefaultProvider(Text)))
+	assert.NotNil(result)
+	_, ok := cfs.Cache["missing.html"]
+	assert.False(ok, "missing files should not be cached")
+}
+
+func TestStaticFileserverServesCreatedFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "static_file_server")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+
+	cfs := NewStaticFileServer(http.Dir(dir))
+	serve := func() (Result, string) {
+		buffer := bytes.NewBuffer(nil)
+		result := cfs.Action(NewCtx(webutil.NewMockResponse(buffer), webutil.NewMockRequest("GET", "/app.js"), OptCtxRouteParams(RouteParameters{
+			RouteTokenFilepath: "app.js",
+		}), OptCtxDefaultProvider(Text)))
+		return result, buffer.String()
+	}
+
+	result, _ := serve()
+	assert.NotNil(result)
+	assert.Empty(cfs.Cache)
+
+	assert.Nil(ioutil.WriteFile(filepath.Join(dir, "app.js"), []byte("created"), 0644))
+	result, contents := serve()
+	assert.Nil(result)
+	assert.Equal("created", contents)
+}
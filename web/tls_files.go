@@ -0,0 +1,174 @@
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/blend/go-sdk/certutil"
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+)
+
+// NewTLSFiles returns a new tls files for a cert, key and optional client ca files.
+func NewTLSFiles(certPath, keyPath string, clientCAPaths ...string) *TLSFiles {
+	return &TLSFiles{
+		CertPath:      certPath,
+		KeyPath:       keyPath,
+		ClientCAPaths: clientCAPaths,
+	}
+}
+
+// TLSFiles is a server tls config loaded from files that reloads the files when they change,
+// so rotated certs are served without a restart.
+/*
+The cert key pair is watched with a `certutil.CertFileWatcher`, which serves the current certificate
+through `tls.Config.GetCertificate`. If client ca files are given, clients must present a cert signed by
+one of them, and the client ca pool is reloaded through a `certutil.CertManager` when any of them change.
+If a reload fails, the last good certs keep being served.
+*/
+type TLSFiles struct {
+	CertPath      string
+	KeyPath       string
+	ClientCAPaths []string
+	// PollInterval is how often the files are checked for changes.
+	PollInterval time.Duration
+	// Log is used to log reload events.
+	Log logger.Log
+
+	CertWatcher *certutil.CertFileWatcher
+	CertManager *certutil.CertManager
+
+	clientCAWatchers []*restartingWatcher
+}
+
+// TLSConfig loads the files and returns the tls config that serves them.
+func (tf *TLSFiles) TLSConfig() (*tls.Config, error) {
+	certWatcher, err := certutil.NewCertFileWatcher(tf.CertPath, tf.KeyPath,
+		certutil.OptCertFileWatcherPollInterval(tf.PollInterval),
+		certutil.OptCertFileWatcherOnReload(tf.onCertReload),
+	)
+	if err != nil {
+		return nil, err
+	}
+	tf.CertWatcher = certWatcher
+
+	if len(tf.ClientCAPaths) == 0 {
+		return &tls.Config{GetCertificate: certWatcher.GetCertificate}, nil
+	}
+
+	tf.CertManager = certutil.NewCertManager()
+	tf.CertManager.TLSConfig.GetCertificate = certWatcher.GetCertificate
+	if err := tf.ReloadClientCAs(); err != nil {
+		return nil, err
+	}
+	return tf.CertManager.TLSConfig, nil
+}
+
+// Start starts watching the files for changes.
+// It should be called after `TLSConfig`.
+func (tf *TLSFiles) Start() error {
+	if tf.CertWatcher == nil {
+		return ex.New(certutil.ErrTLSPathsUnset)
+	}
+	certWatcherErrors := make(chan error, 1)
+	go func() { certWatcherErrors <- tf.CertWatcher.Start() }()
+	select {
+	case <-tf.CertWatcher.NotifyStarted():
+	case err := <-certWatcherErrors:
+		return ex.New(err, ex.OptMessagef("cert: %s", tf.CertPath))
+	}
+	go func() {
+		if err := <-certWatcherErrors; err != nil {
+			logger.MaybeError(tf.Log, ex.New(err, ex.OptMessagef("tls cert watcher exited; cert: %s", tf.CertPath)))
+		}
+	}()
+
+	if tf.CertManager == nil {
+		return nil
+	}
+	for _, clientCAPath := range tf.ClientCAPaths {
+		watcher := newRestartingWatcher(clientCAPath, tf.PollInterval, tf.onClientCAChanged)
+		watcher.OnError = tf.onClientCAWatchError(clientCAPath)
+		watcher.OnRestart = func() { tf.reloadClientCAs(watcher.Path) }
+		tf.clientCAWatchers = append(tf.clientCAWatchers, watcher)
+		if err := watcher.start(); err != nil {
+			tf.Stop()
+			return ex.New(err, ex.OptMessagef("client ca: %s", clientCAPath))
+		}
+	}
+	return nil
+}
+
+// Stop stops watching the files for changes.
+func (tf *TLSFiles) Stop() {
+	if tf.CertWatcher != nil && tf.CertWatcher.CanStop() {
+		tf.CertWatcher.Stop()
+	}
+	for _, watcher := range tf.clientCAWatchers {
+		watcher.stop()
+	}
+	tf.clientCAWatchers = nil
+}
+
+// ReloadClientCAs reloads the client ca pool from the client ca files.
+func (tf *TLSFiles) ReloadClientCAs() error {
+	clientCAs := make(map[string][]byte, len(tf.ClientCAPaths))
+	for _, clientCAPath := range tf.ClientCAPaths {
+		contents, err := ioutil.ReadFile(clientCAPath)
+		if err != nil {
+			return ex.New(err)
+		}
+		clientCAs[clientCAPath] = contents
+	}
+	return tf.CertManager.UpdateClientCerts(clientCAs)
+}
+
+// NotAfter returns when the current certificate expires.
+func (tf *TLSFiles) NotAfter() (time.Time, error) {
+	if tf.CertWatcher == nil {
+		return time.Time{}, ex.New(certutil.ErrTLSPathsUnset)
+	}
+	cert, _ := tf.CertWatcher.GetCertificate(nil)
+	if cert == nil || len(cert.Certificate) == 0 {
+		return time.Time{}, ex.New(certutil.ErrInvalidCertPEM)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return time.Time{}, ex.New(err)
+	}
+	return leaf.NotAfter, nil
+}
+
+func (tf *TLSFiles) onCertReload(_ *certutil.CertFileWatcher, err error) {
+	if err != nil {
+		logger.MaybeError(tf.Log, ex.New(err, ex.OptMessagef("tls cert load failed; cert: %s", tf.CertPath)))
+		return
+	}
+	logger.MaybeInfof(tf.Log, "tls cert loaded: %s", tf.CertPath)
+}
+
+func (tf *TLSFiles) onClientCAChanged(f *os.File) error {
+	f.Close()
+	// reload errors are logged rather than returned so the watcher keeps running.
+	tf.reloadClientCAs(f.Name())
+	return nil
+}
+
+// onClientCAWatchError logs the error a client ca watcher exited with, e.g. because the file was
+// briefly removed while it was rotated. The file is watched again once it can be read.
+func (tf *TLSFiles) onClientCAWatchError(clientCAPath string) func(error) {
+	return func(err error) {
+		logger.MaybeError(tf.Log, ex.New(err, ex.OptMessagef("tls client ca watch failed; client ca: %s", clientCAPath)))
+	}
+}
+
+func (tf *TLSFiles) reloadClientCAs(clientCAPath string) {
+	if err := tf.ReloadClientCAs(); err != nil {
+		logger.MaybeError(tf.Log, ex.New(err, ex.OptMessagef("tls client ca reload failed; client ca: %s", clientCAPath)))
+		return
+	}
+	logger.MaybeInfof(tf.Log, "tls client cas reloaded: %s", clientCAPath)
+}
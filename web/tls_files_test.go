@@ -0,0 +1,165 @@
+package web
+
+import (
+	"context"
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/certutil"
+	"github.com/blend/go-sdk/ex"
+)
+
+func writeTestServerCert(assert *assert.Assertions, ca *certutil.CertBundle, notAfter time.Time, certPath, keyPath string) {
+	server, err := certutil.CreateServer("localhost", ca, certutil.OptNotAfter(notAfter))
+	assert.Nil(err)
+	certPEM, err := server.CertPEM()
+	assert.Nil(err)
+	keyPEM, err := server.KeyPEM()
+	assert.Nil(err)
+	assert.Nil(ioutil.WriteFile(certPath, certPEM, 0600))
+	assert.Nil(ioutil.WriteFile(keyPath, keyPEM, 0600))
+
+	// make sure the change is seen even if the files were written within the file system's mtime resolution.
+	modTime := time.Now().Add(time.Second)
+	assert.Nil(os.Chtimes(certPath, modTime, modTime))
+	assert.Nil(os.Chtimes(keyPath, modTime, modTime))
+}
+
+func TestAppTLSFromFiles(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "web")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	certPath := filepath.Join(tempDir, "server.cert.pem")
+	keyPath := filepath.Join(tempDir, "server.key.pem")
+	caPath := filepath.Join(tempDir, "ca.cert.pem")
+
+	ca, err := certutil.CreateCertificateAuthority()
+	assert.Nil(err)
+	caPEM, err := ca.CertPEM()
+	assert.Nil(err)
+	assert.Nil(ioutil.WriteFile(caPath, caPEM, 0600))
+	caPool, err := ca.CertPool()
+	assert.Nil(err)
+
+	client, err := certutil.CreateClient("client", ca)
+	assert.Nil(err)
+	clientCertPEM, err := client.CertPEM()
+	assert.Nil(err)
+	clientKeyPEM, err := client.KeyPEM()
+	assert.Nil(err)
+	clientCert, err := tls.X509KeyPair(clientCertPEM, clientKeyPEM)
+	assert.Nil(err)
+
+	expiresSoon := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	writeTestServerCert(assert, ca, expiresSoon, certPath, keyPath)
+
+	app := New(OptBindAddr(DefaultMockBindAddr), OptTLSFromFiles(certPath, keyPath, caPath))
+	app.TLSFiles.PollInterval = time.Millisecond
+	app.GET("/", func(_ *Ctx) Result { return Text.Result("tls") })
+	go app.Start()
+	<-app.NotifyStarted()
+	defer app.Stop()
+
+	get := func(certificates ...tls.Certificate) (*http.Response, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      caPool,
+			ServerName:   "localhost",
+			Certificates: certificates,
+		}}}
+		return client.Get("https://" + app.Listener.Addr().String() + "/")
+	}
+
+	res, err := get(clientCert)
+	assert.Nil(err)
+	res.Body.Close()
+	assert.Equal(http.StatusOK, res.StatusCode)
+	assert.True(expiresSoon.Equal(res.TLS.PeerCertificates[0].NotAfter))
+
+	_, err = get()
+	assert.NotNil(err, "client certs should be required")
+
+	notAfter, err := app.TLSFiles.NotAfter()
+	assert.Nil(err)
+	assert.True(expiresSoon.Equal(notAfter))
+	expiryCheck := HealthCheckCertExpiry(app.TLSFiles, 24*time.Hour)
+	assert.True(ex.Is(expiryCheck(context.Background()), ErrHealthCheckCertExpiring))
+
+	renewed := time.Now().UTC().Add(72 * time.Hour).Truncate(time.Second)
+	writeTestServerCert(assert, ca, renewed, certPath, keyPath)
+	for attempt := 0; attempt < 1000; attempt++ {
+		if notAfter, _ = app.TLSFiles.NotAfter(); notAfter.Equal(renewed) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert.True(renewed.Equal(notAfter))
+	assert.Nil(expiryCheck(context.Background()))
+
+	res, err = get(clientCert)
+	assert.Nil(err)
+	res.Body.Close()
+	assert.True(renewed.Equal(res.TLS.PeerCertificates[0].NotAfter))
+}
+
+func TestTLSFilesRemovedDuringRotation(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "web")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+	certPath := filepath.Join(tempDir, "server.cert.pem")
+	keyPath := filepath.Join(tempDir, "server.key.pem")
+	caPath := filepath.Join(tempDir, "ca.cert.pem")
+
+	ca, err := certutil.CreateCertificateAuthority()
+	assert.Nil(err)
+	caPEM, err := ca.CertPEM()
+	assert.Nil(err)
+	assert.Nil(ioutil.WriteFile(caPath, caPEM, 0600))
+	writeTestServerCert(assert, ca, time.Now().UTC().Add(time.Hour), certPath, keyPath)
+
+	tlsFiles := NewTLSFiles(certPath, keyPath, caPath)
+	tlsFiles.PollInterval = time.Millisecond
+	_, err = tlsFiles.TLSConfig()
+	assert.Nil(err)
+	assert.Nil(tlsFiles.Start())
+
+	// the files are briefly missing while they're rotated.
+	assert.Nil(os.Remove(certPath))
+	assert.Nil(os.Remove(caPath))
+	time.Sleep(20 * time.Millisecond)
+	assert.Nil(ioutil.WriteFile(caPath, caPEM, 0600))
+	renewed := time.Now().UTC().Add(72 * time.Hour).Truncate(time.Second)
+	writeTestServerCert(assert, ca, renewed, certPath, keyPath)
+
+	var notAfter time.Time
+	for attempt := 0; attempt < 1000; attempt++ {
+		if notAfter, _ = tlsFiles.NotAfter(); notAfter.Equal(renewed) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	assert.True(renewed.Equal(notAfter), "the cert should keep being watched after it was missing")
+
+	assert.Nil(os.Remove(certPath))
+	assert.Nil(os.Remove(caPath))
+	time.Sleep(20 * time.Millisecond)
+	stopped := make(chan struct{})
+	go func() {
+		tlsFiles.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		assert.FailNow("stop should not block after the files are removed")
+	}
+}
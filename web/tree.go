@@ -773,3 +773,13 @@ func min(a, b int) int {
 	}
 	return b
 }
+
+// Walk calls a visitor for every route registered in the tree.
+func (n *RouteNode) Walk(visitor func(*Route)) {
+	if n.Route != nil {
+		visitor(n.Route)
+	}
+	for _, child := range n.Children {
+		child.Walk(visitor)
+	}
+}
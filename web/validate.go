@@ -0,0 +1,255 @@
+package web
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// Validation rules.
+const (
+	// ValidateTag is the struct tag that holds validation rules.
+	ValidateTag = "validate"
+
+	// ValidateRuleRequired requires a field to be set to a non-zero value.
+	ValidateRuleRequired = "required"
+	// ValidateRuleMin is the minimum value of a number, or the minimum length of a string or slice.
+	ValidateRuleMin = "min"
+	// ValidateRuleMax is the maximum value of a number, or the maximum length of a string or slice.
+	ValidateRuleMax = "max"
+	// ValidateRuleRegex requires a string to match a regular expression.
+	// It must be the last rule in the tag, as the expression may contain commas.
+	ValidateRuleRegex = "regex"
+	// ValidateRuleEnum requires a value to be one of a `|` delimited set of values.
+	ValidateRuleEnum = "enum"
+)
+
+var (
+	validateRegexCache = map[string]*regexp.Regexp{}
+	validateRegexLock  sync.Mutex
+)
+
+// Validate checks a struct (or a pointer to a struct) against the rules in its `validate` field tags.
+/*
+Rules are comma delimited, for example:
+
+	Name  string   `validate:"required,min=1,max=64"`
+	Kind  string   `validate:"enum=foo|bar|baz"`
+	Tags  []string `validate:"max=10"`
+	Email string   `validate:"regex=^.+@.+$"`
+
+Fields that are unset (the zero value or nil) are only checked for `required`.
+If any rules fail, a `FieldErrors` is returned.
+*/
+func Validate(obj interface{}) error {
+	value := reflect.ValueOf(obj)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return ex.New(ErrBindTarget, ex.OptMessagef("type: %T", obj))
+	}
+
+	fieldErrors, err := validateStruct(value)
+	if err != nil {
+		return err
+	}
+	if len(fieldErrors) > 0 {
+		return fieldErrors
+	}
+	return nil
+}
+
+func validateStruct(structValue reflect.Value) (output FieldErrors, err error) {
+	structType := structValue.Type()
+	var nested FieldErrors
+	var fieldErr *FieldError
+	for x := 0; x < structType.NumField(); x++ {
+		field := structType.Field(x)
+		if field.PkgPath != "" && !field.Anonymous { // unexported
+			continue
+		}
+
+		tag := field.Tag.Get(ValidateTag)
+		if tag == "" {
+			if isNestedStruct(field.Type) {
+				if nested, err = validateStruct(structValue.Field(x)); err != nil {
+					return
+				}
+				output = append(output, nested...)
+			}
+			continue
+		}
+
+		fieldErr, err = validateField(field, structValue.Field(x), tag)
+		if err != nil {
+			return
+		}
+		if fieldErr != nil {
+			output = append(output, *fieldErr)
+		}
+	}
+	return
+}
+
+func validateField(field reflect.StructField, fieldValue reflect.Value, tag string) (*FieldError, error) {
+	source, name := validateFieldName(field)
+	for fieldValue.Kind() == reflect.Ptr && !fieldValue.IsNil() {
+		fieldValue = fieldValue.Elem()
+	}
+	isUnset := fieldValue.Kind() == reflect.Ptr || isZeroValue(fieldValue)
+
+	for _, rule := range parseValidateRules(tag) {
+		ruleName, ruleArg := rule[0], rule[1]
+		if ruleName == ValidateRuleRequired {
+			if isUnset {
+				return &FieldError{Field: name, Source: source, Rule: ruleName, Message: "is required"}, nil
+			}
+			continue
+		}
+		if isUnset {
+			continue
+		}
+
+		var message string
+		switch ruleName {
+		case ValidateRuleMin, ValidateRuleMax:
+			limit, err := strconv.ParseFloat(ruleArg, 64)
+			if err != nil {
+				return nil, ex.New("invalid validation rule", ex.OptMessagef("field: %s, rule: %s=%s", field.Name, ruleName, ruleArg))
+			}
+			actual, isLength, ok := validateMeasure(fieldValue)
+			if !ok {
+				return nil, ex.New("invalid validation rule for field type", ex.OptMessagef("field: %s, rule: %s", field.Name, ruleName))
+			}
+			if ruleName == ValidateRuleMin && actual < limit {
+				message = fmt.Sprintf("must be at least %s", ruleArg)
+			} else if ruleName == ValidateRuleMax && actual > limit {
+				message = fmt.Sprintf("must be at most %s", ruleArg)
+			}
+			if message != "" && isLength {
+				message = message + " in length"
+			}
+		case ValidateRuleRegex:
+			expr, err := validateRegex(ruleArg)
+			if err != nil {
+				return nil, ex.New(err, ex.OptMessagef("field: %s", field.Name))
+			}
+			if !expr.MatchString(fmt.Sprint(fieldValue.Interface())) {
+				message = "has an invalid format"
+			}
+		case ValidateRuleEnum:
+			actual := fmt.Sprint(fieldValue.Interface())
+			var found bool
+			for _, option := range strings.Split(ruleArg, "|") {
+				if option == actual {
+					found = true
+					break
+				}
+			}
+			if !found {
+				message = fmt.Sprintf("must be one of: %s", strings.Replace(ruleArg, "|", ", ", -1))
+			}
+		default:
+			return nil, ex.New("unknown validation rule", ex.OptMessagef("field: %s, rule: %s", field.Name, ruleName))
+		}
+		if message != "" {
+			return &FieldError{Field: name, Source: source, Rule: ruleName, Message: message}, nil
+		}
+	}
+	return nil, nil
+}
+
+// parseValidateRules splits a tag into name, argument pairs.
+func parseValidateRules(tag string) (output [][2]string) {
+	for len(tag) > 0 {
+		var rule string
+		if strings.HasPrefix(tag, ValidateRuleRegex+"=") {
+			rule, tag = tag, ""
+		} else if index := strings.Index(tag, ","); index >= 0 {
+			rule, tag = tag[:index], tag[index+1:]
+		} else {
+			rule, tag = tag, ""
+		}
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		if index := strings.Index(rule, "="); index >= 0 {
+			output = append(output, [2]string{rule[:index], rule[index+1:]})
+		} else {
+			output = append(output, [2]string{rule, ""})
+		}
+	}
+	return
+}
+
+// validateFieldName returns the source and name used to identify a field in errors.
+func validateFieldName(field reflect.StructField) (source, name string) {
+	for _, tag := range []struct{ Tag, Source string }{
+		{BindTagParam, FieldSourceParam},
+		{BindTagQuery, FieldSourceQuery},
+		{BindTagForm, FieldSourceForm},
+		{BindTagHeader, FieldSourceHeader},
+	} {
+		if value := field.Tag.Get(tag.Tag); value != "" {
+			return tag.Source, value
+		}
+	}
+	for _, tag := range []string{"json", "xml"} {
+		if value := strings.Split(field.Tag.Get(tag), ",")[0]; value != "" && value != "-" {
+			return FieldSourceBody, value
+		}
+	}
+	return "", field.Name
+}
+
+// validateMeasure returns the numeric value or length of a value for min / max comparisons.
+func validateMeasure(value reflect.Value) (measure float64, isLength bool, ok bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), false, true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), true, true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(value.Len()), true, true
+	}
+	return 0, false, false
+}
+
+func validateRegex(expr string) (*regexp.Regexp, error) {
+	validateRegexLock.Lock()
+	defer validateRegexLock.Unlock()
+	if compiled, ok := validateRegexCache[expr]; ok {
+		return compiled, nil
+	}
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	validateRegexCache[expr] = compiled
+	return compiled, nil
+}
+
+func isZeroValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.IsNil() || value.Len() == 0
+	case reflect.Interface, reflect.Ptr:
+		return value.IsNil()
+	}
+	return value.IsZero()
+}
@@ -3,7 +3,9 @@ package web
 import (
 	"html/template"
 	"net/http"
+	"os"
 	"sync"
+	"time"
 
 	"github.com/blend/go-sdk/bufferutil"
 	"github.com/blend/go-sdk/ex"
@@ -60,10 +62,24 @@ func NewViewCache(options ...ViewCacheOption) *ViewCache {
 type ViewCache struct {
 	sync.Mutex
 	LiveReload bool
-	FuncMap    template.FuncMap
-	Paths      []string
-	Literals   []string
-	Templates  *template.Template
+	// DevMode watches the view paths and re-parses the views when they change.
+	// If there are search paths, the views are instead re-parsed on every lookup.
+	// Parse errors are rendered as an error page with the file and line, and the last good views are kept.
+	DevMode bool
+	// WatchPollInterval is how often view paths are checked for changes in dev mode.
+	WatchPollInterval time.Duration
+	FuncMap           template.FuncMap
+	Paths             []string
+	Literals          []string
+	// SearchPaths are file systems views are loaded from, following the `layouts/` and `partials/` directory conventions.
+	SearchPaths []http.FileSystem
+	// Extension is the extension of views in the search paths, and defaults to `.html`.
+	Extension string
+	// DefaultLayout is the layout used by search path pages that don't declare one.
+	DefaultLayout string
+	Templates     *template.Template
+	// Pages are the search path pages by name, each parsed with its layout.
+	Pages      map[string]*template.Template
 	BufferPool *bufferutil.Pool
 
 	BadRequestTemplateName    string
@@ -71,18 +87,56 @@ type ViewCache struct {
 	NotFoundTemplateName      string
 	NotAuthorizedTemplateName string
 	StatusTemplateName        string
+
+	viewErr  *ViewError
+	watchers []*restartingWatcher
 }
 
 // Initialize caches templates by path.
+// In dev mode it also starts watching the view paths for changes.
 func (vc *ViewCache) Initialize() error {
 	vc.Lock()
 	defer vc.Unlock()
+	if vc.DevMode {
+		return vc.initializeDevMode()
+	}
 	if vc.Templates == nil && !vc.LiveReload {
 		return vc.initialize()
 	}
 	return nil
 }
 
+// Reload re-parses the views.
+// If the views fail to parse, the previous views are kept and the error is returned.
+// In dev mode the error is also rendered in place of views until the views parse.
+func (vc *ViewCache) Reload() error {
+	views, pages, err := vc.parseAll()
+
+	vc.Lock()
+	defer vc.Unlock()
+	if err != nil {
+		vc.viewErr = vc.newViewError(err)
+		return err
+	}
+	vc.Templates = views
+	vc.Pages = pages
+	vc.viewErr = nil
+	return nil
+}
+
+// StopWatching stops watching the view paths for changes.
+func (vc *ViewCache) StopWatching() {
+	vc.Lock()
+	watchers := vc.watchers
+	vc.watchers = nil
+	vc.Unlock()
+
+	// the lock isn't held while waiting, as the watchers take it to reload the views.
+	for _, watcher := range watchers {
+		watcher.stop()
+	}
+}
+
 // Parse parses the view tree.
 func (vc *ViewCache) Parse() (views *template.Template, err error) {
 	views = template.New("").Funcs(vc.FuncMap)
@@ -106,16 +160,48 @@ func (vc *ViewCache) Parse() (views *template.Template, err error) {
 	return
 }
 
+// ParsePages parses the search path pages with their layouts and the partials.
+// The partials are also added to the given views.
+func (vc *ViewCache) ParsePages(views *template.Template) (map[string]*template.Template, error) {
+	if len(vc.SearchPaths) == 0 {
+		return nil, nil
+	}
+	files, err := ReadViewFiles(vc.ExtensionOrDefault(), vc.SearchPaths...)
+	if err != nil {
+		return nil, err
+	}
+	return parseViewFiles(views, files, vc.DefaultLayout)
+}
+
+// ExtensionOrDefault returns the search path view extension or a default.
+func (vc *ViewCache) ExtensionOrDefault() string {
+	if vc.Extension != "" {
+		return vc.Extension
+	}
+	return DefaultViewExtension
+}
+
 // Lookup looks up a view.
 func (vc *ViewCache) Lookup(name string) (*template.Template, error) {
+	if vc.DevMode {
+		if len(vc.SearchPaths) > 0 {
+			// search paths aren't watched, so they're re-parsed on every lookup in dev mode.
+			_ = vc.Reload()
+		}
+		vc.Lock()
+		defer vc.Unlock()
+		if vc.viewErr != nil {
+			return nil, vc.viewErr
+		}
+	}
 	if vc.Templates == nil {
-		templates, err := vc.Parse()
+		templates, pages, err := vc.parseAll()
 		if err != nil {
 			return nil, err
 		}
-		return templates.Lookup(name), nil
+		return lookupView(templates, pages, name), nil
 	}
-	return vc.Templates.Lookup(name), nil
+	return lookupView(vc.Templates, vc.Pages, name), nil
 }
 
 // ----------------------------------------------------------------------
@@ -251,11 +337,26 @@ func (vc *ViewCache) AddLiterals(views ...string) {
 	vc.Literals = append(vc.Literals, views...)
 }
 
+// AddSearchPaths adds file systems to load views from.
+func (vc *ViewCache) AddSearchPaths(searchPaths ...http.FileSystem) {
+	vc.SearchPaths = append(vc.SearchPaths, searchPaths...)
+}
+
 // ----------------------------------------------------------------------
 // helpers
 // ----------------------------------------------------------------------
 
 func (vc *ViewCache) viewError(err error) Result {
+	if vc.DevMode {
+		viewErr := vc.newViewError(err)
+		return &ViewResult{
+			ViewName:   DefaultTemplateNameInternalError,
+			StatusCode: http.StatusInternalServerError,
+			ViewModel:  viewErr,
+			Template:   viewErrorTemplate,
+			Views:      vc,
+		}
+	}
 	t, _ := template.New("").Parse(DefaultTemplateInternalError)
 	return &ViewResult{
 		ViewName:   DefaultTemplateNameInternalError,
@@ -266,14 +367,85 @@ func (vc *ViewCache) viewError(err error) Result {
 	}
 }
 
+// newViewError returns a view error for an error, keeping errors that are already view errors.
+func (vc *ViewCache) newViewError(err error) *ViewError {
+	if typed, ok := err.(*ViewError); ok {
+		return typed
+	}
+	return NewViewError(err, vc.Paths...)
+}
+
 func (vc *ViewCache) initialize() error {
-	if len(vc.Paths) == 0 && len(vc.Literals) == 0 {
+	if len(vc.Paths) == 0 && len(vc.Literals) == 0 && len(vc.SearchPaths) == 0 {
 		return nil
 	}
-	views, err := vc.Parse()
+	views, pages, err := vc.parseAll()
 	if err != nil {
 		return err
 	}
 	vc.Templates = views
+	vc.Pages = pages
+	return nil
+}
+
+// parseAll parses the views and the search path pages.
+func (vc *ViewCache) parseAll() (views *template.Template, pages map[string]*template.Template, err error) {
+	views, err = vc.Parse()
+	if err != nil {
+		return
+	}
+	pages, err = vc.ParsePages(views)
+	return
+}
+
+// lookupView returns a search path page by name, falling back to the views.
+// Pages are executed as their layout, if they have one.
+func lookupView(views *template.Template, pages map[string]*template.Template, name string) *template.Template {
+	if page, ok := pages[name]; ok {
+		return page
+	}
+	return views.Lookup(name)
+}
+
+// initializeDevMode parses the views, keeping any parse error to render, and watches the view paths.
+func (vc *ViewCache) initializeDevMode() error {
+	if len(vc.watchers) > 0 {
+		return nil
+	}
+	if views, pages, err := vc.parseAll(); err != nil {
+		vc.viewErr = vc.newViewError(err)
+	} else {
+		vc.Templates = views
+		vc.Pages = pages
+	}
+
+	for _, path := range vc.Paths {
+		watcher := newRestartingWatcher(path, vc.WatchPollInterval, vc.onViewChanged)
+		watcher.OnError = vc.onViewWatchError(path)
+		// the views may have changed while the file wasn't watched.
+		watcher.OnRestart = func() { _ = vc.Reload() }
+		// errors are rendered in place of the views until the file can be watched again.
+		_ = watcher.start()
+		vc.watchers = append(vc.watchers, watcher)
+	}
+	return nil
+}
+
+// onViewChanged re-parses the views when a view file changes.
+func (vc *ViewCache) onViewChanged(f *os.File) error {
+	f.Close()
+	// parse errors are rendered in place of the views, so they shouldn't stop the watcher.
+	_ = vc.Reload()
 	return nil
 }
+
+// onViewWatchError renders the error a view path watcher exited with, e.g. because the file was removed.
+func (vc *ViewCache) onViewWatchError(path string) func(error) {
+	return func(err error) {
+		viewErr := NewViewError(err)
+		viewErr.Path = path
+		vc.Lock()
+		vc.viewErr = viewErr
+		vc.Unlock()
+	}
+}
@@ -1,6 +1,8 @@
 package web
 
 import (
+	"time"
+
 	"github.com/blend/go-sdk/configutil"
 	"github.com/blend/go-sdk/env"
 )
@@ -13,8 +15,20 @@ var (
 type ViewCacheConfig struct {
 	// LiveReload indicates if we should store compiled views in memory for re-use (default), or read them from disk each load.
 	LiveReload bool `json:"liveReload,omitempty" yaml:"liveReload,omitempty" env:"LIVE_RELOAD"`
+	// DevMode indicates if we should watch the view paths and re-parse views when they change.
+	// Views in search paths are re-parsed every time they're looked up.
+	// View errors are rendered as an error page with the file and line they occurred on.
+	DevMode bool `json:"devMode,omitempty" yaml:"devMode,omitempty" env:"VIEWS_DEV_MODE"`
+	// WatchPollInterval is how often view paths are checked for changes in dev mode.
+	WatchPollInterval time.Duration `json:"watchPollInterval,omitempty" yaml:"watchPollInterval,omitempty"`
 	// Paths are a list of view paths to include in the templates list.
 	Paths []string `json:"paths,omitempty" yaml:"paths,omitempty"`
+	// SearchPaths are directories to load views from, following the `layouts/` and `partials/` directory conventions.
+	SearchPaths []string `json:"searchPaths,omitempty" yaml:"searchPaths,omitempty"`
+	// Extension is the extension of views in the search paths.
+	Extension string `json:"extension,omitempty" yaml:"extension,omitempty"`
+	// DefaultLayout is the layout used by search path pages that don't declare one.
+	DefaultLayout string `json:"defaultLayout,omitempty" yaml:"defaultLayout,omitempty"`
 	// BufferPoolSize is the size of the re-usable buffer pool for rendering views.
 	BufferPoolSize int `json:"bufferPoolSize,omitempty" yaml:"bufferPoolSize,omitempty"`
 
@@ -1,6 +1,9 @@
 package web
 
-import "html/template"
+import (
+	"html/template"
+	"net/http"
+)
 
 // ViewCacheOption is an option for ViewCache.
 type ViewCacheOption func(*ViewCache) error
@@ -15,6 +18,28 @@ func OptViewCacheLiterals(literals ...string) ViewCacheOption {
 	return func(vc *ViewCache) error { vc.Literals = append(vc.Literals, literals...); return nil }
 }
 
+// OptViewCacheSearchPaths adds file systems to load views from.
+//
+// Views are named by their path relative to the search path without the extension, e.g. `users/index`.
+// Files in the `partials/` directory are available to every view, and files in the `layouts/` directory
+// are layouts that pages can use with a layout directive at the start of the page:
+//
+//	{{/* layout: base */}}
+//	{{ define "content" }}...{{ end }}
+func OptViewCacheSearchPaths(searchPaths ...http.FileSystem) ViewCacheOption {
+	return func(vc *ViewCache) error { vc.SearchPaths = append(vc.SearchPaths, searchPaths...); return nil }
+}
+
+// OptViewCacheExtension sets the extension of views in the search paths.
+func OptViewCacheExtension(extension string) ViewCacheOption {
+	return func(vc *ViewCache) error { vc.Extension = extension; return nil }
+}
+
+// OptViewCacheDefaultLayout sets the layout used by search path pages that don't declare one.
+func OptViewCacheDefaultLayout(layout string) ViewCacheOption {
+	return func(vc *ViewCache) error { vc.DefaultLayout = layout; return nil }
+}
+
 // OptViewCacheFuncMap sets the view cache func maps.
 func OptViewCacheFuncMap(funcMap template.FuncMap) ViewCacheOption {
 	return func(vc *ViewCache) error { vc.FuncMap = funcMap; return nil }
@@ -36,6 +61,11 @@ func OptViewCacheLiveReload(liveReload bool) ViewCacheOption {
 	return func(vc *ViewCache) error { vc.LiveReload = liveReload; return nil }
 }
 
+// OptViewCacheDevMode sets if the view cache watches its paths and renders view errors.
+func OptViewCacheDevMode(devMode bool) ViewCacheOption {
+	return func(vc *ViewCache) error { vc.DevMode = devMode; return nil }
+}
+
 // OptViewCacheInternalErrorTemplateName sets the internal error template name.
 func OptViewCacheInternalErrorTemplateName(name string) ViewCacheOption {
 	return func(vc *ViewCache) error { vc.InternalErrorTemplateName = name; return nil }
@@ -65,7 +95,14 @@ func OptViewCacheStatusTemplateName(name string) ViewCacheOption {
 func OptViewCacheConfig(cfg *ViewCacheConfig) ViewCacheOption {
 	return func(vc *ViewCache) error {
 		vc.Paths = cfg.Paths
+		for _, searchPath := range cfg.SearchPaths {
+			vc.SearchPaths = append(vc.SearchPaths, http.Dir(searchPath))
+		}
+		vc.Extension = cfg.Extension
+		vc.DefaultLayout = cfg.DefaultLayout
 		vc.LiveReload = cfg.LiveReload
+		vc.DevMode = cfg.DevMode
+		vc.WatchPollInterval = cfg.WatchPollInterval
 		vc.InternalErrorTemplateName = cfg.InternalErrorTemplateNameOrDefault()
 		vc.BadRequestTemplateName = cfg.BadRequestTemplateNameOrDefault()
 		vc.NotFoundTemplateName = cfg.NotFoundTemplateNameOrDefault()
@@ -0,0 +1,15 @@
+//go:build go1.16
+// +build go1.16
+
+package web
+
+import (
+	"io/fs"
+	"net/http"
+)
+
+// OptViewCacheFS adds a file system, e.g. an `embed.FS`, to load views from.
+// It is a search path, and follows the same directory conventions as `OptViewCacheSearchPaths`.
+func OptViewCacheFS(fsys fs.FS) ViewCacheOption {
+	return func(vc *ViewCache) error { vc.SearchPaths = append(vc.SearchPaths, http.FS(fsys)); return nil }
+}
@@ -0,0 +1,29 @@
+//go:build go1.16
+// +build go1.16
+
+package web
+
+import (
+	"bytes"
+	"testing"
+	"testing/fstest"
+
+	"github.com/blend/go-sdk/assert"
+	"github.com/blend/go-sdk/webutil"
+)
+
+func TestOptViewCacheFS(t *testing.T) {
+	assert := assert.New(t)
+
+	vc := NewViewCache(OptViewCacheFS(fstest.MapFS{
+		"layouts/base.html": &fstest.MapFile{Data: []byte(`<main>{{ block "content" . }}{{ end }}</main>`)},
+		"index.html":        &fstest.MapFile{Data: []byte(`{{/* layout: base */}}{{ define "content" }}{{ .ViewModel }}{{ end }}`)},
+	}))
+	assert.Nil(vc.Initialize())
+
+	buffer := new(bytes.Buffer)
+	ctx := MockCtx("GET", "/")
+	ctx.Response = webutil.NewMockResponse(buffer)
+	assert.Nil(vc.View("index", "embedded").Render(ctx))
+	assert.Equal("<main>embedded</main>", buffer.String())
+}
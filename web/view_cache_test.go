@@ -2,11 +2,17 @@ package web
 
 import (
 	"bytes"
+	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/blend/go-sdk/assert"
 	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/webutil"
 )
 
 func TestViewCacheProperties(t *testing.T) {
@@ -220,3 +226,238 @@ func TestViewCacheViewError(t *testing.T) {
 	assert.NotNil(vcr.Template)
 	assert.NotNil(vcr.Views)
 }
+
+func TestViewCacheDevMode(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "view_cache")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	viewPath := filepath.Join(tempDir, "index.html")
+	assert.Nil(ioutil.WriteFile(viewPath, []byte(`{{ define "index" }}good{{ end }}`), 0600))
+
+	vc := NewViewCache(OptViewCacheConfig(&ViewCacheConfig{
+		DevMode:           true,
+		WatchPollInterval: time.Millisecond,
+		Paths:             []string{viewPath},
+	}))
+	assert.Nil(vc.Initialize())
+	defer vc.StopWatching()
+
+	render := func() (int, string) {
+		buffer := new(bytes.Buffer)
+		ctx := MockCtx("GET", "/")
+		ctx.Response = webutil.NewMockResponse(buffer)
+		assert.Nil(vc.View("index", nil).Render(ctx))
+		return ctx.Response.StatusCode(), buffer.String()
+	}
+	waitFor := func(contents string) (statusCode int, body string) {
+		for attempt := 0; attempt < 500; attempt++ {
+			if statusCode, body = render(); strings.Contains(body, contents) {
+				return
+			}
+			time.Sleep(2 * time.Millisecond)
+		}
+		return
+	}
+	touch := func(contents string) {
+		assert.Nil(ioutil.WriteFile(viewPath, []byte(contents), 0600))
+		modTime := time.Now().Add(time.Second)
+		assert.Nil(os.Chtimes(viewPath, modTime, modTime))
+	}
+
+	statusCode, body := render()
+	assert.Equal(http.StatusOK, statusCode)
+	assert.Equal("good", body)
+	lastGood := vc.Templates
+
+	touch("{{ define \"index\" }}\nbad {{ .Foo }\n{{ end }}")
+	statusCode, body = waitFor("View Error")
+	assert.Equal(http.StatusInternalServerError, statusCode)
+	assert.Contains(body, viewPath+":2")
+	assert.Contains(body, "bad {{ .Foo }")
+	assert.True(lastGood == vc.Templates, "the last good views should be kept")
+
+	touch(`{{ define "index" }}fixed{{ end }}`)
+	statusCode, body = waitFor("fixed")
+	assert.Equal(http.StatusOK, statusCode)
+	assert.Equal("fixed", body)
+}
+
+func TestViewCacheDevModeFileRemoved(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "view_cache")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	viewPath := filepath.Join(tempDir, "index.html")
+	assert.Nil(ioutil.WriteFile(viewPath, []byte(`{{ define "index" }}good{{ end }}`), 0600))
+
+	vc := NewViewCache(OptViewCacheConfig(&ViewCacheConfig{
+		DevMode:           true,
+		WatchPollInterval: time.Millisecond,
+		Paths:             []string{viewPath},
+	}))
+	assert.Nil(vc.Initialize())
+
+	render := func() string {
+		buffer := new(bytes.Buffer)
+		ctx := MockCtx("GET", "/")
+		ctx.Response = webutil.NewMockResponse(buffer)
+		assert.Nil(vc.View("index", nil).Render(ctx))
+		return buffer.String()
+	}
+	waitFor := func(contents string) (body string) {
+		for attempt := 0; attempt < 500; attempt++ {
+			if body = render(); strings.Contains(body, contents) {
+				return
+			}
+			time.Sleep(2 * time.Millisecond)
+		}
+		return
+	}
+
+	// editors that save by renaming a new file over the view remove it first.
+	assert.Nil(os.Remove(viewPath))
+	assert.Contains(waitFor("View Error"), viewPath)
+
+	replacement := filepath.Join(tempDir, "index.html.tmp")
+	assert.Nil(ioutil.WriteFile(replacement, []byte(`{{ define "index" }}replaced{{ end }}`), 0600))
+	assert.Nil(os.Rename(replacement, viewPath))
+	assert.Equal("replaced", waitFor("replaced"), "the view should be watched again once it's replaced")
+
+	assert.Nil(os.Remove(viewPath))
+	waitFor("View Error")
+	stopped := make(chan struct{})
+	go func() {
+		vc.StopWatching()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		assert.FailNow("stop watching should not block after a view file is removed")
+	}
+}
+
+func TestNewViewError(t *testing.T) {
+	assert := assert.New(t)
+
+	viewErr := NewViewError(ex.New(`template: index.html:12: unexpected "}" in operand`), "views/index.html")
+	assert.Equal("views/index.html", viewErr.Path)
+	assert.Equal(12, viewErr.Line)
+	assert.Equal(`unexpected "}" in operand`, viewErr.Message)
+	assert.Equal(`views/index.html:12: unexpected "}" in operand`, viewErr.Error())
+
+	viewErr = NewViewError(ex.New("not a template error"))
+	assert.Empty(viewErr.Path)
+	assert.Equal("not a template error", viewErr.Error())
+}
+
+func TestViewCacheSearchPaths(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "view_cache")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	files := map[string]string{
+		"layouts/base.html":  `<main>{{ block "title" . }}default{{ end }}|{{ block "content" . }}{{ end }}</main>`,
+		"partials/nav.html":  `<nav>{{ .ViewModel }}</nav>`,
+		"users/index.html":   "{{/* layout: base */}}\n" + `{{ define "title" }}users{{ end }}{{ define "content" }}{{ template "partials/nav" . }}{{ end }}`,
+		"about.html":         `{{ define "content" }}about{{ end }}`,
+		"plain.html":         `{{/* layout: none */}}plain`,
+		"ignored/readme.txt": `not a view`,
+	}
+	for name, contents := range files {
+		assert.Nil(os.MkdirAll(filepath.Join(tempDir, filepath.Dir(name)), 0700))
+		assert.Nil(ioutil.WriteFile(filepath.Join(tempDir, name), []byte(contents), 0600))
+	}
+
+	vc := NewViewCache(
+		OptViewCacheSearchPaths(http.Dir(tempDir)),
+		OptViewCacheDefaultLayout("base"),
+		OptViewCacheLiterals(`{{ define "literal" }}{{ template "partials/nav" . }}{{ end }}`),
+	)
+	assert.Nil(vc.Initialize())
+
+	render := func(viewName string, viewModel interface{}) string {
+		buffer := new(bytes.Buffer)
+		ctx := MockCtx("GET", "/")
+		ctx.Response = webutil.NewMockResponse(buffer)
+		assert.Nil(vc.View(viewName, viewModel).Render(ctx))
+		return buffer.String()
+	}
+	assert.Equal("<main>users|<nav>foo</nav></main>", render("users/index", "foo"))
+	assert.Equal("<main>default|about</main>", render("about", nil))
+	assert.Equal("plain", render("plain", nil))
+	assert.Equal("<nav>bar</nav>", render("literal", "bar"))
+	assert.Nil(vc.Pages["ignored/readme"])
+
+	missing := NewViewCache(OptViewCacheSearchPaths(http.Dir(tempDir)), OptViewCacheDefaultLayout("missing"))
+	assert.True(ex.Is(missing.Initialize(), ErrViewLayoutNotFound))
+}
+
+func TestViewCacheSearchPathsDevMode(t *testing.T) {
+	assert := assert.New(t)
+
+	tempDir, err := ioutil.TempDir("", "view_cache")
+	assert.Nil(err)
+	defer os.RemoveAll(tempDir)
+
+	viewPath := filepath.Join(tempDir, "index.html")
+	assert.Nil(ioutil.WriteFile(viewPath, []byte(`good`), 0600))
+
+	vc := NewViewCache(OptViewCacheConfig(&ViewCacheConfig{
+		DevMode:     true,
+		SearchPaths: []string{tempDir},
+	}))
+	assert.Nil(vc.Initialize())
+	defer vc.StopWatching()
+
+	render := func() (int, string) {
+		buffer := new(bytes.Buffer)
+		ctx := MockCtx("GET", "/")
+		ctx.Response = webutil.NewMockResponse(buffer)
+		assert.Nil(vc.View("index", nil).Render(ctx))
+		return ctx.Response.StatusCode(), buffer.String()
+	}
+
+	statusCode, body := render()
+	assert.Equal(http.StatusOK, statusCode)
+	assert.Equal("good", body)
+
+	assert.Nil(ioutil.WriteFile(viewPath, []byte("first\nbad {{ .Foo }\nlast"), 0600))
+	statusCode, body = render()
+	assert.Equal(http.StatusInternalServerError, statusCode)
+	assert.Contains(body, viewPath+":2")
+	assert.Contains(body, "bad {{ .Foo }")
+
+	assert.Nil(ioutil.WriteFile(viewPath, []byte(`fixed`), 0600))
+	statusCode, body = render()
+	assert.Equal(http.StatusOK, statusCode)
+	assert.Equal("fixed", body)
+}
+
+func TestReadViewFilesPrecedence(t *testing.T) {
+	assert := assert.New(t)
+
+	first, err := ioutil.TempDir("", "view_cache")
+	assert.Nil(err)
+	defer os.RemoveAll(first)
+	second, err := ioutil.TempDir("", "view_cache")
+	assert.Nil(err)
+	defer os.RemoveAll(second)
+
+	assert.Nil(ioutil.WriteFile(filepath.Join(first, "index.html"), []byte("first"), 0600))
+	assert.Nil(ioutil.WriteFile(filepath.Join(second, "index.html"), []byte("second"), 0600))
+	assert.Nil(ioutil.WriteFile(filepath.Join(second, "other.html"), []byte("other"), 0600))
+
+	files, err := ReadViewFiles(DefaultViewExtension, http.Dir(first), http.Dir(second))
+	assert.Nil(err)
+	assert.Len(files, 2)
+	assert.Equal(ViewFile{Name: "index", Path: filepath.Join(first, "index.html"), Contents: "first"}, files[0])
+	assert.Equal(ViewFile{Name: "other", Path: filepath.Join(second, "other.html"), Contents: "other"}, files[1])
+}
@@ -0,0 +1,112 @@
+package web
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+const (
+	// ViewErrorSourceContext is the number of lines shown either side of the line a view error occurred on.
+	ViewErrorSourceContext = 3
+
+	// DefaultTemplateViewError is the development mode error page for template parse and execution errors.
+	DefaultTemplateViewError = `<html><head><style>
+body { font-family: sans-serif; margin: 2em; }
+pre { background: #f6f6f6; padding: 1em; overflow-x: auto; }
+.error { background: #fdd; font-weight: bold; }
+</style></head><body>
+<h3>View Error</h3>
+<p>{{ if .ViewModel.Path }}<code>{{ .ViewModel.Path }}{{ if .ViewModel.Line }}:{{ .ViewModel.Line }}{{ end }}</code> {{ end }}{{ .ViewModel.Message }}</p>
+{{ if .ViewModel.Source }}<pre>{{ range .ViewModel.Source }}<span{{ if .IsError }} class="error"{{ end }}>{{ printf "%4d" .Number }} | {{ .Text }}</span>
+{{ end }}</pre>{{ end }}
+</body></html>`
+)
+
+// viewErrorTemplate is the parsed development mode error page.
+var viewErrorTemplate = template.Must(template.New("view_error").Parse(DefaultTemplateViewError))
+
+// viewErrorExpr matches the file and line in template errors, e.g. `template: index.html:3: unexpected "}" in operand`.
+var viewErrorExpr = regexp.MustCompile(`^template: ([^:]+):(\d+):(?:\d+:)?\s*(.*)$`)
+
+// NewViewError returns a view error for a template parse or execution error.
+// The file the error occurred in is resolved from the given template paths by name.
+func NewViewError(err error, paths ...string) *ViewError {
+	message := ex.ErrClass(err)
+	if message == "" {
+		message = err.Error()
+	}
+	viewErr := &ViewError{Err: err, Message: message}
+
+	matches := viewErrorExpr.FindStringSubmatch(message)
+	if len(matches) != 4 {
+		return viewErr
+	}
+	viewErr.Path = matches[1]
+	viewErr.Line, _ = strconv.Atoi(matches[2])
+	viewErr.Message = matches[3]
+	for _, path := range paths {
+		if filepath.Base(path) == matches[1] {
+			viewErr.Path = path
+			viewErr.Source = readViewSource(path, viewErr.Line)
+			break
+		}
+	}
+	return viewErr
+}
+
+// ViewError is a template error with the file and line it occurred on.
+type ViewError struct {
+	Err     error
+	Path    string
+	Line    int
+	Message string
+	Source  []ViewSourceLine
+}
+
+// Error implements error.
+func (ve *ViewError) Error() string {
+	if ve.Path == "" {
+		return ve.Message
+	}
+	return fmt.Sprintf("%s:%d: %s", ve.Path, ve.Line, ve.Message)
+}
+
+// ViewSourceLine is a line of a template shown with a view error.
+type ViewSourceLine struct {
+	Number  int
+	Text    string
+	IsError bool
+}
+
+// readViewSource reads the lines of a file around a given line.
+func readViewSource(path string, line int) (output []ViewSourceLine) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	return viewSource(f, line)
+}
+
+// viewSource reads the lines of a template around a given line.
+func viewSource(r io.Reader, line int) (output []ViewSourceLine) {
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		if number < line-ViewErrorSourceContext {
+			continue
+		}
+		if number > line+ViewErrorSourceContext {
+			break
+		}
+		output = append(output, ViewSourceLine{Number: number, Text: scanner.Text(), IsError: number == line})
+	}
+	return
+}
@@ -0,0 +1,190 @@
+package web
+
+import (
+	"html/template"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+const (
+	// ViewLayoutsDir is the search path directory layouts are loaded from.
+	ViewLayoutsDir = "layouts"
+	// ViewPartialsDir is the search path directory partials are loaded from.
+	ViewPartialsDir = "partials"
+	// ViewLayoutNone is the layout name a page uses to opt out of the default layout.
+	ViewLayoutNone = "none"
+	// DefaultViewExtension is the default extension of view files in search paths.
+	DefaultViewExtension = ".html"
+)
+
+// viewLayoutExpr matches the layout directive at the start of a page, e.g. `{{/* layout: base */}}`.
+var viewLayoutExpr = regexp.MustCompile(`^\s*\{\{-?\s*/\*\s*layout:\s*([^\s*]+)\s*\*/\s*-?\}\}`)
+
+// ViewFile is a view loaded from a search path.
+type ViewFile struct {
+	// Name is the path of the file relative to its search path without the extension, e.g. `users/index`.
+	Name string
+	// Path is the path of the file on disk for `http.Dir` search paths, and within its search path otherwise.
+	Path     string
+	Contents string
+}
+
+// Layout returns the layout a page declares with a layout directive, or an empty string.
+func (vf ViewFile) Layout() string {
+	if matches := viewLayoutExpr.FindStringSubmatch(vf.Contents); len(matches) == 2 {
+		return matches[1]
+	}
+	return ""
+}
+
+// ReadViewFiles reads the view files with a given extension from a list of search paths.
+// Files in earlier search paths take precedence over files with the same name in later search paths.
+func ReadViewFiles(extension string, searchPaths ...http.FileSystem) ([]ViewFile, error) {
+	files := map[string]ViewFile{}
+	for _, searchPath := range searchPaths {
+		if err := readViewFiles(searchPath, "/", extension, files); err != nil {
+			return nil, err
+		}
+	}
+	output := make([]ViewFile, 0, len(files))
+	for _, file := range files {
+		output = append(output, file)
+	}
+	sort.Slice(output, func(i, j int) bool { return output[i].Name < output[j].Name })
+	return output, nil
+}
+
+func readViewFiles(searchPath http.FileSystem, dir, extension string, files map[string]ViewFile) error {
+	f, err := searchPath.Open(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return ex.New(err, ex.OptMessagef("dir: %s", dir))
+	}
+	infos, err := f.Readdir(-1)
+	f.Close()
+	if err != nil {
+		return ex.New(err, ex.OptMessagef("dir: %s", dir))
+	}
+
+	for _, info := range infos {
+		filePath := path.Join(dir, info.Name())
+		if info.IsDir() {
+			if err := readViewFiles(searchPath, filePath, extension, files); err != nil {
+				return err
+			}
+			continue
+		}
+		if !strings.HasSuffix(filePath, extension) {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimSuffix(filePath, extension), "/")
+		if _, ok := files[name]; ok {
+			continue
+		}
+		contents, err := readViewFile(searchPath, filePath)
+		if err != nil {
+			return err
+		}
+		files[name] = ViewFile{Name: name, Path: viewFilePath(searchPath, filePath), Contents: contents}
+	}
+	return nil
+}
+
+func readViewFile(searchPath http.FileSystem, filePath string) (string, error) {
+	f, err := searchPath.Open(filePath)
+	if err != nil {
+		return "", ex.New(err, ex.OptMessagef("path: %s", filePath))
+	}
+	defer f.Close()
+	contents, err := ioutil.ReadAll(f)
+	if err != nil {
+		return "", ex.New(err, ex.OptMessagef("path: %s", filePath))
+	}
+	return string(contents), nil
+}
+
+// viewFilePath returns the path of a search path file on disk if the search path is a directory.
+func viewFilePath(searchPath http.FileSystem, filePath string) string {
+	if dir, ok := searchPath.(http.Dir); ok {
+		return filepath.Join(string(dir), filepath.FromSlash(filePath))
+	}
+	return filePath
+}
+
+// newViewFileError returns a view error for a template parse error in a search path file.
+func newViewFileError(err error, file ViewFile) *ViewError {
+	viewErr := NewViewError(ex.New(err))
+	viewErr.Path = file.Path
+	if viewErr.Line > 0 {
+		viewErr.Source = viewSource(strings.NewReader(file.Contents), viewErr.Line)
+	}
+	return viewErr
+}
+
+// parseViewFiles parses the search path views.
+//
+// Partials (`partials/*`) are added to the shared views so they can be used by every view.
+// Each page (any other file outside `layouts/`) is parsed into its own copy of the shared views,
+// along with its layout if it has one, so pages can override the same layout blocks:
+//
+//	layouts/base.html:	<html><body>{{ block "content" . }}{{ end }}</body></html>
+//	users/index.html:	{{/* layout: base */}}{{ define "content" }}users{{ end }}
+//
+// Rendering the `users/index` view then executes `layouts/base` with the page's blocks.
+func parseViewFiles(views *template.Template, files []ViewFile, defaultLayout string) (map[string]*template.Template, error) {
+	layouts := map[string]ViewFile{}
+	var pages []ViewFile
+	for _, file := range files {
+		switch {
+		case strings.HasPrefix(file.Name, ViewPartialsDir+"/"):
+			if _, err := views.New(file.Name).Parse(file.Contents); err != nil {
+				return nil, newViewFileError(err, file)
+			}
+		case strings.HasPrefix(file.Name, ViewLayoutsDir+"/"):
+			layouts[strings.TrimPrefix(file.Name, ViewLayoutsDir+"/")] = file
+		default:
+			pages = append(pages, file)
+		}
+	}
+
+	output := make(map[string]*template.Template, len(pages))
+	for _, page := range pages {
+		set, err := views.Clone()
+		if err != nil {
+			return nil, ex.New(err)
+		}
+
+		layoutName := page.Layout()
+		if layoutName == "" {
+			layoutName = defaultLayout
+		}
+		if layoutName == "" || layoutName == ViewLayoutNone {
+			if output[page.Name], err = set.New(page.Name).Parse(page.Contents); err != nil {
+				return nil, newViewFileError(err, page)
+			}
+			continue
+		}
+
+		layout, ok := layouts[layoutName]
+		if !ok {
+			return nil, ex.New(ErrViewLayoutNotFound, ex.OptMessagef("view: %s, layout: %s", page.Name, layoutName))
+		}
+		if output[page.Name], err = set.New(layout.Name).Parse(layout.Contents); err != nil {
+			return nil, newViewFileError(err, layout)
+		}
+		if _, err = set.New(page.Name).Parse(page.Contents); err != nil {
+			return nil, newViewFileError(err, page)
+		}
+	}
+	return output, nil
+}
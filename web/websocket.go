@@ -0,0 +1,365 @@
+package web
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blend/go-sdk/ex"
+	"github.com/blend/go-sdk/logger"
+)
+
+// WebSocket constants.
+const (
+	// HeaderUpgrade is the upgrade header.
+	HeaderUpgrade = "Upgrade"
+	// HeaderSecWebSocketKey is the handshake key header.
+	HeaderSecWebSocketKey = "Sec-WebSocket-Key"
+	// HeaderSecWebSocketAccept is the handshake accept header.
+	HeaderSecWebSocketAccept = "Sec-WebSocket-Accept"
+	// HeaderSecWebSocketVersion is the protocol version header.
+	HeaderSecWebSocketVersion = "Sec-WebSocket-Version"
+	// HeaderSecWebSocketProtocol is the subprotocol header.
+	HeaderSecWebSocketProtocol = "Sec-WebSocket-Protocol"
+
+	// WebSocketVersion is the only supported websocket protocol version.
+	WebSocketVersion = "13"
+
+	// DefaultWebSocketPingInterval is the default interval between keepalive pings.
+	DefaultWebSocketPingInterval = 30 * time.Second
+	// DefaultWebSocketPongTimeout is how long to wait to read anything from the peer before closing the connection.
+	DefaultWebSocketPongTimeout = 60 * time.Second
+	// DefaultWebSocketWriteTimeout is the default timeout for writing a single frame.
+	DefaultWebSocketWriteTimeout = 10 * time.Second
+	// DefaultWebSocketMaxMessageBytes is the default maximum message size.
+	DefaultWebSocketMaxMessageBytes int64 = 1 << 20
+
+	webSocketAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+)
+
+const (
+	// ErrWebSocketHandshake is returned if the request is not a valid websocket handshake.
+	ErrWebSocketHandshake ex.Class = "invalid websocket handshake"
+	// ErrWebSocketVersion is returned if the client requests an unsupported protocol version.
+	ErrWebSocketVersion ex.Class = "unsupported websocket version"
+	// ErrWebSocketOrigin is returned if the request origin is not allowed.
+	ErrWebSocketOrigin ex.Class = "websocket origin not allowed"
+)
+
+// WebSocketHandler is a handler for an upgraded websocket connection.
+// The connection is closed when the handler returns; if the handler returns an error
+// it is logged and the connection is closed with an internal error code.
+type WebSocketHandler func(*Ctx, *WebSocketConn) error
+
+// WS registers a websocket handler for a given path.
+/*
+The handshake is a normal GET request, so app and route middleware (sessions,
+logging, tracing etc.) apply to it before the connection is upgraded.
+
+Open connections are sent a going away close message when the app is stopped,
+and are forcibly closed if their handlers have not returned by the end of the shutdown grace period.
+*/
+func (a *App) WS(path string, handler WebSocketHandler, middleware ...Middleware) {
+	a.GET(path, a.WebSocketAction(handler), middleware...)
+}
+
+// WebSocketAction returns an action that upgrades the request with the app's upgrader and runs the handler.
+func (a *App) WebSocketAction(handler WebSocketHandler) Action {
+	return func(ctx *Ctx) Result {
+		if a.websockets.isClosing() {
+			return ctx.DefaultProvider.Status(http.StatusServiceUnavailable)
+		}
+
+		conn, err := a.WebSocketUpgrader.Upgrade(ctx)
+		if err != nil {
+			return webSocketErrorResult(ctx, err)
+		}
+		if !a.websockets.add(conn) {
+			conn.Close(WebSocketCloseGoingAway, "")
+			conn.conn.Close()
+			return nil
+		}
+		defer a.websockets.remove(conn)
+		defer conn.Shutdown()
+
+		if interval := a.WebSocketUpgrader.PingIntervalOrDefault(); interval > 0 {
+			go conn.keepalive(interval)
+		}
+		if err := handler(ctx, conn); err != nil && !IsErrWebSocketClosed(err) {
+			conn.Close(WebSocketCloseInternalError, "")
+			if a.Log != nil {
+				a.Log.Trigger(ctx.Context(), logger.NewErrorEvent(logger.Error, err, logger.OptErrorEventState(ctx.Request)))
+			}
+		}
+		return nil
+	}
+}
+
+// WS registers a websocket handler for a given path relative to the group.
+func (rg *RouteGroup) WS(path string, handler WebSocketHandler, middleware ...Middleware) {
+	rg.GET(path, rg.App.WebSocketAction(handler), middleware...)
+}
+
+// WebSocketUpgrader upgrades requests to websocket connections.
+type WebSocketUpgrader struct {
+	// Subprotocols are the supported subprotocols in order of preference.
+	Subprotocols []string
+	// CheckOrigin returns if the request origin is allowed.
+	// If unset, requests with an origin header must match the request host.
+	CheckOrigin func(*Ctx) bool
+	// PingInterval is the interval keepalive pings are sent on; a negative value disables pings.
+	PingInterval time.Duration
+	// PongTimeout is how long to wait to read anything from the peer before closing the connection.
+	PongTimeout time.Duration
+	// WriteTimeout is the timeout for writing a single frame.
+	WriteTimeout time.Duration
+	// MaxMessageBytes is the maximum size of a message read from the peer.
+	MaxMessageBytes int64
+}
+
+// PingIntervalOrDefault returns the ping interval or a default.
+func (wsu WebSocketUpgrader) PingIntervalOrDefault() time.Duration {
+	if wsu.PingInterval != 0 {
+		return wsu.PingInterval
+	}
+	return DefaultWebSocketPingInterval
+}
+
+// PongTimeoutOrDefault returns the pong timeout or a default.
+func (wsu WebSocketUpgrader) PongTimeoutOrDefault() time.Duration {
+	if wsu.PongTimeout > 0 {
+		return wsu.PongTimeout
+	}
+	return DefaultWebSocketPongTimeout
+}
+
+// WriteTimeoutOrDefault returns the write timeout or a default.
+func (wsu WebSocketUpgrader) WriteTimeoutOrDefault() time.Duration {
+	if wsu.WriteTimeout > 0 {
+		return wsu.WriteTimeout
+	}
+	return DefaultWebSocketWriteTimeout
+}
+
+// MaxMessageBytesOrDefault returns the max message size or a default.
+func (wsu WebSocketUpgrader) MaxMessageBytesOrDefault() int64 {
+	if wsu.MaxMessageBytes > 0 {
+		return wsu.MaxMessageBytes
+	}
+	return DefaultWebSocketMaxMessageBytes
+}
+
+// Upgrade validates the websocket handshake, hijacks the connection and writes the handshake response.
+func (wsu WebSocketUpgrader) Upgrade(ctx *Ctx) (*WebSocketConn, error) {
+	req := ctx.Request
+	if req.Method != http.MethodGet {
+		return nil, ex.New(ErrWebSocketHandshake, ex.OptMessage("method must be GET"))
+	}
+	if !headerContainsToken(req.Header, HeaderConnection, "upgrade") {
+		return nil, ex.New(ErrWebSocketHandshake, ex.OptMessage("missing connection upgrade header"))
+	}
+	if !headerContainsToken(req.Header, HeaderUpgrade, "websocket") {
+		return nil, ex.New(ErrWebSocketHandshake, ex.OptMessage("missing websocket upgrade header"))
+	}
+	if req.Header.Get(HeaderSecWebSocketVersion) != WebSocketVersion {
+		return nil, ex.New(ErrWebSocketVersion, ex.OptMessagef("version: %s", req.Header.Get(HeaderSecWebSocketVersion)))
+	}
+	key := req.Header.Get(HeaderSecWebSocketKey)
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return nil, ex.New(ErrWebSocketHandshake, ex.OptMessage("invalid websocket key"))
+	}
+	checkOrigin := wsu.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = webSocketSameOrigin
+	}
+	if !checkOrigin(ctx) {
+		return nil, ex.New(ErrWebSocketOrigin, ex.OptMessagef("origin: %s", req.Header.Get(HeaderOrigin)))
+	}
+
+	hijacker, ok := ctx.Response.(http.Hijacker)
+	if !ok {
+		return nil, ex.New(ErrHijackUnsupported)
+	}
+	netConn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// clear any deadlines set by the server; the connection manages its own.
+	netConn.SetDeadline(time.Time{})
+
+	subprotocol := wsu.negotiateSubprotocol(req)
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		HeaderSecWebSocketAccept + ": " + webSocketAccept(key) + "\r\n"
+	if subprotocol != "" {
+		response += HeaderSecWebSocketProtocol + ": " + subprotocol + "\r\n"
+	}
+	response += "\r\n"
+
+	netConn.SetWriteDeadline(time.Now().Add(wsu.WriteTimeoutOrDefault()))
+	if _, err := netConn.Write([]byte(response)); err != nil {
+		netConn.Close()
+		return nil, ex.New(err)
+	}
+
+	conn := newWebSocketConn(netConn, brw.Reader, true, wsu)
+	conn.Subprotocol = subprotocol
+	return conn, nil
+}
+
+func (wsu WebSocketUpgrader) negotiateSubprotocol(req *http.Request) string {
+	if len(wsu.Subprotocols) == 0 {
+		return ""
+	}
+	var requested []string
+	for _, value := range req.Header[HeaderSecWebSocketProtocol] {
+		for _, protocol := range strings.Split(value, ",") {
+			requested = append(requested, strings.TrimSpace(protocol))
+		}
+	}
+	for _, supported := range wsu.Subprotocols {
+		for _, protocol := range requested {
+			if protocol == supported {
+				return supported
+			}
+		}
+	}
+	return ""
+}
+
+// webSocketAccept computes the accept header value for a given handshake key.
+func webSocketAccept(key string) string {
+	hash := sha1.New()
+	hash.Write([]byte(key + webSocketAcceptGUID))
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil))
+}
+
+// webSocketSameOrigin allows requests without an origin, or with an origin that matches the request host.
+func webSocketSameOrigin(ctx *Ctx) bool {
+	origin := ctx.Request.Header.Get(HeaderOrigin)
+	if origin == "" {
+		return true
+	}
+	parsed, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(parsed.Host, ctx.Request.Host)
+}
+
+// webSocketErrorResult maps an upgrade error to a result.
+func webSocketErrorResult(ctx *Ctx, err error) Result {
+	switch {
+	case ex.Is(err, ErrWebSocketVersion):
+		ctx.Response.Header().Set(HeaderSecWebSocketVersion, WebSocketVersion)
+		return ctx.DefaultProvider.Status(http.StatusUpgradeRequired, err)
+	case ex.Is(err, ErrWebSocketOrigin):
+		return ctx.DefaultProvider.Status(http.StatusForbidden, err)
+	case ex.Is(err, ErrWebSocketHandshake):
+		return ctx.DefaultProvider.BadRequest(err)
+	}
+	return ctx.DefaultProvider.InternalError(err)
+}
+
+func headerContainsToken(header http.Header, key, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(key)] {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// webSocketTracker tracks open websocket connections so they can be closed when the app stops.
+type webSocketTracker struct {
+	sync.Mutex
+	closing bool
+	conns   map[*WebSocketConn]struct{}
+	wg      sync.WaitGroup
+}
+
+func (wst *webSocketTracker) isClosing() bool {
+	wst.Lock()
+	defer wst.Unlock()
+	return wst.closing
+}
+
+func (wst *webSocketTracker) add(conn *WebSocketConn) bool {
+	wst.Lock()
+	defer wst.Unlock()
+	if wst.closing {
+		return false
+	}
+	if wst.conns == nil {
+		wst.conns = map[*WebSocketConn]struct{}{}
+	}
+	wst.conns[conn] = struct{}{}
+	wst.wg.Add(1)
+	return true
+}
+
+func (wst *webSocketTracker) remove(conn *WebSocketConn) {
+	wst.Lock()
+	defer wst.Unlock()
+	if _, ok := wst.conns[conn]; ok {
+		delete(wst.conns, conn)
+		wst.wg.Done()
+	}
+}
+
+// shutdown sends a going away close message to every open connection and waits for
+// their handlers to return, forcibly closing any connections left when the context is done.
+// It does not wait for handlers to return after forcibly closing their connections.
+func (wst *webSocketTracker) shutdown(ctx context.Context) {
+	for _, conn := range wst.startClosing() {
+		conn.Close(WebSocketCloseGoingAway, "server shutting down")
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wst.wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		for _, conn := range wst.open() {
+			conn.conn.Close()
+		}
+	}
+
+	wst.Lock()
+	wst.closing = false
+	wst.Unlock()
+}
+
+// startClosing stops new connections from being added and returns the open connections.
+func (wst *webSocketTracker) startClosing() []*WebSocketConn {
+	wst.Lock()
+	defer wst.Unlock()
+	wst.closing = true
+	return wst.openUnsafe()
+}
+
+// open returns the open connections.
+func (wst *webSocketTracker) open() []*WebSocketConn {
+	wst.Lock()
+	defer wst.Unlock()
+	return wst.openUnsafe()
+}
+
+func (wst *webSocketTracker) openUnsafe() []*WebSocketConn {
+	conns := make([]*WebSocketConn, 0, len(wst.conns))
+	for conn := range wst.conns {
+		conns = append(conns, conn)
+	}
+	return conns
+}
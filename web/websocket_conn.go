@@ -0,0 +1,424 @@
+package web
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/blend/go-sdk/ex"
+)
+
+// WebSocket message types (frame opcodes).
+const (
+	WebSocketMessageContinuation = 0x0
+	WebSocketMessageText         = 0x1
+	WebSocketMessageBinary       = 0x2
+	WebSocketMessageClose        = 0x8
+	WebSocketMessagePing         = 0x9
+	WebSocketMessagePong         = 0xA
+)
+
+// WebSocket close codes.
+const (
+	WebSocketCloseNormal          = 1000
+	WebSocketCloseGoingAway       = 1001
+	WebSocketCloseProtocolError   = 1002
+	WebSocketCloseUnsupportedData = 1003
+	WebSocketCloseNoStatus        = 1005
+	WebSocketCloseInvalidPayload  = 1007
+	WebSocketClosePolicyViolation = 1008
+	WebSocketCloseMessageTooBig   = 1009
+	WebSocketCloseInternalError   = 1011
+)
+
+const (
+	// ErrWebSocketClosed is returned from reads and writes after the connection is closed.
+	ErrWebSocketClosed ex.Class = "websocket closed"
+	// ErrWebSocketProtocol is returned if the peer violates the websocket protocol.
+	ErrWebSocketProtocol ex.Class = "websocket protocol error"
+	// ErrWebSocketMessageTooBig is returned if a message exceeds the maximum message size.
+	ErrWebSocketMessageTooBig ex.Class = "websocket message too big"
+)
+
+const (
+	webSocketFinalBit          = 0x80
+	webSocketRSVBits           = 0x70
+	webSocketMaskBit           = 0x80
+	webSocketMaxControlPayload = 125
+)
+
+// WebSocketCloseError is returned from reads when the peer closes the connection.
+// It is not an exception, so `ex.Is(err, ErrWebSocketClosed)` does not match it; use `IsErrWebSocketClosed`.
+type WebSocketCloseError struct {
+	Code   int
+	Reason string
+}
+
+// Error implements error.
+func (wce *WebSocketCloseError) Error() string {
+	return ErrWebSocketClosed.Error() + ": " + webSocketCloseText(wce.Code, wce.Reason)
+}
+
+// IsErrWebSocketClosed returns if an error is the result of the websocket connection closing,
+// either a `*WebSocketCloseError` or an exception with the `ErrWebSocketClosed` class.
+func IsErrWebSocketClosed(err error) bool {
+	if err == nil {
+		return false
+	}
+	if _, ok := err.(*WebSocketCloseError); ok {
+		return true
+	}
+	return ex.Is(err, ErrWebSocketClosed)
+}
+
+// newWebSocketConn returns a new websocket connection.
+// Client connections mask the frames they write, server connections do not.
+func newWebSocketConn(conn net.Conn, reader *bufio.Reader, isServer bool, cfg WebSocketUpgrader) *WebSocketConn {
+	if reader == nil {
+		reader = bufio.NewReader(conn)
+	}
+	return &WebSocketConn{
+		conn:            conn,
+		reader:          reader,
+		isServer:        isServer,
+		maxMessageBytes: cfg.MaxMessageBytesOrDefault(),
+		writeTimeout:    cfg.WriteTimeoutOrDefault(),
+		pongTimeout:     cfg.PongTimeoutOrDefault(),
+		done:            make(chan struct{}),
+	}
+}
+
+// WebSocketConn is an open websocket connection.
+/*
+Reads must be made from a single goroutine; writes are safe from multiple goroutines.
+Pings from the peer are answered automatically during reads, and the connection
+is closed if nothing (including pongs to our keepalive pings) is read from the peer
+within the pong timeout.
+*/
+type WebSocketConn struct {
+	// Subprotocol is the negotiated subprotocol, if any.
+	Subprotocol string
+
+	conn            net.Conn
+	reader          *bufio.Reader
+	isServer        bool
+	maxMessageBytes int64
+	writeTimeout    time.Duration
+	pongTimeout     time.Duration
+
+	writeLock sync.Mutex
+	closeOnce sync.Once
+	closeSent bool
+	done      chan struct{}
+}
+
+// RemoteAddr returns the remote network address.
+func (wsc *WebSocketConn) RemoteAddr() net.Addr {
+	return wsc.conn.RemoteAddr()
+}
+
+// Done returns a channel that is closed when the connection is closed.
+func (wsc *WebSocketConn) Done() <-chan struct{} {
+	return wsc.done
+}
+
+// ReadMessage reads the next text or binary message from the connection.
+// If the peer closes the connection, a `*WebSocketCloseError` is returned.
+func (wsc *WebSocketConn) ReadMessage() (messageType int, data []byte, err error) {
+	for {
+		var final bool
+		var opcode int
+		var payload []byte
+		final, opcode, payload, err = wsc.readFrame()
+		if err != nil {
+			return
+		}
+
+		switch opcode {
+		case WebSocketMessagePing:
+			if err = wsc.WriteControl(WebSocketMessagePong, payload); err != nil {
+				return
+			}
+			continue
+		case WebSocketMessagePong:
+			continue
+		case WebSocketMessageClose:
+			closeErr := &WebSocketCloseError{Code: WebSocketCloseNoStatus}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			wsc.Close(closeErr.Code, "")
+			err = closeErr
+			return
+		case WebSocketMessageContinuation:
+			if messageType == 0 {
+				err = wsc.fail(WebSocketCloseProtocolError, ex.New(ErrWebSocketProtocol, ex.OptMessage("unexpected continuation frame")))
+				return
+			}
+		case WebSocketMessageText, WebSocketMessageBinary:
+			if messageType != 0 {
+				err = wsc.fail(WebSocketCloseProtocolError, ex.New(ErrWebSocketProtocol, ex.OptMessage("expected continuation frame")))
+				return
+			}
+			messageType = opcode
+		default:
+			err = wsc.fail(WebSocketCloseProtocolError, ex.New(ErrWebSocketProtocol, ex.OptMessagef("unknown opcode %d", opcode)))
+			return
+		}
+
+		if int64(len(data)+len(payload)) > wsc.maxMessageBytes {
+			err = wsc.fail(WebSocketCloseMessageTooBig, ex.New(ErrWebSocketMessageTooBig))
+			return
+		}
+		data = append(data, payload...)
+		if final {
+			if messageType == WebSocketMessageText && !utf8.Valid(data) {
+				err = wsc.fail(WebSocketCloseInvalidPayload, ex.New(ErrWebSocketProtocol, ex.OptMessage("invalid utf-8 in text message")))
+				return
+			}
+			return
+		}
+	}
+}
+
+// ReadJSON reads the next message and deserializes it as json into a given object.
+func (wsc *WebSocketConn) ReadJSON(dst interface{}) error {
+	_, data, err := wsc.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return ex.New(json.Unmarshal(data, dst))
+}
+
+// WriteMessage writes a text or binary message.
+func (wsc *WebSocketConn) WriteMessage(messageType int, data []byte) error {
+	if messageType != WebSocketMessageText && messageType != WebSocketMessageBinary {
+		return ex.New(ErrWebSocketProtocol, ex.OptMessagef("invalid message type %d", messageType))
+	}
+	return wsc.writeFrame(messageType, data)
+}
+
+// WriteText writes a text message.
+func (wsc *WebSocketConn) WriteText(text string) error {
+	return wsc.writeFrame(WebSocketMessageText, []byte(text))
+}
+
+// WriteJSON serializes an object as json and writes it as a text message.
+func (wsc *WebSocketConn) WriteJSON(obj interface{}) error {
+	contents, err := json.Marshal(obj)
+	if err != nil {
+		return ex.New(err)
+	}
+	return wsc.writeFrame(WebSocketMessageText, contents)
+}
+
+// WriteControl writes a ping, pong or close control message.
+func (wsc *WebSocketConn) WriteControl(messageType int, data []byte) error {
+	if messageType != WebSocketMessagePing && messageType != WebSocketMessagePong && messageType != WebSocketMessageClose {
+		return ex.New(ErrWebSocketProtocol, ex.OptMessagef("invalid control message type %d", messageType))
+	}
+	if len(data) > webSocketMaxControlPayload {
+		return ex.New(ErrWebSocketProtocol, ex.OptMessage("control message payload too large"))
+	}
+	return wsc.writeFrame(messageType, data)
+}
+
+// Ping sends a ping to the peer.
+func (wsc *WebSocketConn) Ping() error {
+	return wsc.WriteControl(WebSocketMessagePing, nil)
+}
+
+// Close sends a close message with a given code and reason if one hasn't been sent already.
+// Further writes will fail; the underlying connection is closed once the handler returns.
+func (wsc *WebSocketConn) Close(code int, reason string) error {
+	var err error
+	wsc.closeOnce.Do(func() {
+		var payload []byte
+		if code != WebSocketCloseNoStatus {
+			payload = make([]byte, 2, 2+len(reason))
+			binary.BigEndian.PutUint16(payload, uint16(code))
+			payload = append(payload, reason...)
+			if len(payload) > webSocketMaxControlPayload {
+				payload = payload[:webSocketMaxControlPayload]
+			}
+		}
+		err = wsc.writeFrame(WebSocketMessageClose, payload)
+
+		wsc.writeLock.Lock()
+		wsc.closeSent = true
+		wsc.writeLock.Unlock()
+		close(wsc.done)
+	})
+	return err
+}
+
+// Shutdown sends a close message if one hasn't been sent, and closes the underlying connection.
+func (wsc *WebSocketConn) Shutdown() error {
+	wsc.Close(WebSocketCloseNormal, "")
+	return wsc.conn.Close()
+}
+
+// keepalive sends pings on a given interval until the connection is closed.
+func (wsc *WebSocketConn) keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-wsc.done:
+			return
+		case <-ticker.C:
+			if err := wsc.Ping(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// fail closes the connection with a given code and returns the error.
+func (wsc *WebSocketConn) fail(code int, err error) error {
+	wsc.Close(code, "")
+	return err
+}
+
+func (wsc *WebSocketConn) readFrame() (final bool, opcode int, payload []byte, err error) {
+	if wsc.pongTimeout > 0 {
+		wsc.conn.SetReadDeadline(time.Now().Add(wsc.pongTimeout))
+	}
+
+	var header [2]byte
+	if _, err = io.ReadFull(wsc.reader, header[:]); err != nil {
+		err = ex.New(ErrWebSocketClosed, ex.OptInner(err))
+		return
+	}
+	final = header[0]&webSocketFinalBit != 0
+	opcode = int(header[0] & 0x0F)
+	masked := header[1]&webSocketMaskBit != 0
+	length := int64(header[1] & 0x7F)
+
+	if header[0]&webSocketRSVBits != 0 {
+		err = wsc.fail(WebSocketCloseProtocolError, ex.New(ErrWebSocketProtocol, ex.OptMessage("reserved bits set")))
+		return
+	}
+	if masked != wsc.isServer {
+		err = wsc.fail(WebSocketCloseProtocolError, ex.New(ErrWebSocketProtocol, ex.OptMessage("invalid frame masking")))
+		return
+	}
+	if opcode >= WebSocketMessageClose && (!final || length > webSocketMaxControlPayload) {
+		err = wsc.fail(WebSocketCloseProtocolError, ex.New(ErrWebSocketProtocol, ex.OptMessage("invalid control frame")))
+		return
+	}
+
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err = io.ReadFull(wsc.reader, extended[:]); err != nil {
+			err = ex.New(ErrWebSocketClosed, ex.OptInner(err))
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err = io.ReadFull(wsc.reader, extended[:]); err != nil {
+			err = ex.New(ErrWebSocketClosed, ex.OptInner(err))
+			return
+		}
+		length = int64(binary.BigEndian.Uint64(extended[:]))
+	}
+	if length < 0 || length > wsc.maxMessageBytes {
+		err = wsc.fail(WebSocketCloseMessageTooBig, ex.New(ErrWebSocketMessageTooBig))
+		return
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(wsc.reader, mask[:]); err != nil {
+			err = ex.New(ErrWebSocketClosed, ex.OptInner(err))
+			return
+		}
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(wsc.reader, payload); err != nil {
+		err = ex.New(ErrWebSocketClosed, ex.OptInner(err))
+		return
+	}
+	if masked {
+		applyWebSocketMask(mask, payload)
+	}
+	return
+}
+
+func (wsc *WebSocketConn) writeFrame(opcode int, payload []byte) error {
+	wsc.writeLock.Lock()
+	defer wsc.writeLock.Unlock()
+	if wsc.closeSent {
+		return ex.New(ErrWebSocketClosed)
+	}
+
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, webSocketFinalBit|byte(opcode))
+
+	var maskBit byte
+	if !wsc.isServer {
+		maskBit = webSocketMaskBit
+	}
+	switch {
+	case len(payload) <= 125:
+		frame = append(frame, maskBit|byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, maskBit|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(len(payload)))
+	default:
+		frame = append(frame, maskBit|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(len(payload)))
+	}
+
+	if wsc.isServer {
+		frame = append(frame, payload...)
+	} else {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return ex.New(err)
+		}
+		frame = append(frame, mask[:]...)
+		offset := len(frame)
+		frame = append(frame, payload...)
+		applyWebSocketMask(mask, frame[offset:])
+	}
+
+	if wsc.writeTimeout > 0 {
+		wsc.conn.SetWriteDeadline(time.Now().Add(wsc.writeTimeout))
+	}
+	if _, err := wsc.conn.Write(frame); err != nil {
+		return ex.New(ErrWebSocketClosed, ex.OptInner(err))
+	}
+	return nil
+}
+
+func applyWebSocketMask(mask [4]byte, payload []byte) {
+	for index := range payload {
+		payload[index] ^= mask[index%4]
+	}
+}
+
+func webSocketCloseText(code int, reason string) string {
+	if reason != "" {
+		return reason
+	}
+	switch code {
+	case WebSocketCloseNormal:
+		return "normal closure"
+	case WebSocketCloseGoingAway:
+		return "going away"
+	case WebSocketCloseNoStatus:
+		return "no status"
+	}
+	return "code " + strconv.Itoa(code)
+}
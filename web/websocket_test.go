@@ -0,0 +1,223 @@
+package web
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/blend/go-sdk/assert"
+)
+
+const testWebSocketKey = "dGhlIHNhbXBsZSBub25jZQ=="
+
+func dialTestWebSocket(addr, path string, headers map[string]string) (*WebSocketConn, *http.Response, error) {
+	netConn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	allHeaders := map[string]string{
+		HeaderConnection:          "Upgrade",
+		HeaderUpgrade:             "websocket",
+		HeaderSecWebSocketVersion: WebSocketVersion,
+		HeaderSecWebSocketKey:     testWebSocketKey,
+	}
+	for key, value := range headers {
+		allHeaders[key] = value
+	}
+	req := fmt.Sprintf("GET %s HTTP/1.1\r\nHost: %s\r\n", path, addr)
+	for key, value := range allHeaders {
+		req += key + ": " + value + "\r\n"
+	}
+	if _, err = netConn.Write([]byte(req + "\r\n")); err != nil {
+		return nil, nil, err
+	}
+	reader := bufio.NewReader(netConn)
+	res, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		netConn.Close()
+		return nil, res, nil
+	}
+	return newWebSocketConn(netConn, reader, false, WebSocketUpgrader{}), res, nil
+}
+
+func startTestWebSocketApp(options ...Option) *App {
+	app := New(append([]Option{OptBindAddr(DefaultMockBindAddr)}, options...)...)
+	app.WS("/echo", func(_ *Ctx, conn *WebSocketConn) error {
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return err
+			}
+			if err = conn.WriteMessage(messageType, data); err != nil {
+				return err
+			}
+		}
+	})
+	go app.Start()
+	<-app.NotifyStarted()
+	return app
+}
+
+func TestWebSocketAccept(t *testing.T) {
+	assert := assert.New(t)
+	// from RFC 6455 section 1.3
+	assert.Equal("s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", webSocketAccept(testWebSocketKey))
+}
+
+func TestAppWSEcho(t *testing.T) {
+	assert := assert.New(t)
+
+	app := startTestWebSocketApp()
+	defer app.Stop()
+
+	conn, res, err := dialTestWebSocket(app.Listener.Addr().String(), "/echo", map[string]string{
+		HeaderAcceptEncoding: "gzip",
+	})
+	assert.Nil(err)
+	assert.Equal(http.StatusSwitchingProtocols, res.StatusCode)
+	assert.Equal("s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", res.Header.Get(HeaderSecWebSocketAccept))
+	assert.Empty(res.Header.Get(HeaderContentEncoding))
+	defer conn.Shutdown()
+
+	assert.Nil(conn.WriteText("hello"))
+	messageType, data, err := conn.ReadMessage()
+	assert.Nil(err)
+	assert.Equal(WebSocketMessageText, messageType)
+	assert.Equal("hello", string(data))
+
+	large := make([]byte, 70000)
+	assert.Nil(conn.WriteMessage(WebSocketMessageBinary, large))
+	messageType, data, err = conn.ReadMessage()
+	assert.Nil(err)
+	assert.Equal(WebSocketMessageBinary, messageType)
+	assert.Len(data, len(large))
+
+	// pings are answered while reading, and the pong is skipped by our read.
+	assert.Nil(conn.Ping())
+	assert.Nil(conn.WriteJSON(map[string]string{"foo": "bar"}))
+	var decoded map[string]string
+	assert.Nil(conn.ReadJSON(&decoded))
+	assert.Equal("bar", decoded["foo"])
+}
+
+func TestAppWSHandshakeErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	app := startTestWebSocketApp()
+	defer app.Stop()
+
+	_, res, err := dialTestWebSocket(app.Listener.Addr().String(), "/echo", map[string]string{
+		HeaderOrigin: "https://evil.example.com",
+	})
+	assert.Nil(err)
+	assert.Equal(http.StatusForbidden, res.StatusCode)
+
+	_, res, err = dialTestWebSocket(app.Listener.Addr().String(), "/echo", map[string]string{
+		HeaderSecWebSocketVersion: "8",
+	})
+	assert.Nil(err)
+	assert.Equal(http.StatusUpgradeRequired, res.StatusCode)
+	assert.Equal(WebSocketVersion, res.Header.Get(HeaderSecWebSocketVersion))
+
+	mockRes, err := MockGet(app, "/echo").DiscardWithResponse()
+	assert.Nil(err)
+	assert.Equal(http.StatusBadRequest, mockRes.StatusCode)
+}
+
+func TestAppWSMiddleware(t *testing.T) {
+	assert := assert.New(t)
+
+	var handshakes int
+	app := New(OptBindAddr(DefaultMockBindAddr))
+	app.WS("/", func(_ *Ctx, conn *WebSocketConn) error {
+		return conn.WriteText("ok")
+	}, func(action Action) Action {
+		return func(ctx *Ctx) Result {
+			handshakes++
+			return action(ctx)
+		}
+	})
+	go app.Start()
+	<-app.NotifyStarted()
+	defer app.Stop()
+
+	conn, _, err := dialTestWebSocket(app.Listener.Addr().String(), "/", nil)
+	assert.Nil(err)
+	defer conn.Shutdown()
+	_, data, err := conn.ReadMessage()
+	assert.Nil(err)
+	assert.Equal("ok", string(data))
+	assert.Equal(1, handshakes)
+
+	_, _, err = conn.ReadMessage()
+	assert.True(IsErrWebSocketClosed(err))
+	closeErr, ok := err.(*WebSocketCloseError)
+	assert.True(ok)
+	assert.Equal(WebSocketCloseNormal, closeErr.Code)
+}
+
+func TestAppWSClosedOnStop(t *testing.T) {
+	assert := assert.New(t)
+
+	app := startTestWebSocketApp(func(a *App) { a.Config.ShutdownGracePeriod = time.Second })
+	conn, _, err := dialTestWebSocket(app.Listener.Addr().String(), "/echo", nil)
+	assert.Nil(err)
+	defer conn.Shutdown()
+
+	// make sure the handler is running before stopping.
+	assert.Nil(conn.WriteText("hello"))
+	_, _, err = conn.ReadMessage()
+	assert.Nil(err)
+
+	stopped := make(chan error)
+	go func() { stopped <- app.Stop() }()
+
+	_, _, err = conn.ReadMessage()
+	closeErr, ok := err.(*WebSocketCloseError)
+	assert.True(ok)
+	assert.Equal(WebSocketCloseGoingAway, closeErr.Code)
+
+	select {
+	case err = <-stopped:
+		assert.Nil(err)
+	case <-time.After(5 * time.Second):
+		assert.FailNow("app did not stop")
+	}
+}
+
+func TestAppWSStopDoesNotWaitForStuckHandlers(t *testing.T) {
+	assert := assert.New(t)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	app := New(OptBindAddr(DefaultMockBindAddr), func(a *App) { a.Config.ShutdownGracePeriod = 50 * time.Millisecond })
+	started := make(chan struct{})
+	app.WS("/stuck", func(_ *Ctx, _ *WebSocketConn) error {
+		close(started)
+		<-release
+		return nil
+	})
+	go app.Start()
+	<-app.NotifyStarted()
+
+	conn, _, err := dialTestWebSocket(app.Listener.Addr().String(), "/stuck", nil)
+	assert.Nil(err)
+	defer conn.Shutdown()
+	<-started
+
+	stopped := make(chan error)
+	go func() { stopped <- app.Stop() }()
+	select {
+	case err = <-stopped:
+		assert.Nil(err)
+	case <-time.After(5 * time.Second):
+		assert.FailNow("app did not stop")
+	}
+}
@@ -41,6 +41,12 @@ func (xrp XMLResultProvider) InternalError(err error) Result {
 
 // BadRequest returns a service response.
 func (xrp XMLResultProvider) BadRequest(err error) Result {
+	if fieldErrors, ok := AsFieldErrors(err); ok {
+		return &XMLResult{
+			StatusCode: http.StatusBadRequest,
+			Response:   NewFieldErrorsResponse(fieldErrors),
+		}
+	}
 	if err != nil {
 		return &XMLResult{
 			StatusCode: http.StatusBadRequest,
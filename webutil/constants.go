@@ -17,6 +17,7 @@ var (
 	HeaderXForwardedProto         = http.CanonicalHeaderKey("X-Forwarded-Proto")
 	HeaderXForwardedScheme        = http.CanonicalHeaderKey("X-Forwarded-Scheme")
 	HeaderXRealIP                 = http.CanonicalHeaderKey("X-Real-IP")
+	HeaderXRequestID              = http.CanonicalHeaderKey("X-Request-ID")
 	HeaderAcceptEncoding          = http.CanonicalHeaderKey("Accept-Encoding")
 	HeaderSetCookie               = http.CanonicalHeaderKey("Set-Cookie")
 	HeaderCookie                  = http.CanonicalHeaderKey("Cookie")
@@ -1,6 +1,8 @@
 package webutil
 
 import (
+	"bytes"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -40,3 +42,15 @@ func DetectContentType(path string) (string, error) {
 	}
 	return http.DetectContentType(header), nil
 }
+
+// DetectContentTypeReader sniffs the content type of the start of a stream.
+// It returns the content type and a reader that replays the sniffed bytes before the rest of the stream.
+func DetectContentTypeReader(r io.Reader) (string, io.Reader, error) {
+	header := make([]byte, 512)
+	n, err := io.ReadFull(r, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", nil, ex.New(err)
+	}
+	header = header[:n]
+	return http.DetectContentType(header), io.MultiReader(bytes.NewReader(header), r), nil
+}